@@ -0,0 +1,103 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package benchls
+
+import "fmt"
+
+// Aggregations are the ways that Aggregate can combine replicates.
+var Aggregations = []string{"mean", "median", "min", "all"}
+
+// Aggregate combines the replicates in s, which are the observations of the
+// same benchmark name, like those of go test -count, into one observation
+// each.  "mean" and "median" combine each explanatory term, input variable
+// and the response separately, "min" keeps the replicate with the smallest
+// response, and "all" keeps every replicate as its own observation.  Fits of
+// an aggregated sample have one observation, and so one degree of freedom,
+// per benchmark name.
+func Aggregate(s Sample, how string) (Sample, error) {
+	var combine func([]float64) float64
+	switch how {
+	case "all":
+		return s, nil
+	case "mean":
+		combine = mean
+	case "median":
+		combine = median
+	case "min":
+	default:
+		return s, fmt.Errorf("unknown aggregation %q, must be one of %q", how, Aggregations)
+	}
+	if len(s.Y) == 0 {
+		return s, nil
+	}
+
+	// the replicates of each name, in order of their first observation
+	var names []string
+	reps := make(map[string][]int)
+	for i, name := range s.Names {
+		if reps[name] == nil {
+			names = append(names, name)
+		}
+		reps[name] = append(reps[name], i)
+	}
+
+	stride := len(s.X) / len(s.Y)
+	agg := s
+	agg.X = make([]float64, 0, len(names)*stride)
+	agg.Y = make([]float64, 0, len(names))
+	agg.Names = names
+	agg.Vars = nil
+	if s.Vars != nil {
+		agg.Vars = make([]map[string]float64, 0, len(names))
+	}
+	for _, name := range names {
+		idx := reps[name]
+		if combine == nil {
+			best := idx[0]
+			for _, i := range idx[1:] {
+				if s.Y[i] < s.Y[best] {
+					best = i
+				}
+			}
+			agg.X = append(agg.X, s.X[best*stride:(best+1)*stride]...)
+			agg.Y = append(agg.Y, s.Y[best])
+			if s.Vars != nil {
+				agg.Vars = append(agg.Vars, s.Vars[best])
+			}
+			continue
+		}
+
+		vals := make([]float64, len(idx))
+		for j := 0; j < stride; j++ {
+			for k, i := range idx {
+				vals[k] = s.X[i*stride+j]
+			}
+			agg.X = append(agg.X, combine(vals))
+		}
+		for k, i := range idx {
+			vals[k] = s.Y[i]
+		}
+		agg.Y = append(agg.Y, combine(vals))
+		if s.Vars != nil {
+			vars := make(map[string]float64, len(s.Vars[idx[0]]))
+			for v := range s.Vars[idx[0]] {
+				for k, i := range idx {
+					vals[k] = s.Vars[i][v]
+				}
+				vars[v] = combine(vals)
+			}
+			agg.Vars = append(agg.Vars, vars)
+		}
+	}
+	return agg, nil
+}
+
+func mean(xs []float64) float64 {
+	var sum float64
+	for _, x := range xs {
+		sum += x
+	}
+	return sum / float64(len(xs))
+}
@@ -0,0 +1,52 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package benchls
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAggregate(t *testing.T) {
+	s := Sample{
+		X:     []float64{10, 1, 10, 1, 10, 1, 100, 1, 100, 1},
+		Y:     []float64{12, 10, 20, 104, 100},
+		Names: []string{"Sort10", "Sort10", "Sort10", "Sort100", "Sort100"},
+		Vars: []map[string]float64{
+			{"N": 10}, {"N": 10}, {"N": 10}, {"N": 100}, {"N": 100},
+		},
+	}
+	for _, test := range []struct {
+		how   string
+		y     []float64
+		names []string
+	}{
+		{"mean", []float64{14, 102}, []string{"Sort10", "Sort100"}},
+		{"median", []float64{12, 102}, []string{"Sort10", "Sort100"}},
+		{"min", []float64{10, 100}, []string{"Sort10", "Sort100"}},
+		{"all", s.Y, s.Names},
+	} {
+		agg, err := Aggregate(s, test.how)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", test.how, err)
+			continue
+		}
+		if !reflect.DeepEqual(agg.Y, test.y) {
+			t.Errorf("%s: expected responses %v, got %v", test.how, test.y, agg.Y)
+		}
+		if !reflect.DeepEqual(agg.Names, test.names) {
+			t.Errorf("%s: expected names %q, got %q", test.how, test.names, agg.Names)
+		}
+		if len(agg.X) != 2*len(agg.Y) || len(agg.Vars) != len(agg.Y) {
+			t.Errorf("%s: expected %d observations, got %d terms and %d variables", test.how, len(agg.Y), len(agg.X), len(agg.Vars))
+		}
+		if agg.X[0] != 10 || agg.Vars[len(agg.Vars)-1]["N"] != 100 {
+			t.Errorf("%s: expected the inputs to be kept, got %v and %v", test.how, agg.X, agg.Vars)
+		}
+	}
+	if _, err := Aggregate(s, "max"); err == nil {
+		t.Error("expected an error for an unknown aggregation")
+	}
+}
@@ -0,0 +1,143 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package benchls
+
+import (
+	"math"
+	"sort"
+)
+
+// Piecewise is a fit of a sample in segments, split at breakpoints in one of
+// its explanatory variables, with each segment fit separately.
+type Piecewise struct {
+	Breaks   []float64 // between the segments, in increasing order
+	Segments []*Fit
+	BIC      float64
+}
+
+// Segment fits s piecewise in explanatory variable col, with up to k
+// breakpoints.  For each number of breakpoints, they are placed to minimize
+// the total residual sum of squares, and the number with the lowest BIC is
+// chosen.  Each segment needs more observations than the model has terms,
+// and a breakpoint lies midway between two distinct values of col.  The
+// segments are fit with opts.  It returns nil if even a single segment cannot
+// be fit.
+func Segment(s Sample, col, k int, opts Options) *Piecewise {
+	n := len(s.Y)
+	if n == 0 {
+		return nil
+	}
+	p := len(s.X) / n
+
+	// sort the observations by col
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+	sort.Stable(byCol{order, s.X, p, col})
+	sorted := Sample{X: make([]float64, 0, len(s.X)), Y: make([]float64, 0, n)}
+	for _, i := range order {
+		sorted.X = append(sorted.X, s.X[i*p:(i+1)*p]...)
+		sorted.Y = append(sorted.Y, s.Y[i])
+		if s.Names != nil {
+			sorted.Names = append(sorted.Names, s.Names[i])
+		}
+	}
+	x := func(i int) float64 { return sorted.X[i*p+col] }
+
+	// rss[i][j] is the residual sum of squares of a segment of observations
+	// i through j-1, or +Inf if it cannot be fit
+	rss := make([][]float64, n+1)
+	for i := range rss {
+		rss[i] = make([]float64, n+1)
+		for j := range rss[i] {
+			rss[i][j] = math.Inf(1)
+			if j-i <= p {
+				continue
+			}
+			seg := sub(sorted, i, j, p)
+			if m := Estimate(seg, opts); m != nil {
+				rss[i][j] = 0
+				for o, y := range seg.Y {
+					r := y
+					for c, xc := range seg.X[o*p : (o+1)*p] {
+						r -= m[c] * xc
+					}
+					rss[i][j] += r * r
+				}
+			}
+		}
+	}
+
+	// best[m][j] is the least total rss of fitting observations 0 through
+	// j-1 in m+1 segments, and from[m][j] is where the last one starts
+	best := [][]float64{rss[0]}
+	from := [][]int{make([]int, n+1)}
+	var pw *Piecewise
+	for m := 0; m <= k; m++ {
+		if m > 0 {
+			b, f := make([]float64, n+1), make([]int, n+1)
+			for j := range b {
+				b[j] = math.Inf(1)
+				for i := 1; i < j; i++ {
+					if x(i-1) == x(i) {
+						continue // no break between equal values
+					}
+					if t := best[m-1][i] + rss[i][j]; t < b[j] {
+						b[j], f[j] = t, i
+					}
+				}
+			}
+			best, from = append(best, b), append(from, f)
+		}
+		total := best[m][n]
+		if math.IsInf(total, 1) {
+			continue
+		}
+		// each segment has p coefficients, and each break is a parameter too
+		bic := float64(n)*math.Log(total/float64(n)) + float64((m+1)*p+m)*math.Log(float64(n))
+		if pw != nil && !(bic < pw.BIC) {
+			continue
+		}
+		starts := make([]int, m+1)
+		for j, mm := n, m; mm > 0; mm-- {
+			j = from[mm][j]
+			starts[mm] = j
+		}
+		pw = &Piecewise{BIC: bic}
+		for i, start := range starts {
+			end := n
+			if i+1 < len(starts) {
+				end = starts[i+1]
+				pw.Breaks = append(pw.Breaks, (x(end-1)+x(end))/2)
+			}
+			pw.Segments = append(pw.Segments, NewFit(sub(sorted, start, end, p), opts))
+		}
+	}
+	return pw
+}
+
+// sub returns observations i through j-1 of s, which has p explanatory
+// variables.
+func sub(s Sample, i, j, p int) Sample {
+	seg := Sample{X: s.X[i*p : j*p], Y: s.Y[i:j]}
+	if s.Names != nil {
+		seg.Names = s.Names[i:j]
+	}
+	return seg
+}
+
+// byCol sorts observation indexes by one of their explanatory variables.
+type byCol struct {
+	order  []int
+	x      []float64
+	p, col int
+}
+
+func (b byCol) Len() int      { return len(b.order) }
+func (b byCol) Swap(i, j int) { b.order[i], b.order[j] = b.order[j], b.order[i] }
+func (b byCol) Less(i, j int) bool {
+	return b.x[b.order[i]*b.p+b.col] < b.x[b.order[j]*b.p+b.col]
+}
@@ -0,0 +1,50 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package benchls
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSegment(t *testing.T) {
+	// two regimes, y = x below 10 and y = 5x - 30 from there
+	var s Sample
+	for i := 20; i >= 1; i-- {
+		x := float64(i)
+		y := x
+		if x >= 10 {
+			y = 5*x - 30
+		}
+		s.X = append(s.X, x, 1)
+		s.Y = append(s.Y, y+0.1*math.Cos(math.Pi*x)) // alternating noise
+	}
+
+	pw := Segment(s, 0, 2, Options{})
+	if pw == nil {
+		t.Fatal("expected a piecewise fit")
+	}
+	if len(pw.Breaks) != 1 || pw.Breaks[0] != 9.5 {
+		t.Fatalf("expected a break at 9.5, got %v", pw.Breaks)
+	}
+	for i, want := range []float64{1, 5} {
+		if got := pw.Segments[i].Model[0]; math.Abs(got-want) > 0.05 {
+			t.Errorf("expected slope %g in segment %d, got %g", want, i, got)
+		}
+	}
+
+	// a single regime needs no breaks
+	for i := range s.Y {
+		s.Y[i] = 3*s.X[2*i] + 0.1*math.Cos(math.Pi*s.X[2*i])
+	}
+	if pw := Segment(s, 0, 2, Options{}); pw == nil || len(pw.Breaks) != 0 {
+		t.Errorf("expected no breaks, got %v", pw)
+	}
+
+	// too few points for even one segment
+	if pw := Segment(Sample{X: []float64{1, 1, 2, 1}, Y: []float64{1, 2}}, 0, 1, Options{}); pw != nil {
+		t.Errorf("expected no fit, got %v", pw)
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package benchls
+
+import (
+	"sort"
+	"strings"
+)
+
+// Categorical finds categorical variables, like algo in
+// BenchmarkSort/algo=quick, among the elements of the groups of another
+// Extractor, and encodes each of their values as an indicator variable, like
+// algo_quick, which is 1 for the benchmarks with that value and 0 for the
+// others.  Groups that differ only in categorical variables are pooled, so
+// that one model can have an offset or slope for each value.
+type Categorical struct {
+	Extractor
+	levels map[string][]string // sorted values of each categorical variable
+}
+
+// NewCategorical finds the values of the named categorical variables in
+// benchSet.
+func NewCategorical(ex Extractor, benchSet Set, names []string) Categorical {
+	c := Categorical{Extractor: ex, levels: make(map[string][]string)}
+	for _, name := range names {
+		c.levels[name] = nil
+	}
+	for name := range benchSet {
+		group, _, ok := ex.Extract(name)
+		if !ok {
+			continue
+		}
+		for _, elem := range strings.Split(group, "/") {
+			k, v := splitElem(elem)
+			if levels, ok := c.levels[k]; ok && !contains(levels, v) {
+				c.levels[k] = append(levels, v)
+			}
+		}
+	}
+	for _, levels := range c.levels {
+		sort.Strings(levels)
+	}
+	return c
+}
+
+// Levels returns the sorted values of the named categorical variable.
+func (c Categorical) Levels(name string) []string {
+	return c.levels[name]
+}
+
+// Indicator returns the name of the indicator variable of a value of a
+// categorical variable, like algo_quick.  Characters that cannot be in an
+// identifier are replaced by underscores.
+func Indicator(name, level string) string {
+	return name + "_" + strings.Map(func(r rune) rune {
+		if r == '_' || 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9' {
+			return r
+		}
+		return '_'
+	}, level)
+}
+
+func (c Categorical) VarNames() map[string]struct{} {
+	names := c.Extractor.VarNames()
+	for name, levels := range c.levels {
+		for _, l := range levels {
+			names[Indicator(name, l)] = struct{}{}
+		}
+	}
+	return names
+}
+
+func (c Categorical) Extract(name string) (string, map[string]float64, bool) {
+	group, vars, ok := c.Extractor.Extract(name)
+	if !ok {
+		return "", nil, false
+	}
+	elems := strings.Split(group, "/")
+	kept := elems[:1]
+	found := make(map[string]string)
+	for _, elem := range elems[1:] {
+		k, v := splitElem(elem)
+		if _, ok := c.levels[k]; ok {
+			found[k] = v
+			continue
+		}
+		kept = append(kept, elem)
+	}
+	for k, levels := range c.levels {
+		for _, l := range levels {
+			vars[Indicator(k, l)] = 0
+		}
+		if v, ok := found[k]; ok {
+			vars[Indicator(k, v)] = 1
+		}
+	}
+	return strings.Join(kept, "/"), vars, true
+}
+
+// splitElem splits an element of a group name, like algo=quick, into its key
+// and value.  Elements without a value have an empty key.
+func splitElem(elem string) (string, string) {
+	if i := strings.Index(elem, "="); i > 0 {
+		return elem[:i], elem[i+1:]
+	}
+	return "", elem
+}
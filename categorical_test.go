@@ -0,0 +1,69 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package benchls
+
+import (
+	"math"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestCategorical(t *testing.T) {
+	// quick is 100 ns/N + 50, heap 200 ns/N
+	s := `
+BenchmarkSort/algo=quick/N=10-4   	 100	      1050 ns/op
+BenchmarkSort/algo=quick/N=100-4  	 100	     10050 ns/op
+BenchmarkSort/algo=quick/N=1000-4 	 100	    100050 ns/op
+BenchmarkSort/algo=heap/N=10-4    	 100	      2000 ns/op
+BenchmarkSort/algo=heap/N=100-4   	 100	     20000 ns/op
+BenchmarkSort/algo=heap/N=1000-4  	 100	    200000 ns/op
+BenchmarkCopy/N=10-4              	 100	        10 ns/op
+`
+	benchSet, err := ReadSet(strings.NewReader(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, ex := range []Extractor{
+		NewAutoExtractor(benchSet),
+		RegexpExtractor{regexp.MustCompile(`/algo=(?P<algo>[a-z]+)/N=(?P<N>\d+)-\d+$|/N=(?P<M>\d+)-\d+$`)},
+	} {
+		c := NewCategorical(ex, benchSet, []string{"algo"})
+		if got := strings.Join(c.Levels("algo"), ","); got != "heap,quick" {
+			t.Errorf("expected levels heap,quick, got %s", got)
+		}
+		if _, ok := c.VarNames()["algo_quick"]; !ok {
+			t.Errorf("expected the variable algo_quick, got %v", c.VarNames())
+		}
+		xExprs, err := NewExpressions("N, N * algo_quick, 1.0, algo_quick", c.VarNames(), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		yExpr, err := NewExpression("Y", map[string]struct{}{"Y": {}}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		samps := SampleGroup(benchSet, c, xExprs, yExpr, "NsPerOp")
+		samp, ok := samps["BenchmarkSort"]
+		if !ok || len(samp.Y) != 6 {
+			t.Fatalf("expected the algorithms to be pooled, got %v", samps)
+		}
+		if _, ok := samps["BenchmarkCopy"]; !ok {
+			t.Errorf("expected BenchmarkCopy without a categorical variable, got %v", samps)
+		}
+		m := Estimate(samp, Options{})
+		for i, want := range []float64{200, -100, 0, 50} {
+			if math.Abs(m[i]-want) > 1e-6 {
+				t.Errorf("coefficient %d: expected %g, got %g", i, want, m[i])
+			}
+		}
+	}
+}
+
+func TestIndicator(t *testing.T) {
+	if got := Indicator("algo", "radix-2.b"); got != "algo_radix_2_b" {
+		t.Errorf("expected algo_radix_2_b, got %s", got)
+	}
+}
@@ -0,0 +1,119 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build arrow
+// +build arrow
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/apache/arrow/go/arrow"
+	"github.com/apache/arrow/go/arrow/array"
+	"github.com/apache/arrow/go/arrow/ipc"
+	"github.com/apache/arrow/go/arrow/memory"
+	"github.com/jonlawlor/benchls"
+)
+
+// haveArrow is whether benchls was built with -tags arrow, to write -arrow.
+const haveArrow = true
+
+// writeArrow writes the per group samples and fits as Arrow IPC files named
+// samples.arrow and fits.arrow in dir.  Groups that could not be fit have null
+// coefficients.
+func writeArrow(dir string, xExprs []benchls.Expression, yExpr benchls.Expression, samps map[string]benchls.Sample, fits map[string]*benchls.Fit) error {
+	mem := memory.NewGoAllocator()
+
+	groups := make([]string, 0, len(samps))
+	for g := range samps {
+		groups = append(groups, g)
+	}
+	sort.Strings(groups)
+
+	// samples: group, one column per explanatory term, then the response
+	fields := []arrow.Field{{Name: "group", Type: arrow.BinaryTypes.String}}
+	for _, xExpr := range xExprs {
+		fields = append(fields, arrow.Field{Name: xExpr.String(), Type: arrow.PrimitiveTypes.Float64})
+	}
+	fields = append(fields, arrow.Field{Name: yExpr.String(), Type: arrow.PrimitiveTypes.Float64})
+	b := array.NewRecordBuilder(mem, arrow.NewSchema(fields, nil))
+	defer b.Release()
+	for _, g := range groups {
+		s := samps[g]
+		stride := len(xExprs)
+		for i, y := range s.Y {
+			b.Field(0).(*array.StringBuilder).Append(g)
+			for j, x := range s.X[i*stride : (i+1)*stride] {
+				b.Field(j + 1).(*array.Float64Builder).Append(x)
+			}
+			b.Field(stride + 1).(*array.Float64Builder).Append(y)
+		}
+	}
+	if err := writeRecord(filepath.Join(dir, "samples.arrow"), mem, b); err != nil {
+		return err
+	}
+
+	// fits: group, coefficient and confidence interval per term, then R^2
+	fields = []arrow.Field{{Name: "group", Type: arrow.BinaryTypes.String}}
+	for _, xExpr := range xExprs {
+		fields = append(fields,
+			arrow.Field{Name: xExpr.String(), Type: arrow.PrimitiveTypes.Float64, Nullable: true},
+			arrow.Field{Name: xExpr.String() + " ±", Type: arrow.PrimitiveTypes.Float64, Nullable: true})
+	}
+	fields = append(fields, arrow.Field{Name: "R^2", Type: arrow.PrimitiveTypes.Float64, Nullable: true})
+	fb := array.NewRecordBuilder(mem, arrow.NewSchema(fields, nil))
+	defer fb.Release()
+	for _, g := range groups {
+		fb.Field(0).(*array.StringBuilder).Append(g)
+		fit := fits[g]
+		for i := range xExprs {
+			coeff := fb.Field(2*i + 1).(*array.Float64Builder)
+			cint := fb.Field(2*i + 2).(*array.Float64Builder)
+			if fit == nil {
+				coeff.AppendNull()
+				cint.AppendNull()
+				continue
+			}
+			coeff.Append(fit.Model[i])
+			cint.Append(fit.Stats.CI[i])
+		}
+		r2 := fb.Field(len(fields) - 1).(*array.Float64Builder)
+		if fit == nil {
+			r2.AppendNull()
+		} else {
+			r2.Append(fit.Stats.RSquared)
+		}
+	}
+	return writeRecord(filepath.Join(dir, "fits.arrow"), mem, fb)
+}
+
+// writeRecord writes the contents of b as a single record batch to an Arrow
+// IPC file at path.
+func writeRecord(path string, mem memory.Allocator, b *array.RecordBuilder) error {
+	rec := b.NewRecord()
+	defer rec.Release()
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w, err := ipc.NewFileWriter(f, ipc.WithSchema(b.Schema()), ipc.WithAllocator(mem))
+	if err != nil {
+		f.Close()
+		return err
+	}
+	if err := w.Write(rec); err != nil {
+		w.Close()
+		f.Close()
+		return err
+	}
+	if err := w.Close(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
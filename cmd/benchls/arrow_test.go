@@ -0,0 +1,107 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build arrow
+// +build arrow
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/apache/arrow/go/arrow/array"
+	"github.com/apache/arrow/go/arrow/ipc"
+)
+
+// readRecord reads the only record batch of the Arrow IPC file at path.
+func readRecord(t *testing.T, path string) array.Record {
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	r, err := ipc.NewFileReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if n := r.NumRecords(); n != 1 {
+		t.Fatalf("%s: expected 1 record batch, got %d", path, n)
+	}
+	rec, err := r.Record(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec.Retain()
+	return rec
+}
+
+func TestWriteArrow(t *testing.T) {
+	xExprs, yExpr, samps, fits := testFits(t)
+	dir, err := ioutil.TempDir("", "benchls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := writeArrow(dir, xExprs, yExpr, samps, fits); err != nil {
+		t.Fatal(err)
+	}
+
+	// the samples of the groups, in order
+	rec := readRecord(t, filepath.Join(dir, "samples.arrow"))
+	defer rec.Release()
+	var names []string
+	for _, f := range rec.Schema().Fields() {
+		names = append(names, f.Name)
+	}
+	if len(names) != 4 || names[0] != "group" || names[1] != "N" || names[2] != "1.0" || names[3] != "Y" {
+		t.Fatalf("expected the columns group, N, 1.0 and Y, got %q", names)
+	}
+	if n := rec.NumRows(); n != 17 {
+		t.Fatalf("expected 17 samples, got %d", n)
+	}
+	groups, ys := rec.Column(0).(*array.String), rec.Column(3).(*array.Float64)
+	if g, y := groups.Value(0), ys.Value(0); g != "BenchmarkFast" || y != samps[g].Y[0] {
+		t.Errorf("expected the first sample to be BenchmarkFast, %g, got %s, %g", samps["BenchmarkFast"].Y[0], g, y)
+	}
+	if g := groups.Value(16); g != "BenchmarkSlow" {
+		t.Errorf("expected the last sample to be of BenchmarkSlow, got %s", g)
+	}
+
+	// the fits, with nulls for the group that could not be fit
+	rec = readRecord(t, filepath.Join(dir, "fits.arrow"))
+	defer rec.Release()
+	if n, cols := rec.NumRows(), rec.NumCols(); n != 3 || cols != 6 {
+		t.Fatalf("expected 3 fits of 6 columns, got %d of %d", n, cols)
+	}
+	groups = rec.Column(0).(*array.String)
+	for i, g := range []string{"BenchmarkFast", "BenchmarkOne", "BenchmarkSlow"} {
+		if groups.Value(i) != g {
+			t.Errorf("row %d: expected %s, got %s", i, g, groups.Value(i))
+			continue
+		}
+		for j := 1; j < 6; j++ {
+			col := rec.Column(j).(*array.Float64)
+			if fits[g] == nil {
+				if !col.IsNull(i) {
+					t.Errorf("%s: expected %s to be null", g, rec.ColumnName(j))
+				}
+				continue
+			}
+			want := fits[g].Stats.RSquared
+			if j < 5 {
+				want = fits[g].Model[(j-1)/2]
+				if j%2 == 0 {
+					want = fits[g].Stats.CI[(j-1)/2]
+				}
+			}
+			if col.IsNull(i) || col.Value(i) != want {
+				t.Errorf("%s: expected %s to be %g, got %g", g, rec.ColumnName(j), want, col.Value(i))
+			}
+		}
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/jonlawlor/benchls"
+)
+
+// baseline returns the fit of the -baseline group, or an error if it could
+// not be fit.
+func baseline(fits map[string]*benchls.Fit) (*benchls.Fit, error) {
+	base, ok := fits[flagBaseline]
+	if !ok {
+		return nil, fmt.Errorf("-baseline: no group %s", flagBaseline)
+	}
+	if base == nil {
+		return nil, fmt.Errorf("-baseline: group %s could not be fit", flagBaseline)
+	}
+	return base, nil
+}
+
+// writeRatios writes each coefficient of every other group as a ratio to the
+// same coefficient of the -baseline group, with its confidence interval.
+func writeRatios(w io.Writer, terms []benchls.Expression, fits map[string]*benchls.Fit) error {
+	base, err := baseline(fits)
+	if err != nil {
+		return err
+	}
+
+	heading := newRow("group ÷ " + flagBaseline)
+	for _, x := range terms {
+		heading.add(x.String())
+	}
+	table := []*row{heading}
+	for _, g := range sortedGroups(fits, flagSort) {
+		if g == flagBaseline {
+			continue
+		}
+		r := newRow(g)
+		if fits[g] == nil {
+			for len(r.cols) < len(heading.cols) {
+				r.add("~")
+			}
+		} else {
+			for _, ratio := range benchls.Ratios(base, fits[g], fitOpts) {
+				r.add(fmt.Sprintf("%.3g±%.2g×", ratio.Value, ratio.CI))
+			}
+		}
+		table = append(table, r)
+	}
+
+	var buf bytes.Buffer
+	writeTable(&buf, table)
+	_, err = w.Write(buf.Bytes())
+	return err
+}
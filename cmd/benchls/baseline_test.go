@@ -0,0 +1,84 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/jonlawlor/benchls"
+)
+
+func TestWriteRatios(t *testing.T) {
+	xExprs, yExpr, samps, fits := testFits(t)
+	defer func() { flagBaseline = "" }()
+
+	for base, want := range map[string]string{
+		"BenchmarkNone": "-baseline: no group BenchmarkNone",
+		"BenchmarkOne":  "-baseline: group BenchmarkOne could not be fit",
+	} {
+		flagBaseline = base
+		if err := writeRatios(&bytes.Buffer{}, xExprs, fits); err == nil || err.Error() != want {
+			t.Errorf("%s: expected the error %q, got %v", base, want, err)
+		}
+	}
+
+	flagBaseline = "BenchmarkFast"
+	var buf bytes.Buffer
+	if err := writeRatios(&buf, xExprs, fits); err != nil {
+		t.Fatal(err)
+	}
+	var rows [][]string
+	for _, l := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		rows = append(rows, strings.Fields(l))
+	}
+	// the baseline is left out, and the group that could not be fit has no
+	// ratios
+	rs := benchls.Ratios(fits["BenchmarkFast"], fits["BenchmarkSlow"], fitOpts)
+	want := [][]string{
+		{"group", "÷", "BenchmarkFast", "N", "1.0"},
+		{"BenchmarkSlow", fmt.Sprintf("%.3g±%.2g×", rs[0].Value, rs[0].CI), fmt.Sprintf("%.3g±%.2g×", rs[1].Value, rs[1].CI)},
+		{"BenchmarkOne", "~", "~"},
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("expected %q, got %q", want, rows)
+	}
+	for i := range want {
+		if strings.Join(rows[i], " ") != strings.Join(want[i], " ") {
+			t.Errorf("row %d: expected %q, got %q", i, want[i], rows[i])
+		}
+	}
+	if rs[0].Value < 1.9 || rs[0].Value > 2.1 {
+		t.Errorf("expected BenchmarkSlow to be about twice BenchmarkFast per N, got %g", rs[0].Value)
+	}
+
+	// the ratios are in the JSON of every group but the baseline
+	buf.Reset()
+	if err := writeJSON(xExprs, yExpr, samps, fits, nil, nil, nil, nil, 0, &buf); err != nil {
+		t.Fatal(err)
+	}
+	var rep struct {
+		Groups []struct {
+			Name    string    `json:"name"`
+			Ratios  []float64 `json:"ratios"`
+			RatioCI []float64 `json:"ratio_ci"`
+		} `json:"groups"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &rep); err != nil {
+		t.Fatalf("invalid JSON %s: %v", buf.Bytes(), err)
+	}
+	for _, g := range rep.Groups {
+		n := 0
+		if g.Name == "BenchmarkSlow" {
+			n = 2
+		}
+		if len(g.Ratios) != n || len(g.RatioCI) != n {
+			t.Errorf("%s: expected %d ratios and intervals, got %v and %v", g.Name, n, g.Ratios, g.RatioCI)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+
+	"github.com/jonlawlor/benchls"
+)
+
+// writeBreakpoints writes the segments of the piecewise fit of each group,
+// one per row, with the range of the first term that each covers.
+func writeBreakpoints(xExprs []benchls.Expression, yExpr benchls.Expression, pws map[string]*benchls.Piecewise, man *manifest, seed int64, w io.Writer) {
+	groups := make([]string, 0, len(pws))
+	for g := range pws {
+		groups = append(groups, g)
+	}
+	sort.Strings(groups)
+
+	heading := newRow("group \\ "+yExpr.String()+" ~", "segment of "+xExprs[0].String())
+	for _, x := range xExprs {
+		heading.add(x.String())
+	}
+	heading.add("R^2")
+	table := []*row{heading}
+	for _, g := range groups {
+		pw := pws[g]
+		if pw == nil {
+			r := newRow(g)
+			for len(r.cols) < len(heading.cols) {
+				r.add("~")
+			}
+			table = append(table, r)
+			continue
+		}
+		bounds := append(append([]float64{math.Inf(-1)}, pw.Breaks...), math.Inf(1))
+		for i, fit := range pw.Segments {
+			r := newRow(g, fmt.Sprintf("[%.4g, %.4g)", bounds[i], bounds[i+1]))
+			for j, b := range fit.Model {
+				r.add(coefficient(b, fit.Stats.CI[j]))
+			}
+			r.add(fmt.Sprintf("%g", fit.Stats.RSquared))
+			table = append(table, r)
+		}
+	}
+
+	var buf bytes.Buffer
+	writeHeader(&buf, man, seed)
+	writeTable(&buf, table)
+	w.Write(buf.Bytes())
+}
@@ -0,0 +1,161 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jonlawlor/benchls"
+)
+
+// check is a threshold on a fitted statistic of a group, like
+// "BenchmarkSort: coeff[0] < 30".  The group "*" checks every group.
+type check struct {
+	src   string
+	group string
+	stat  string // "coeff" or "ci", which are indexed, or "r2"
+	index int
+	op    string
+	value float64
+}
+
+var checkPattern = regexp.MustCompile(`^\s*(.+?)\s*:\s*(coeff|ci|r2)(?:\[(\d+)\])?\s*(<=|>=|<|>)\s*(\S+)\s*$`)
+
+func parseCheck(src string) (check, error) {
+	m := checkPattern.FindStringSubmatch(src)
+	if m == nil {
+		return check{}, fmt.Errorf("invalid check %q, want something like \"BenchmarkSort: coeff[0] < 30\"", src)
+	}
+	c := check{src: strings.TrimSpace(src), group: m[1], stat: m[2], op: m[4]}
+	if (c.stat == "r2") != (m[3] == "") {
+		return check{}, fmt.Errorf("invalid check %q, coeff and ci need an index and r2 does not", src)
+	}
+	if m[3] != "" {
+		c.index, _ = strconv.Atoi(m[3])
+	}
+	var err error
+	if c.value, err = strconv.ParseFloat(m[5], 64); err != nil {
+		return check{}, fmt.Errorf("invalid check %q: %v", src, err)
+	}
+	return c, nil
+}
+
+// checkList is the repeatable -check flag.
+type checkList []check
+
+func (cs *checkList) String() string {
+	srcs := make([]string, len(*cs))
+	for i, c := range *cs {
+		srcs[i] = c.src
+	}
+	return strings.Join(srcs, "; ")
+}
+
+func (cs *checkList) Set(src string) error {
+	c, err := parseCheck(src)
+	if err != nil {
+		return err
+	}
+	*cs = append(*cs, c)
+	return nil
+}
+
+// readFile adds the checks in the named file, one per line.  Blank lines
+// and lines starting with # are ignored.
+func (cs *checkList) readFile(name string) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		l := strings.TrimSpace(sc.Text())
+		if l == "" || strings.HasPrefix(l, "#") {
+			continue
+		}
+		if err := cs.Set(l); err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+	}
+	return sc.Err()
+}
+
+// failures returns a description of every check that the fits violate.  A
+// check of a group that is missing or could not be fit fails.
+func (cs checkList) failures(fits map[string]*benchls.Fit) []string {
+	groups := make([]string, 0, len(fits))
+	for g := range fits {
+		groups = append(groups, g)
+	}
+	sort.Strings(groups)
+
+	var fails []string
+	for _, c := range cs {
+		matched := false
+		for _, g := range groups {
+			if c.group != "*" && c.group != g {
+				continue
+			}
+			matched = true
+			if msg := c.eval(fits[g]); msg != "" {
+				fails = append(fails, fmt.Sprintf("check %q failed for %s: %s", c.src, g, msg))
+			}
+		}
+		if !matched {
+			fails = append(fails, fmt.Sprintf("check %q failed: no group %s", c.src, c.group))
+		}
+	}
+	return fails
+}
+
+// eval returns why fit violates c, or "" if it does not.
+func (c check) eval(fit *benchls.Fit) string {
+	if fit == nil {
+		return "it could not be fit"
+	}
+	var v float64
+	switch c.stat {
+	case "r2":
+		v = fit.Stats.RSquared
+	case "coeff", "ci":
+		if c.index >= len(fit.Model) {
+			return fmt.Sprintf("it has only %d coefficients", len(fit.Model))
+		}
+		v = fit.Model[c.index]
+		if c.stat == "ci" {
+			v = fit.Stats.CI[c.index]
+		}
+	}
+	var ok bool
+	switch c.op {
+	case "<":
+		ok = v < c.value
+	case "<=":
+		ok = v <= c.value
+	case ">":
+		ok = v > c.value
+	case ">=":
+		ok = v >= c.value
+	}
+	if ok {
+		return ""
+	}
+	return fmt.Sprintf("%s is %g", c.stat+c.suffix(), v)
+}
+
+// suffix is the index of an indexed statistic, like "[0]".
+func (c check) suffix() string {
+	if c.stat == "r2" {
+		return ""
+	}
+	return "[" + strconv.Itoa(c.index) + "]"
+}
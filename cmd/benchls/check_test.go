@@ -0,0 +1,83 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseCheck(t *testing.T) {
+	c, err := parseCheck(" BenchmarkSort: coeff[1] >= -2.5e3 ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.group != "BenchmarkSort" || c.stat != "coeff" || c.index != 1 || c.op != ">=" || c.value != -2.5e3 {
+		t.Errorf("unexpected check %+v", c)
+	}
+	for _, src := range []string{
+		"BenchmarkSort coeff[0] < 30",
+		"BenchmarkSort: coeff < 30",
+		"BenchmarkSort: r2[0] > 0.9",
+		"BenchmarkSort: coeff[0] == 30",
+		"BenchmarkSort: coeff[0] < thirty",
+	} {
+		if _, err := parseCheck(src); err == nil {
+			t.Errorf("%s: expected an error", src)
+		}
+	}
+}
+
+func TestCheckFailures(t *testing.T) {
+	_, _, _, fits := testFits(t)
+	var cs checkList
+	for _, src := range []string{
+		"BenchmarkFast: coeff[0] < 4", // passes
+		"BenchmarkSlow: coeff[0] < 4", // fails
+		"*: r2 > 0.99",                // fails for One
+		"BenchmarkFast: coeff[2] < 1", // there are only 2
+		"BenchmarkNone: ci[0] < 1",    // no such group
+	} {
+		if err := cs.Set(src); err != nil {
+			t.Fatal(err)
+		}
+	}
+	fails := cs.failures(fits)
+	for i, want := range []string{
+		`"BenchmarkSlow: coeff[0] < 4" failed for BenchmarkSlow: coeff[0] is 6`,
+		`"*: r2 > 0.99" failed for BenchmarkOne: it could not be fit`,
+		`"BenchmarkFast: coeff[2] < 1" failed for BenchmarkFast: it has only 2 coefficients`,
+		`"BenchmarkNone: ci[0] < 1" failed: no group BenchmarkNone`,
+	} {
+		if i >= len(fails) || !strings.Contains(fails[i], want) {
+			t.Errorf("expected the failures to include %s, got %q", want, fails)
+		}
+	}
+	if len(fails) != 4 {
+		t.Errorf("expected 4 failures, got %q", fails)
+	}
+}
+
+func TestCheckFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "benchls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	name := filepath.Join(dir, "checks.txt")
+	if err := ioutil.WriteFile(name, []byte("# scaling\n\nBenchmarkSort: coeff[0] < 30\n*: r2 > 0.9\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	var cs checkList
+	if err := cs.readFile(name); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := cs.String(), "BenchmarkSort: coeff[0] < 30; *: r2 > 0.9"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+
+	"github.com/jonlawlor/benchls"
+)
+
+// writeCompare writes each coefficient of the groups fit to both inputs, and
+// the relative change from before to after with its confidence interval.
+func writeCompare(xExprs []benchls.Expression, yExpr benchls.Expression, before, after map[string]*benchls.Fit, man *manifest, seed int64, w io.Writer) {
+	var groups []string
+	for g := range before {
+		groups = append(groups, g)
+	}
+	for g := range after {
+		if _, ok := before[g]; !ok {
+			groups = append(groups, g)
+		}
+	}
+	sort.Strings(groups)
+
+	heading := newRow("group \\ " + yExpr.String() + " ~")
+	for _, xExpr := range xExprs {
+		heading.add(xExpr.String() + " before")
+		heading.add("after")
+		heading.add("Δ")
+		heading.add("sig")
+	}
+	table := []*row{heading}
+	for _, g := range groups {
+		r := newRow(g)
+		b, a := before[g], after[g]
+		if b == nil || a == nil {
+			// put a placeholder
+			for len(r.cols) < len(heading.cols) {
+				r.add("~")
+			}
+			table = append(table, r)
+			continue
+		}
+		for _, d := range benchls.Compare(b, a, fitOpts) {
+			r.add(fmt.Sprintf("%.4g", d.Before))
+			r.add(fmt.Sprintf("%.4g", d.After))
+			rel := d.Diff() / math.Abs(d.Before)
+			sign := ""
+			if rel >= 0 {
+				sign = "+"
+			}
+			r.add(sign + percent(rel) + "±" + percent(d.CI/math.Abs(d.Before)))
+			if d.Significant() {
+				r.add("yes")
+			} else {
+				r.add("no")
+			}
+		}
+		table = append(table, r)
+	}
+
+	var buf bytes.Buffer
+	writeHeader(&buf, man, seed)
+	writeTable(&buf, table)
+	w.Write(buf.Bytes())
+}
@@ -0,0 +1,127 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/jonlawlor/benchls"
+)
+
+// crossoverRange is how far beyond the observed range of the input variable
+// crossovers are sought, as a factor when the range is positive.  Other
+// ranges are widened by their span on either side.
+const crossoverRange = 1000
+
+// crossoverGroups are the two groups named by -crossover.
+var crossoverGroups []string
+
+// parseCrossover parses the -crossover groups, like
+// "BenchmarkSort,BenchmarkStableSort".
+func parseCrossover(spec string) ([]string, error) {
+	groups := strings.Split(spec, ",")
+	for i, g := range groups {
+		groups[i] = strings.TrimSpace(g)
+	}
+	if len(groups) != 2 || groups[0] == "" || groups[1] == "" || groups[0] == groups[1] {
+		return nil, fmt.Errorf("invalid -crossover %q, want two groups like \"BenchmarkSort,BenchmarkStableSort\"", spec)
+	}
+	return groups, nil
+}
+
+// crossoverReport is where the fits of the -crossover groups predict the same
+// response.
+type crossoverReport struct {
+	Groups     []string    `json:"groups"`
+	Var        string      `json:"var"`
+	From       number      `json:"from"` // the range that was searched
+	To         number      `json:"to"`
+	Crossovers []crossover `json:"crossovers"`
+	Lower      string      `json:"lower,omitempty"` // the lower group throughout, if they do not cross
+}
+
+// crossover is one point where the responses are equal.
+type crossover struct {
+	At         map[string]float64 `json:"at"`
+	Y          number             `json:"y"`
+	LowerBelow string             `json:"lower_below"`
+	LowerAbove string             `json:"lower_above"`
+	Extra      string             `json:"extrapolation,omitempty"`
+}
+
+// crossovers finds where the fits of the -crossover groups cross, in their
+// only input variable.
+func crossovers(xExprs []benchls.Expression, samps map[string]benchls.Sample, fits map[string]*benchls.Fit) (*crossoverReport, error) {
+	a, b := crossoverGroups[0], crossoverGroups[1]
+	for _, g := range crossoverGroups {
+		if fits[g] == nil {
+			return nil, fmt.Errorf("-crossover: group %s could not be fit", g)
+		}
+	}
+	sa, sb := samps[a], samps[b]
+	var v string
+	for name := range sa.Min {
+		v = name
+	}
+	if _, ok := sb.Min[v]; !ok || len(sa.Min) != 1 || len(sb.Min) != 1 {
+		return nil, errors.New("-crossover needs groups with exactly one input variable")
+	}
+
+	// the observed range, and the wider one to search
+	observed := benchls.Sample{
+		Min: map[string]float64{v: math.Min(sa.Min[v], sb.Min[v])},
+		Max: map[string]float64{v: math.Max(sa.Max[v], sb.Max[v])},
+	}
+	lo, hi := observed.Min[v], observed.Max[v]
+	if lo > 0 {
+		lo, hi = lo/crossoverRange, hi*crossoverRange
+	} else {
+		span := hi - lo
+		lo, hi = lo-span, hi+span
+	}
+
+	rep := &crossoverReport{Groups: crossoverGroups, Var: v, From: number(lo), To: number(hi), Crossovers: []crossover{}}
+	for _, c := range benchls.Crossovers(xExprs, fits[a].Model, fits[b].Model, v, lo, hi) {
+		at := map[string]float64{v: c.At}
+		x := crossover{At: at, Y: number(c.Y), LowerBelow: b, LowerAbove: a, Extra: benchls.Extrapolation(observed, at)}
+		if c.ALower {
+			x.LowerBelow, x.LowerAbove = a, b
+		}
+		rep.Crossovers = append(rep.Crossovers, x)
+	}
+	if len(rep.Crossovers) == 0 {
+		at := []map[string]float64{{v: hi}}
+		rep.Lower = a
+		if predict(xExprs, sb, fits[b], at)[0].Y < predict(xExprs, sa, fits[a], at)[0].Y {
+			rep.Lower = b
+		}
+	}
+	return rep, nil
+}
+
+// writeCrossovers writes where the -crossover groups cross.
+func writeCrossovers(w io.Writer, yExpr benchls.Expression, rep *crossoverReport) {
+	if len(rep.Crossovers) == 0 {
+		fmt.Fprintf(w, "%s and %s do not cross for %s in [%g, %g], %s is lower\n",
+			rep.Groups[0], rep.Groups[1], rep.Var, rep.From, rep.To, rep.Lower)
+		return
+	}
+	table := []*row{newRow("crossover of "+rep.Groups[0]+" and "+rep.Groups[1], yExpr.String(), "lower below", "lower above", "extrapolation")}
+	for _, c := range rep.Crossovers {
+		r := newRow(fmt.Sprintf("%s=%.4g", rep.Var, c.At[rep.Var]), fmt.Sprintf("%.4g", float64(c.Y)), c.LowerBelow, c.LowerAbove, c.Extra)
+		r.mark(noteClass)
+		r.trim()
+		table = append(table, r)
+	}
+
+	var buf bytes.Buffer
+	writeTable(&buf, table)
+	w.Write(buf.Bytes())
+}
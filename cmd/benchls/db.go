@@ -0,0 +1,160 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jonlawlor/benchls"
+	_ "github.com/mattn/go-sqlite3" // the sqlite3 driver of database/sql
+)
+
+// inputNames are the names of the input files, which -db records with each
+// run.
+var inputNames []string
+
+// dbSchema creates the tables of -db.  Each run of benchls is a row of runs,
+// with a row of samples per observation and of coefficients per group and
+// term.
+const dbSchema = `CREATE TABLE IF NOT EXISTS runs (
+	id INTEGER PRIMARY KEY,
+	time TEXT NOT NULL,
+	label TEXT,
+	inputs TEXT,
+	response TEXT,
+	ytransform TEXT,
+	xtransform TEXT
+);
+CREATE TABLE IF NOT EXISTS samples (
+	run INTEGER NOT NULL REFERENCES runs(id),
+	group_name TEXT NOT NULL,
+	name TEXT,
+	vars TEXT,
+	y REAL
+);
+CREATE TABLE IF NOT EXISTS coefficients (
+	run INTEGER NOT NULL REFERENCES runs(id),
+	group_name TEXT NOT NULL,
+	term TEXT NOT NULL,
+	value REAL,
+	ci REAL,
+	se REAL,
+	r2 REAL,
+	dof INTEGER,
+	n INTEGER
+);
+`
+
+// writeDB appends the samples and fitted coefficients to the SQLite database
+// at path as a new run, labelled by -label.  The vars of each sample are a
+// JSON object of its input variables, which SQLite's json_extract can query.
+// Groups that could not be fit have no coefficients.
+func writeDB(path string, terms []benchls.Expression, yExpr benchls.Expression, samps map[string]benchls.Sample, fits map[string]*benchls.Fit) error {
+	db, err := sql.Open("sqlite3", path)
+	if err == nil {
+		err = insertRun(db, terms, yExpr, samps, fits, time.Now())
+		if cerr := db.Close(); err == nil {
+			err = cerr
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("-db %s: %v", path, err)
+	}
+	return nil
+}
+
+// insertRun creates the tables of -db in db, if they do not exist, and
+// appends the run at t to them, in one transaction.
+func insertRun(db *sql.DB, terms []benchls.Expression, yExpr benchls.Expression, samps map[string]benchls.Sample, fits map[string]*benchls.Fit, t time.Time) error {
+	groups := make([]string, 0, len(samps))
+	for g := range samps {
+		groups = append(groups, g)
+	}
+	sort.Strings(groups)
+	termNames := make([]string, len(terms))
+	for i, t := range terms {
+		termNames[i] = t.String()
+	}
+
+	if _, err := db.Exec(dbSchema); err != nil {
+		return err
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	// the transaction is rolled back unless it is committed
+	defer tx.Rollback()
+	res, err := tx.Exec("INSERT INTO runs (time, label, inputs, response, ytransform, xtransform) VALUES (?, ?, ?, ?, ?, ?)",
+		t.UTC().Format(time.RFC3339), flagLabel, strings.Join(inputNames, " "), flagYVar, yExpr.String(), strings.Join(termNames, ", "))
+	if err != nil {
+		return err
+	}
+	run, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	insertSample, err := tx.Prepare("INSERT INTO samples VALUES (?, ?, ?, ?, ?)")
+	if err != nil {
+		return err
+	}
+	defer insertSample.Close()
+	insertCoef, err := tx.Prepare("INSERT INTO coefficients VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)")
+	if err != nil {
+		return err
+	}
+	defer insertCoef.Close()
+
+	for _, g := range groups {
+		s := samps[g]
+		for i, y := range s.Y {
+			var vars interface{}
+			if i < len(s.Vars) {
+				if b, err := json.Marshal(s.Vars[i]); err == nil {
+					vars = string(b)
+				}
+			}
+			name := ""
+			if i < len(s.Names) {
+				name = s.Names[i]
+			}
+			if _, err := insertSample.Exec(run, g, name, vars, sqlFloat(y)); err != nil {
+				return err
+			}
+		}
+		fit := fits[g]
+		if fit == nil {
+			continue
+		}
+		for j, b := range fit.Model {
+			var ci, se float64
+			if j < len(fit.Stats.CI) {
+				ci = fit.Stats.CI[j]
+			}
+			if j < len(fit.Stats.SE) {
+				se = fit.Stats.SE[j]
+			}
+			if _, err := insertCoef.Exec(run, g, termNames[j], sqlFloat(b), sqlFloat(ci), sqlFloat(se),
+				sqlFloat(fit.Stats.RSquared), fit.Stats.DOF, len(s.Y)); err != nil {
+				return err
+			}
+		}
+	}
+	return tx.Commit()
+}
+
+// sqlFloat returns f as an SQL parameter, which is NULL if it is not finite.
+func sqlFloat(f float64) interface{} {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return nil
+	}
+	return f
+}
@@ -0,0 +1,84 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"database/sql"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWriteDB(t *testing.T) {
+	xExprs, yExpr, samps, fits := testFits(t)
+	flagLabel = "it's v1"
+	defer func() { flagLabel = "" }()
+	dir, err := ioutil.TempDir("", "benchls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "runs.db")
+
+	// each run is appended
+	for i := 0; i < 2; i++ {
+		if err := writeDB(path, xExprs, yExpr, samps, fits); err != nil {
+			t.Fatal(err)
+		}
+	}
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	var runs int
+	var label, response, xt, stamp string
+	if err := db.QueryRow("SELECT count(*), max(label), max(response), max(xtransform), max(time) FROM runs").Scan(&runs, &label, &response, &xt, &stamp); err != nil {
+		t.Fatal(err)
+	}
+	if runs != 2 || label != "it's v1" || response != "NsPerOp" || xt != "N, 1.0" {
+		t.Errorf("expected 2 runs of NsPerOp ~ N, 1.0 labelled it's v1, got %d of %s ~ %s labelled %s", runs, response, xt, label)
+	}
+	if _, err := time.Parse(time.RFC3339, stamp); err != nil {
+		t.Errorf("expected the time of the run, got %q", stamp)
+	}
+
+	// a sample per observation, and a coefficient per term of each fit
+	for table, want := range map[string]int{"samples": 17, "coefficients": 4} {
+		var n int
+		if err := db.QueryRow("SELECT count(*) FROM " + table + " WHERE run = 2").Scan(&n); err != nil {
+			t.Fatal(err)
+		}
+		if n != want {
+			t.Errorf("expected %d %s in the run, got %d", want, table, n)
+		}
+	}
+	var n, y float64
+	if err := db.QueryRow("SELECT json_extract(vars, '$.N'), y FROM samples WHERE name = 'BenchmarkOne/10' AND run = 1").Scan(&n, &y); err != nil {
+		t.Fatal(err)
+	}
+	if n != 10 || y != samps["BenchmarkOne"].Y[0] {
+		t.Errorf("expected BenchmarkOne/10 at N=10, got N=%g, y=%g", n, y)
+	}
+	var b, ci float64
+	if err := db.QueryRow("SELECT value, ci FROM coefficients WHERE group_name = 'BenchmarkSlow' AND term = 'N' AND run = 1").Scan(&b, &ci); err != nil {
+		t.Fatal(err)
+	}
+	if slow := fits["BenchmarkSlow"]; b != slow.Model[0] || ci != slow.Stats.CI[0] {
+		t.Errorf("expected the coefficient %g±%g, got %g±%g", slow.Model[0], slow.Stats.CI[0], b, ci)
+	}
+}
+
+func TestSQLFloat(t *testing.T) {
+	for f, want := range map[float64]interface{}{1.5: 1.5, -2e-10: -2e-10, math.NaN(): nil, math.Inf(1): nil} {
+		if got := sqlFloat(f); got != want {
+			t.Errorf("%g: expected %v, got %v", f, want, got)
+		}
+	}
+}
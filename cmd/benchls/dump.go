@@ -0,0 +1,87 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/jonlawlor/benchls"
+)
+
+// fileName makes a group name safe to use as a file name, without
+// separators, spaces or the characters that some file systems reserve.
+func fileName(group string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '/', '\\', ':', '*', '?', '"', '<', '>', '|':
+			return '_'
+		}
+		if unicode.IsSpace(r) {
+			return '_'
+		}
+		return r
+	}, group)
+}
+
+// dumpSamples writes one CSV file per group to dir, with the benchmark name,
+// input variables, explanatory terms and response of every observation.
+func dumpSamples(dir string, xExprs []benchls.Expression, yExpr benchls.Expression, samps map[string]benchls.Sample) error {
+	for group, s := range samps {
+		if err := dumpSample(filepath.Join(dir, fileName(group)+".csv"), xExprs, yExpr, s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func dumpSample(path string, xExprs []benchls.Expression, yExpr benchls.Expression, s benchls.Sample) error {
+	var varNames []string
+	if len(s.Vars) > 0 {
+		for name := range s.Vars[0] {
+			if name != "" {
+				varNames = append(varNames, name)
+			}
+		}
+	}
+	sort.Strings(varNames)
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w := csv.NewWriter(f)
+
+	heading := append([]string{"name"}, varNames...)
+	for _, xExpr := range xExprs {
+		heading = append(heading, xExpr.String())
+	}
+	heading = append(heading, yExpr.String())
+	w.Write(heading)
+
+	stride := len(xExprs)
+	for i, y := range s.Y {
+		rec := []string{s.Names[i]}
+		for _, name := range varNames {
+			rec = append(rec, strconv.FormatFloat(s.Vars[i][name], 'g', -1, 64))
+		}
+		for _, x := range s.X[i*stride : (i+1)*stride] {
+			rec = append(rec, strconv.FormatFloat(x, 'g', -1, 64))
+		}
+		rec = append(rec, strconv.FormatFloat(y, 'g', -1, 64))
+		w.Write(rec)
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,70 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/csv"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/jonlawlor/benchls"
+)
+
+func TestFileName(t *testing.T) {
+	for group, want := range map[string]string{
+		"BenchmarkSort":             "BenchmarkSort",
+		"BenchmarkSort/ints":        "BenchmarkSort_ints",
+		"BenchmarkMap/int keys":     "BenchmarkMap_int_keys",
+		"BenchmarkA\tB":             "BenchmarkA_B",
+		`BenchmarkWin\path:x*?"<>|`: "BenchmarkWin_path_x______",
+		"BenchmarkÜber":             "BenchmarkÜber",
+	} {
+		if got := fileName(group); got != want {
+			t.Errorf("fileName(%q): expected %q, got %q", group, want, got)
+		}
+	}
+}
+
+func TestDumpSamples(t *testing.T) {
+	dir, err := ioutil.TempDir("", "benchls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	xExprs, yExpr, samps, _ := testFits(t)
+	s := samps["BenchmarkOne"]
+	samps = map[string]benchls.Sample{"BenchmarkMap/int keys": s, "BenchmarkSlow": samps["BenchmarkSlow"]}
+	if err := dumpSamples(dir, xExprs, yExpr, samps); err != nil {
+		t.Fatal(err)
+	}
+	// a file per group
+	fis, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fis) != 2 || fis[0].Name() != "BenchmarkMap_int_keys.csv" || fis[1].Name() != "BenchmarkSlow.csv" {
+		t.Errorf("expected a file per group, got %v", fis)
+	}
+	f, err := os.Open(filepath.Join(dir, "BenchmarkMap_int_keys.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	got, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{
+		{"name", "N", "N", "1.0", "Y"},
+		{"BenchmarkOne/10", "10", "10", "1", "10"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected the samples\n%q\ngot\n%q", want, got)
+	}
+}
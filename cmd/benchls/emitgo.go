@@ -0,0 +1,158 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/jonlawlor/benchls"
+)
+
+// goName makes a group name into the exported part of a Go identifier, like
+// "SortInts" for "BenchmarkSort/ints".
+func goName(group string) string {
+	var b bytes.Buffer
+	upper := true
+	for _, r := range strings.TrimPrefix(group, "Benchmark") {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// goPackage returns the name of the package that a Go file written to path
+// belongs to, which is the name of its directory if that is a valid package
+// name.
+func goPackage(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "model"
+	}
+	name := filepath.Base(filepath.Dir(abs))
+	if !identifier.MatchString(name) || token.Lookup(name).IsKeyword() {
+		return "model"
+	}
+	return name
+}
+
+// writeGo writes a Go file to path with a function per group, like
+// PredictSort(n float64) float64, that predicts its response with the fitted
+// coefficients.  The input variables are its parameters, with the first
+// letter of their names in lower case.
+func writeGo(path string, xExprs []benchls.Expression, yExpr benchls.Expression, samps map[string]benchls.Sample, fits map[string]*benchls.Fit) error {
+	groups := make([]string, 0, len(fits))
+	for g, fit := range fits {
+		if fit != nil {
+			groups = append(groups, g)
+		}
+	}
+	sort.Strings(groups)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by benchls; DO NOT EDIT.\n\npackage %s\n\n", goPackage(path))
+	var funcs bytes.Buffer
+	names := make(map[string]string)
+	for _, g := range groups {
+		name := "Predict" + goName(g)
+		if other, ok := names[name]; ok {
+			return fmt.Errorf("-emit-go: groups %s and %s are both named %s", other, g, name)
+		}
+		names[name] = g
+
+		// the parameters are the variables the terms use
+		params := make(map[string]string)
+		rename := func(v string) string {
+			p := strings.ToLower(v[:1]) + v[1:]
+			if _, taken := samps[g].Min[p]; taken && p != v {
+				p = v
+			}
+			params[v] = p
+			return p
+		}
+		var sum string
+		for j, b := range fits[g].Model {
+			term, err := xExprs[j].GoSource(rename)
+			if err != nil {
+				return fmt.Errorf("-emit-go: %v", err)
+			}
+			sum += goTerm(j == 0, b, term)
+		}
+		vars := make([]string, 0, len(params))
+		for v := range params {
+			vars = append(vars, v)
+		}
+		sort.Strings(vars)
+		for i, v := range vars {
+			vars[i] = params[v]
+		}
+		sig := ""
+		if len(vars) > 0 {
+			sig = strings.Join(vars, ", ") + " float64"
+		}
+
+		predicts := flagYVar
+		if y := yExpr.String(); y != "Y" {
+			predicts = y + ", where Y is " + flagYVar + ","
+		}
+		fmt.Fprintf(&funcs, "\n// %s predicts %s of %s.\n", name, predicts, g)
+		fmt.Fprintf(&funcs, "// It was fit to %s, with R^2 %.6g.\n", varRange(samps[g]), fits[g].Stats.RSquared)
+		fmt.Fprintf(&funcs, "func %s(%s) float64 {\nreturn %s\n}\n", name, sig, sum)
+	}
+	if strings.Contains(funcs.String(), "math.") {
+		buf.WriteString("import \"math\"\n")
+	}
+	buf.Write(funcs.Bytes())
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return fmt.Errorf("-emit-go: %v", err)
+	}
+	return ioutil.WriteFile(path, src, 0666)
+}
+
+// goTerm writes b times term as a term of a sum, which is first or follows
+// another term.  A constant term is written as just b.
+func goTerm(first bool, b float64, term string) string {
+	op := " + "
+	if b < 0 {
+		op = " - "
+		b = -b
+	}
+	if first {
+		op = strings.TrimSpace(op)
+		if op == "+" {
+			op = ""
+		}
+	}
+	coef := strconv.FormatFloat(b, 'g', -1, 64)
+	if v, err := strconv.ParseFloat(term, 64); err == nil && v == 1 {
+		return op + coef
+	}
+	if n, err := parser.ParseExpr(term); err == nil {
+		switch n.(type) {
+		case *ast.BinaryExpr, *ast.UnaryExpr:
+			term = "(" + term + ")"
+		}
+	}
+	return op + coef + "*" + term
+}
@@ -0,0 +1,84 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGoName(t *testing.T) {
+	for group, want := range map[string]string{
+		"BenchmarkSort":           "Sort",
+		"BenchmarkSort/ints":      "SortInts",
+		"BenchmarkMap/algo=quick": "MapAlgoQuick",
+	} {
+		if got := goName(group); got != want {
+			t.Errorf("%s: expected %s, got %s", group, want, got)
+		}
+	}
+}
+
+func TestGoTerm(t *testing.T) {
+	for _, test := range []struct {
+		first bool
+		b     float64
+		term  string
+		want  string
+	}{
+		{true, 2.5, "n", "2.5*n"},
+		{true, -2.5, "n", "-2.5*n"},
+		{false, 3, "1.0", " + 3"},
+		{false, -3, "n * math.Log(n)", " - 3*(n * math.Log(n))"},
+		{false, 1e-9, "math.Sqrt(n)", " + 1e-09*math.Sqrt(n)"},
+	} {
+		if got := goTerm(test.first, test.b, test.term); got != test.want {
+			t.Errorf("%v %g %s: expected %q, got %q", test.first, test.b, test.term, test.want, got)
+		}
+	}
+}
+
+func TestWriteGo(t *testing.T) {
+	xExprs, yExpr, samps, fits := testFits(t)
+	tmp, err := ioutil.TempDir("", "benchls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	dir := filepath.Join(tmp, "fits")
+	if err := os.Mkdir(dir, 0777); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "fits.go")
+	if err := writeGo(path, xExprs, yExpr, samps, fits); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := parser.ParseFile(token.NewFileSet(), path, nil, 0)
+	if err != nil {
+		t.Fatalf("the written file does not parse: %v", err)
+	}
+	if f.Name.Name != "fits" {
+		t.Errorf("expected the package to be named for its directory, got %s", f.Name.Name)
+	}
+	funcs := make(map[string]*ast.FuncDecl)
+	for _, d := range f.Decls {
+		if fn, ok := d.(*ast.FuncDecl); ok {
+			funcs[fn.Name.Name] = fn
+		}
+	}
+	if len(funcs) != 2 || funcs["PredictFast"] == nil || funcs["PredictSlow"] == nil {
+		t.Fatalf("expected PredictFast and PredictSlow, got %v", funcs)
+	}
+	params := funcs["PredictFast"].Type.Params.List
+	if len(params) != 1 || len(params[0].Names) != 1 || params[0].Names[0].Name != "n" {
+		t.Errorf("expected PredictFast(n float64), got %d parameters", len(params))
+	}
+}
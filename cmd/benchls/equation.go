@@ -0,0 +1,130 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jonlawlor/benchls"
+)
+
+// mathCall matches the calls of math functions in a term, like math.Log(.
+var mathCall = regexp.MustCompile(`math\.([A-Za-z0-9]+)\(`)
+
+// equations writes the fit of each group that could be fit as an equation,
+// in the -sort order.
+func equations(terms []benchls.Expression, yExpr benchls.Expression, fits map[string]*benchls.Fit) []string {
+	var eqs []string
+	for _, g := range sortedGroups(fits, flagSort) {
+		if fits[g] != nil {
+			eqs = append(eqs, g+": "+equation(terms, yExpr, fits[g]))
+		}
+	}
+	return eqs
+}
+
+// equation writes a fit like "Y ≈ 22.5·N·log(N) − 2.0e6 ns", with each
+// coefficient rounded to its significant digits, up to 3.  With -loglog,
+// -semilogy or -back, it is the product of the factors, like
+// "Y ≈ 68·N^1.1 ns" or "Y ≈ 3.9e5·e^(1.3e-6·N) ns".
+func equation(terms []benchls.Expression, yExpr benchls.Expression, fit *benchls.Fit) string {
+	y := yExpr.String()
+	if logTerms != nil {
+		y = logResponse
+	}
+	var b bytes.Buffer
+	b.WriteString(y + " ≈ ")
+	if logTerms != nil {
+		var factors []string
+		for i, t := range logTerms {
+			c, cint := fit.Model[i], fit.Stats.CI[i]
+			switch {
+			case t.constant:
+				f, fint := factor(c, cint)
+				factors = append([]string{eqNumber(f, fint)}, factors...)
+			case flagLogLog:
+				factors = append(factors, eqTerm(t.src)+"^"+eqNumber(c, cint))
+			default:
+				// the factor per unit of the term is often too close to 1
+				// to be shown to the digits that are significant
+				factors = append(factors, eqBase()+"^("+eqNumber(c, cint)+"·"+eqTerm(t.src)+")")
+			}
+		}
+		b.WriteString(strings.Join(factors, "·"))
+	} else {
+		for i, x := range terms {
+			c, cint := fit.Model[i], fit.Stats.CI[i]
+			switch {
+			case i > 0 && c < 0:
+				b.WriteString(" − ")
+			case i > 0:
+				b.WriteString(" + ")
+			case c < 0:
+				b.WriteString("−")
+			}
+			b.WriteString(eqNumber(math.Abs(c), cint))
+			if t := x.String(); t != "1.0" && t != "1" {
+				b.WriteString("·" + eqTerm(t))
+			}
+		}
+	}
+	if y == "Y" {
+		b.WriteString(" " + responseUnit(flagYVar))
+	}
+	return b.String()
+}
+
+// eqBase writes the base of the logarithm of the response, e unless it is
+// that of -back.
+func eqBase() string {
+	if flagBack && backResponse.Base != math.E {
+		return strconv.FormatFloat(backResponse.Base, 'g', -1, 64)
+	}
+	return "e"
+}
+
+// eqTerm writes a term as it is in an equation, like N·log(N) for
+// N * math.Log(N).
+func eqTerm(t string) string {
+	t = mathCall.ReplaceAllStringFunc(t, func(call string) string {
+		return strings.ToLower(strings.TrimPrefix(call, "math."))
+	})
+	t = strings.Replace(t, " * ", "·", -1)
+	if strings.ContainsAny(t, " +-") {
+		t = "(" + t + ")"
+	}
+	return t
+}
+
+// eqNumber formats a coefficient with the digits that are significant given
+// its confidence interval cint, at least 2 and at most 3, like 22.5 or 2.0e6.
+func eqNumber(c, cint float64) string {
+	digits := 2
+	if d := int(math.Log10(math.Abs(c))-math.Log10(cint)) + 1; d > digits {
+		digits = d
+	}
+	if digits > 3 {
+		digits = 3
+	}
+	if flagNumFmt == "eng" || flagNumFmt == "si" {
+		return engineering(c, digits, flagNumFmt == "si")
+	}
+	s := strconv.FormatFloat(c, 'g', digits, 64)
+	if i := strings.Index(s, "e"); i >= 0 {
+		// 2e+06 is 2.0e6
+		mant, exp := s[:i], s[i+1:]
+		if !strings.Contains(mant, ".") && digits > 1 {
+			mant += "." + strings.Repeat("0", digits-1)
+		}
+		e, _ := strconv.Atoi(exp)
+		return fmt.Sprintf("%se%d", mant, e)
+	}
+	return s
+}
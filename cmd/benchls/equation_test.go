@@ -0,0 +1,62 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jonlawlor/benchls"
+)
+
+func TestEquations(t *testing.T) {
+	names := map[string]struct{}{"N": {}, "Y": {}}
+	yExpr, err := benchls.NewExpression("Y", names, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fit := func(b ...float64) *benchls.Fit {
+		// the intervals are narrow enough for 3 digits
+		cint := make([]float64, len(b))
+		for i := range b {
+			cint[i] = 1e-4 * b[i]
+			if cint[i] < 0 {
+				cint[i] = -cint[i]
+			}
+		}
+		return &benchls.Fit{Model: b, Stats: benchls.Stats{CI: cint}}
+	}
+	for _, c := range []struct {
+		xt   string
+		fits map[string]*benchls.Fit
+		want []string
+	}{
+		{"N * math.Log(N), 1.0", map[string]*benchls.Fit{
+			"BenchmarkSort": fit(22.5, -2e6),
+			"BenchmarkFind": fit(-3, 2),
+			"BenchmarkOne":  nil,
+		}, []string{
+			// negative coefficients are subtracted, and the intercept has
+			// no term
+			"BenchmarkFind: Y ≈ −3·N·log(N) + 2 ns",
+			"BenchmarkSort: Y ≈ 22.5·N·log(N) − 2.00e6 ns",
+		}},
+		{"N, 1", map[string]*benchls.Fit{"BenchmarkCopy": fit(0.25, 40)}, []string{
+			"BenchmarkCopy: Y ≈ 0.25·N + 40 ns",
+		}},
+		// a term with a sum is in parentheses
+		{"N + 1.0, N - 1.0", map[string]*benchls.Fit{"BenchmarkCopy": fit(5, -7)}, []string{
+			"BenchmarkCopy: Y ≈ 5·(N + 1.0) − 7·(N - 1.0) ns",
+		}},
+	} {
+		terms, err := benchls.NewExpressions(c.xt, names, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := equations(terms, yExpr, c.fits); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s: expected\n%q\ngot\n%q", c.xt, c.want, got)
+		}
+	}
+}
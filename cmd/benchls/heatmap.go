@@ -0,0 +1,157 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"math"
+	"os"
+	"sort"
+
+	"github.com/jonlawlor/benchls"
+)
+
+// writeHeatmap writes an HTML page with, for each group measured over two
+// input variables, the fitted surface and the relative residuals on the grid
+// of observed values.  Groups with some other number of variables are listed
+// but not drawn.
+func writeHeatmap(path string, yExpr benchls.Expression, samps map[string]benchls.Sample, fits map[string]*benchls.Fit) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+
+	fmt.Fprintf(w, "<!DOCTYPE html>\n<html><head><meta charset='utf-8'><title>benchls</title>\n")
+	fmt.Fprintf(w, "<style>.heatmap td { padding: 0.2em 0.5em; text-align: right; } .heatmap th { padding: 0.2em 0.5em; }</style>\n")
+	fmt.Fprintf(w, "</head><body>\n")
+
+	groups := make([]string, 0, len(samps))
+	for g := range samps {
+		groups = append(groups, g)
+	}
+	sort.Strings(groups)
+	for _, g := range groups {
+		s, fit := samps[g], fits[g]
+		fmt.Fprintf(w, "<h2>%s</h2>\n", html.EscapeString(g))
+		var names []string
+		for name := range s.Min {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		switch {
+		case fit == nil:
+			fmt.Fprintf(w, "<p>could not be fit</p>\n")
+			continue
+		case len(names) != 2:
+			fmt.Fprintf(w, "<p>has %d input variables, a heatmap needs 2</p>\n", len(names))
+			continue
+		}
+		writeSurface(w, yExpr, names[0], names[1], s, fit.Model)
+	}
+	fmt.Fprintf(w, "</body></html>\n")
+
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// cell collects the observations at one point of the grid.
+type cell struct {
+	fitted, y float64
+	n         int
+}
+
+// writeSurface writes the fitted values and the mean relative residuals of s
+// as two tables, with col varying across and row varying down.
+func writeSurface(w *bufio.Writer, yExpr benchls.Expression, col, row string, s benchls.Sample, m benchls.Model) {
+	stride := len(s.X) / len(s.Y)
+	cells := make(map[[2]float64]*cell)
+	var cols, rows []float64
+	for i, y := range s.Y {
+		key := [2]float64{s.Vars[i][col], s.Vars[i][row]}
+		c, ok := cells[key]
+		if !ok {
+			c = &cell{}
+			for j, x := range s.X[i*stride : (i+1)*stride] {
+				c.fitted += m[j] * x
+			}
+			cells[key] = c
+			cols = appendUnique(cols, key[0])
+			rows = appendUnique(rows, key[1])
+		}
+		c.y += y
+		c.n++
+	}
+	sort.Float64s(cols)
+	sort.Float64s(rows)
+
+	// fitted values are shaded on a log scale, from light to dark
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, c := range cells {
+		if c.fitted > 0 {
+			lo = math.Min(lo, math.Log(c.fitted))
+			hi = math.Max(hi, math.Log(c.fitted))
+		}
+	}
+	fmt.Fprintf(w, "<h3>fitted %s</h3>\n", html.EscapeString(yExpr.String()))
+	writeGrid(w, col, row, cols, rows, cells, func(c *cell) (string, string) {
+		shade := 0.0
+		if c.fitted > 0 && hi > lo {
+			shade = (math.Log(c.fitted) - lo) / (hi - lo)
+		}
+		return fmt.Sprintf("%.3g", c.fitted), fmt.Sprintf("hsl(220, 70%%, %.0f%%)", 95-50*shade)
+	})
+
+	// residuals are red when the observations are above the fit, and blue
+	// when they are below it
+	fmt.Fprintf(w, "<h3>relative residuals</h3>\n")
+	writeGrid(w, col, row, cols, rows, cells, func(c *cell) (string, string) {
+		rel := (c.y/float64(c.n) - c.fitted) / math.Abs(c.fitted)
+		hue := 0
+		if rel < 0 {
+			hue = 220
+		}
+		light := 95 - 50*math.Min(math.Abs(rel)/0.2, 1) // saturates at 20%
+		return fmt.Sprintf("%+.1f%%", 100*rel), fmt.Sprintf("hsl(%d, 70%%, %.0f%%)", hue, light)
+	})
+}
+
+// writeGrid writes a table of cells, using format to get the text and
+// background color of each.
+func writeGrid(w *bufio.Writer, col, row string, cols, rows []float64, cells map[[2]float64]*cell, format func(*cell) (text, color string)) {
+	fmt.Fprintf(w, "<table class='heatmap'>\n<tr><th>%s \\ %s</th>", html.EscapeString(row), html.EscapeString(col))
+	for _, c := range cols {
+		fmt.Fprintf(w, "<th>%g</th>", c)
+	}
+	fmt.Fprintf(w, "</tr>\n")
+	for _, r := range rows {
+		fmt.Fprintf(w, "<tr><th>%g</th>", r)
+		for _, c := range cols {
+			cl, ok := cells[[2]float64{c, r}]
+			if !ok {
+				fmt.Fprintf(w, "<td></td>")
+				continue
+			}
+			text, color := format(cl)
+			fmt.Fprintf(w, "<td style='background: %s'>%s</td>", color, text)
+		}
+		fmt.Fprintf(w, "</tr>\n")
+	}
+	fmt.Fprintf(w, "</table>\n")
+}
+
+func appendUnique(fs []float64, f float64) []float64 {
+	for _, g := range fs {
+		if g == f {
+			return fs
+		}
+	}
+	return append(fs, f)
+}
@@ -0,0 +1,63 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jonlawlor/benchls"
+)
+
+func TestWriteHeatmap(t *testing.T) {
+	_, yExpr, samps, fits := testFits(t)
+
+	// Y = 2 M + 3 N + 1 on a 2 by 3 grid, with the corner M=2, N=30 measured
+	// twice, above and below the surface
+	s := benchls.Sample{Min: map[string]float64{"M": 1, "N": 10}, Max: map[string]float64{"M": 2, "N": 30}}
+	for _, o := range []struct{ m, n, dy float64 }{
+		{1, 10, 0}, {1, 20, 0}, {1, 30, 0},
+		{2, 10, 0}, {2, 20, 0}, {2, 30, 2}, {2, 30, -2},
+	} {
+		s.X = append(s.X, o.m, o.n, 1)
+		s.Y = append(s.Y, 2*o.m+3*o.n+1+o.dy)
+		s.Names = append(s.Names, fmt.Sprintf("BenchmarkGrid/M=%g/N=%g", o.m, o.n))
+		s.Vars = append(s.Vars, map[string]float64{"M": o.m, "N": o.n})
+	}
+	samps["BenchmarkGrid"] = s
+	fits["BenchmarkGrid"] = benchls.NewFit(s, benchls.Options{})
+
+	dir, err := ioutil.TempDir("", "benchls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "heatmap.html")
+	if err := writeHeatmap(path, yExpr, samps, fits); err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	page := string(b)
+	for _, want := range []string{
+		"<h2>BenchmarkFast</h2>\n<p>has 1 input variables, a heatmap needs 2</p>",
+		"<h2>BenchmarkOne</h2>\n<p>could not be fit</p>",
+		// N varies down the rows, and M across the columns
+		"<tr><th>N \\ M</th><th>1</th><th>2</th></tr>",
+		"<tr><th>30</th><td style='background: hsl(220, 70%, 46%)'>93</td><td style='background: hsl(220, 70%, 45%)'>95</td></tr>",
+		// the replicates at M=2, N=30 cancel out
+		"<tr><th>30</th><td style='background: hsl(0, 70%, 95%)'>+0.0%</td><td style='background: hsl(0, 70%, 95%)'>+0.0%</td></tr>",
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("expected the heatmap to contain %q, got\n%s", want, page)
+		}
+	}
+}
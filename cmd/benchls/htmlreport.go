@@ -0,0 +1,118 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/jonlawlor/benchls"
+)
+
+// sortScript sorts the report table by a column when its heading is clicked,
+// numerically if the cells start with numbers.
+const sortScript = `
+document.querySelectorAll('table.benchls th').forEach(function(th, col) {
+	th.style.cursor = 'pointer';
+	th.addEventListener('click', function() {
+		var body = th.closest('table').tBodies[0];
+		var rows = Array.prototype.slice.call(body.rows);
+		var asc = th.dataset.order !== 'asc';
+		th.dataset.order = asc ? 'asc' : 'desc';
+		rows.sort(function(a, b) {
+			var x = a.cells[col].textContent, y = b.cells[col].textContent;
+			var nx = parseFloat(x), ny = parseFloat(y);
+			var c = (isNaN(nx) || isNaN(ny)) ? x.localeCompare(y) : nx - ny;
+			return asc ? c : -c;
+		});
+		rows.forEach(function(r) { body.appendChild(r); });
+	});
+});
+`
+
+// writeHTMLReport writes a self-contained HTML document to path, with the
+// report table and, for each group that could be fit, a plot of its
+// observations and fitted curve (for single variable groups) and of its
+// residuals.
+func writeHTMLReport(path string, xExprs, terms []benchls.Expression, yExpr benchls.Expression, samps map[string]benchls.Sample, fits map[string]*benchls.Fit, stabilities, powers map[string]float64, man *manifest, seed int64) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	fmt.Fprintf(w, "<!DOCTYPE html>\n<html>\n<head>\n<meta charset='utf-8'>\n<title>benchls report</title>\n")
+	fmt.Fprintf(w, "<style>\nbody { font-family: sans-serif; }\n.benchls td:nth-child(1n+2) { text-align: right; padding: 0em 1em; }\n.benchls th { padding: 0em 1em; }\n%s\n</style>\n", sigCSS)
+	fmt.Fprintf(w, "</head>\n<body>\n")
+	if man != nil {
+		fmt.Fprintf(w, "<pre>\n")
+		for _, l := range man.lines() {
+			fmt.Fprintf(w, "%s\n", html.EscapeString(l))
+		}
+		fmt.Fprintf(w, "</pre>\n")
+	} else if stochastic() {
+		fmt.Fprintf(w, "<p>seed %d</p>\n", seed)
+	}
+
+	table := reportTable(terms, yExpr, samps, fits, stabilities, powers)
+	if len(table) > 0 {
+		fmt.Fprintf(w, "<table class='benchls'>\n<thead>\n")
+		writeHTMLRow(w, table[0], "th")
+		fmt.Fprintf(w, "</thead>\n<tbody>\n")
+		for _, r := range table[1:] {
+			writeHTMLRow(w, r, "td")
+		}
+		fmt.Fprintf(w, "</tbody>\n</table>\n")
+	}
+	if flagShowEq {
+		for _, eq := range equations(terms, yExpr, fits) {
+			fmt.Fprintf(w, "<p>%s</p>\n", html.EscapeString(eq))
+		}
+	}
+	if flagSummary {
+		for _, sum := range summaries(terms, yExpr, fits) {
+			fmt.Fprintf(w, "<p>%s</p>\n", html.EscapeString(sum))
+		}
+	}
+	for _, n := range fitNotes(terms, samps, fits) {
+		fmt.Fprintf(w, "<p>%s</p>\n", html.EscapeString(n))
+	}
+
+	groups := make([]string, 0, len(fits))
+	for g, fit := range fits {
+		if fit != nil {
+			groups = append(groups, g)
+		}
+	}
+	sort.Strings(groups)
+	for _, g := range groups {
+		s, m := samps[g], fits[g].Model
+		fmt.Fprintf(w, "<h2>%s</h2>\n", html.EscapeString(g))
+		if len(s.Min) == 1 {
+			for v := range s.Min {
+				plotSVG(w, g, v, xExprs, yExpr, s, m, flagPlotLog)
+			}
+		}
+		residualSVG(w, g, s, m)
+	}
+
+	fmt.Fprintf(w, "<script>%s</script>\n</body>\n</html>\n", sortScript)
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func writeHTMLRow(w io.Writer, r *row, tag string) {
+	fmt.Fprintf(w, "<tr>")
+	for i, cell := range r.cols {
+		fmt.Fprintf(w, "<%s%s>%s</%s>", tag, classAttr(r.class(i)), html.EscapeString(cell), tag)
+	}
+	fmt.Fprintf(w, "</tr>\n")
+}
@@ -0,0 +1,86 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// svgElem matches an inline SVG drawing.
+var svgElem = regexp.MustCompile(`(?s)<svg .*?</svg>`)
+
+func TestWriteHTMLReport(t *testing.T) {
+	xExprs, yExpr, samps, fits := testFits(t)
+	dir, err := ioutil.TempDir("", "benchls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, logScale := range []bool{false, true} {
+		flagPlotLog = logScale
+		path := filepath.Join(dir, "report.html")
+		err := writeHTMLReport(path, xExprs, xExprs, yExpr, samps, fits, nil, nil, nil, 0)
+		flagPlotLog = false
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		page := string(b)
+
+		for _, want := range []string{
+			"<table class='benchls'>",
+			"<tr><th>group \\ Y ~</th><th>N</th><th>1.0</th>",
+			"<script>" + sortScript + "</script>",
+		} {
+			if !strings.Contains(page, want) {
+				t.Errorf("log %v: expected the report to contain %q", logScale, want)
+			}
+		}
+		// BenchmarkOne is in the table, but has no plots
+		if !strings.Contains(page, "<td>BenchmarkOne</td>") || strings.Contains(page, "<h2>BenchmarkOne</h2>") {
+			t.Errorf("log %v: expected BenchmarkOne in the table but not plotted", logScale)
+		}
+
+		// a plot of the fit and of the residuals of each group that could be
+		// fit, in order, which are well formed and name each observation
+		svgs := svgElem.FindAllString(page, -1)
+		if len(svgs) != 4 {
+			t.Fatalf("log %v: expected 4 plots, got %d", logScale, len(svgs))
+		}
+		for i, g := range []string{"BenchmarkFast", "BenchmarkFast residuals", "BenchmarkSlow", "BenchmarkSlow residuals"} {
+			svg := svgs[i]
+			d := xml.NewDecoder(strings.NewReader(svg))
+			for {
+				if _, err = d.Token(); err != nil {
+					break
+				}
+			}
+			if err != io.EOF {
+				t.Errorf("log %v: plot %d is not well formed: %v", logScale, i, err)
+			}
+			if !strings.Contains(svg, ">"+g+"</text>") {
+				t.Errorf("log %v: expected plot %d to be titled %s", logScale, i, g)
+			}
+			name := strings.Fields(g)[0]
+			if n := strings.Count(svg, "<title>"+name+"/"); n != len(samps[name].Y) {
+				t.Errorf("log %v: expected %d observations in plot %d, got %d", logScale, len(samps[name].Y), i, n)
+			}
+			if i%2 == 0 && !strings.Contains(svg, "<polyline") {
+				t.Errorf("log %v: expected the fitted curve in plot %d", logScale, i)
+			}
+		}
+	}
+}
@@ -0,0 +1,43 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/jonlawlor/benchls"
+)
+
+// writeInfer writes the best fitting complexity class of each group, along
+// with the runner up and how much worse its AIC is.
+func writeInfer(yExpr benchls.Expression, cands map[string][]benchls.Candidate, man *manifest, seed int64, w io.Writer) {
+	groups := make([]string, 0, len(cands))
+	for g := range cands {
+		groups = append(groups, g)
+	}
+	sort.Strings(groups)
+
+	table := []*row{newRow("group \\ "+yExpr.String()+" ~", "class", "adj R^2", "next best", "ΔAIC")}
+	for _, g := range groups {
+		cs := cands[g]
+		r := newRow(g, cs[0].Class, fmt.Sprintf("%.4g", cs[0].AdjRSquared))
+		if len(cs) > 1 {
+			r.add(cs[1].Class)
+			r.add(fmt.Sprintf("%.1f", cs[1].AIC-cs[0].AIC))
+		} else {
+			r.add("~")
+			r.add("~")
+		}
+		table = append(table, r)
+	}
+
+	var buf bytes.Buffer
+	writeHeader(&buf, man, seed)
+	writeTable(&buf, table)
+	w.Write(buf.Bytes())
+}
@@ -0,0 +1,196 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+	"strconv"
+
+	"github.com/jonlawlor/benchls"
+)
+
+// report is the JSON form of the results.
+type report struct {
+	Manifest *manifest  `json:"manifest,omitempty"`
+	Seed     *int64     `json:"seed,omitempty"`
+	Response string     `json:"response"`
+	Field    string     `json:"field,omitempty"` // the -response, if there are several
+	Terms    []string   `json:"terms"`
+	Groups   []groupFit `json:"groups"`
+
+	Crossover *crossoverReport `json:"crossover,omitempty"`
+}
+
+// groupFit is the fit of one group.  The fit fields are omitted if the group
+// could not be fit.
+type groupFit struct {
+	Name         string       `json:"name"`
+	N            int          `json:"n"`
+	Coefficients []number     `json:"coefficients,omitempty"`
+	CI           []number     `json:"ci,omitempty"`
+	T            []number     `json:"t,omitempty"`
+	P            []number     `json:"p,omitempty"`
+	Derived      []number     `json:"derived,omitempty"` // the -derive quantities
+	DerivedCI    []number     `json:"derived_ci,omitempty"`
+	RSquared     *number      `json:"rsquared,omitempty"`
+	DOF          *int         `json:"dof,omitempty"`
+	F            *number      `json:"f,omitempty"`
+	FP           *number      `json:"f_p,omitempty"`
+	AdjRSquared  *number      `json:"adj_rsquared,omitempty"`
+	AIC          *number      `json:"aic,omitempty"`
+	BIC          *number      `json:"bic,omitempty"`
+	LOORMSE      *number      `json:"loo_rmse,omitempty"` // with -cv=loo
+	LOORel       *number      `json:"loo_rel,omitempty"`
+	VIF          []number     `json:"vif,omitempty"`
+	Ratios       []number     `json:"ratios,omitempty"` // to the -baseline group
+	RatioCI      []number     `json:"ratio_ci,omitempty"`
+	Stability    *number      `json:"stability,omitempty"`
+	VarPower     *number      `json:"var_power,omitempty"`
+	DurbinWatson *number      `json:"durbin_watson,omitempty"` // of the residuals ordered by -misspec
+	RunsP        *number      `json:"runs_p,omitempty"`
+	CPUs         []string     `json:"cpus,omitempty"`
+	Error        string       `json:"error,omitempty"` // why the group could not be fit
+	Equation     string       `json:"equation,omitempty"`
+	Predictions  []prediction `json:"predictions,omitempty"`
+	Outliers     []outlier    `json:"outliers,omitempty"`
+}
+
+// number is a float64 that is encoded as null when it is not finite, which
+// JSON cannot represent.
+type number float64
+
+// MarshalJSON implements json.Marshaler.
+func (n number) MarshalJSON() ([]byte, error) {
+	f := float64(n)
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return []byte("null"), nil
+	}
+	return []byte(strconv.FormatFloat(f, 'g', -1, 64)), nil
+}
+
+func numbers(fs []float64) []number {
+	ns := make([]number, len(fs))
+	for i, f := range fs {
+		ns[i] = number(f)
+	}
+	return ns
+}
+
+// writeJSON writes the model fits to the Writer as JSON, with the groups in
+// the -sort order.
+func writeJSON(xExprs []benchls.Expression, yExpr benchls.Expression, samps map[string]benchls.Sample, fits map[string]*benchls.Fit, stabilities, powers map[string]float64, points []map[string]float64, man *manifest, seed int64, w io.Writer) error {
+	rep := report{
+		Manifest: man,
+		Response: yExpr.String(),
+		Terms:    make([]string, len(xExprs)),
+		Groups:   []groupFit{},
+	}
+	if man == nil && stochastic() {
+		rep.Seed = &seed
+	}
+	if len(responses) > 1 {
+		rep.Field = flagYVar
+	}
+	for i, xExpr := range xExprs {
+		rep.Terms[i] = xExpr.String()
+	}
+
+	var base *benchls.Fit
+	if flagBaseline != "" {
+		var err error
+		if base, err = baseline(fits); err != nil {
+			return err
+		}
+	}
+
+	for _, g := range sortedGroups(fits, flagSort) {
+		gf := groupFit{Name: g, N: len(samps[g].Y), CPUs: samps[g].CPUs}
+		if fits[g] == nil {
+			gf.Error = "cannot fit, " + fitFailure(samps[g], xExprs)
+		}
+		if fit := fits[g]; fit != nil {
+			gf.Coefficients = numbers(fit.Model)
+			if flagShowEq {
+				gf.Equation = equation(xExprs, yExpr, fit)
+			}
+			gf.CI = numbers(fit.Stats.CI)
+			for _, d := range derived {
+				dv := benchls.Derive(d, fit.Model, samps[g], fitOpts)
+				gf.Derived = append(gf.Derived, number(dv.Value))
+				gf.DerivedCI = append(gf.DerivedCI, number(dv.CI))
+			}
+			r2 := number(fit.Stats.RSquared)
+			gf.RSquared = &r2
+			gf.DOF = &fit.Stats.DOF
+			if flagStats == "full" {
+				gf.T = numbers(fit.Stats.T)
+				gf.P = numbers(fit.Stats.P)
+				f, fp := number(fit.Stats.F), number(fit.Stats.FP)
+				gf.F, gf.FP = &f, &fp
+			}
+			for _, name := range gofs() {
+				v := number(gof(name, fit.Stats))
+				switch name {
+				case "adj":
+					gf.AdjRSquared = &v
+				case "aic":
+					gf.AIC = &v
+				case "bic":
+					gf.BIC = &v
+				}
+			}
+			if flagCV != "" {
+				cv := benchls.LeaveOneOut(fit.Model, samps[g])
+				rmse, rel := number(cv.RMSE), number(cv.Rel)
+				gf.LOORMSE, gf.LOORel = &rmse, &rel
+			}
+			if flagVIF {
+				gf.VIF = numbers(benchls.VIF(samps[g]))
+			}
+			if base != nil && g != flagBaseline {
+				for _, r := range benchls.Ratios(base, fit, fitOpts) {
+					gf.Ratios = append(gf.Ratios, number(r.Value))
+					gf.RatioCI = append(gf.RatioCI, number(r.CI))
+				}
+			}
+			if flagStability > 0 {
+				s := number(stabilities[g])
+				gf.Stability = &s
+			}
+			if flagVarPower {
+				p := number(powers[g])
+				gf.VarPower = &p
+			}
+			if flagMisspec != "" {
+				mis := benchls.Misspecified(fit.Model, samps[g], flagMisspec)
+				dw, p := number(mis.DurbinWatson), number(mis.RunsP)
+				gf.DurbinWatson, gf.RunsP = &dw, &p
+			}
+			if len(points) > 0 {
+				gf.Predictions = predict(xExprs, samps[g], fit, points)
+			}
+			if flagOutliers {
+				gf.Outliers = outliers(samps[g], fit)
+			}
+		}
+		rep.Groups = append(rep.Groups, gf)
+	}
+
+	if crossoverGroups != nil {
+		var err error
+		if rep.Crossover, err = crossovers(xExprs, samps, fits); err != nil {
+			return err
+		}
+	}
+
+	b, err := json.MarshalIndent(rep, "", "\t")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(b, '\n'))
+	return err
+}
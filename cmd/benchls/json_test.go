@@ -0,0 +1,145 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/jonlawlor/benchls"
+)
+
+// testFits returns the samples and fits of N, 1.0 to three groups: Fast,
+// with Y = 3N + 2, Slow, with Y = 6N + 5, both with a little noise, and One,
+// which has a single observation and cannot be fit.
+func testFits(t *testing.T) ([]benchls.Expression, benchls.Expression, map[string]benchls.Sample, map[string]*benchls.Fit) {
+	names := map[string]struct{}{"N": {}}
+	xExprs, err := benchls.NewExpressions("N, 1.0", names, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names["Y"] = struct{}{}
+	yExpr, err := benchls.NewExpression("Y", names, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	samps := make(map[string]benchls.Sample)
+	fits := make(map[string]*benchls.Fit)
+	for _, g := range []struct {
+		name string
+		b, c float64
+		n    int
+	}{
+		{"BenchmarkFast", 3, 2, 8},
+		{"BenchmarkSlow", 6, 5, 8},
+		{"BenchmarkOne", 1, 0, 1},
+	} {
+		s := benchls.Sample{Min: map[string]float64{}, Max: map[string]float64{}}
+		for i := 1; i <= g.n; i++ {
+			n := 10 * float64(i)
+			s.X = append(s.X, n, 1.0)
+			s.Y = append(s.Y, g.b*n+g.c+0.5*float64(i%3-1))
+			s.Names = append(s.Names, fmt.Sprintf("%s/%g", g.name, n))
+			s.Vars = append(s.Vars, map[string]float64{"N": n})
+			if i == 1 {
+				s.Min["N"] = n
+			}
+			s.Max["N"] = n
+		}
+		samps[g.name] = s
+		fits[g.name] = nil
+		if g.n > 2 {
+			fits[g.name] = benchls.NewFit(s, benchls.Options{})
+		}
+	}
+	return xExprs, yExpr, samps, fits
+}
+
+func TestWriteJSON(t *testing.T) {
+	xExprs, yExpr, samps, fits := testFits(t)
+	var buf bytes.Buffer
+	if err := writeJSON(xExprs, yExpr, samps, fits, nil, nil, nil, nil, 0, &buf); err != nil {
+		t.Fatal(err)
+	}
+	var rep struct {
+		Response string   `json:"response"`
+		Terms    []string `json:"terms"`
+		Groups   []struct {
+			Name         string    `json:"name"`
+			N            int       `json:"n"`
+			Coefficients []float64 `json:"coefficients"`
+			CI           []float64 `json:"ci"`
+			RSquared     *float64  `json:"rsquared"`
+			Error        string    `json:"error"`
+		} `json:"groups"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &rep); err != nil {
+		t.Fatalf("invalid JSON %s: %v", buf.Bytes(), err)
+	}
+	if rep.Response != "Y" || len(rep.Terms) != 2 || rep.Terms[0] != "N" || rep.Terms[1] != "1.0" {
+		t.Errorf("unexpected response %q and terms %q", rep.Response, rep.Terms)
+	}
+	if len(rep.Groups) != 3 {
+		t.Fatalf("expected 3 groups, got %d", len(rep.Groups))
+	}
+	// the groups that could be fit come first, by name
+	for i, want := range []struct {
+		name string
+		b    float64
+	}{{"BenchmarkFast", 3}, {"BenchmarkSlow", 6}} {
+		g := rep.Groups[i]
+		if g.Name != want.name || g.N != 8 || len(g.Coefficients) != 2 || len(g.CI) != 2 || g.RSquared == nil || g.Error != "" {
+			t.Errorf("unexpected group %+v", g)
+			continue
+		}
+		if math.Abs(g.Coefficients[0]-want.b) > g.CI[0] {
+			t.Errorf("%s: expected a slope of %g, got %g ± %g", g.Name, want.b, g.Coefficients[0], g.CI[0])
+		}
+	}
+	if g := rep.Groups[2]; g.Name != "BenchmarkOne" || g.Coefficients != nil || g.RSquared != nil || g.Error == "" {
+		t.Errorf("expected BenchmarkOne to explain why it could not be fit, got %+v", g)
+	}
+}
+
+func TestNumberJSON(t *testing.T) {
+	b, err := json.Marshal([]number{1.5, number(math.NaN()), number(math.Inf(-1)), 2e-9})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(b), "[1.5,null,null,2e-09]"; got != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+}
+
+func TestWriteJSONResponses(t *testing.T) {
+	xExprs, yExpr, samps, fits := testFits(t)
+	defer func() { responses, flagYVar = nil, "NsPerOp" }()
+	responses = []string{"NsPerOp", "AllocsPerOp"}
+
+	// one report per response, in turn
+	var buf bytes.Buffer
+	for _, y := range responses {
+		flagYVar = y
+		if err := writeJSON(xExprs, yExpr, samps, fits, nil, nil, nil, nil, 0, &buf); err != nil {
+			t.Fatal(err)
+		}
+	}
+	dec := json.NewDecoder(&buf)
+	for _, want := range responses {
+		var rep struct {
+			Field string `json:"field"`
+		}
+		if err := dec.Decode(&rep); err != nil {
+			t.Fatal(err)
+		}
+		if rep.Field != want {
+			t.Errorf("expected the report of %s, got %q", want, rep.Field)
+		}
+	}
+}
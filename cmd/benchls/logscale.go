@@ -0,0 +1,99 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/jonlawlor/benchls"
+)
+
+// logTerm is an explanatory term as it was written, before -loglog or
+// -semilogy took its logarithm.
+type logTerm struct {
+	src      string
+	constant bool
+}
+
+// logTerms and logResponse are the terms of -xtransform and the
+// -ytransform for -loglog or -semilogy, or nil and "".  With -back they are
+// the terms and the expression whose logarithm is the response, which is
+// backResponse.
+var (
+	logTerms     []logTerm
+	logResponse  string
+	backResponse benchls.LogResponse
+)
+
+// logTransforms returns the transforms that -loglog or -semilogy fit, which
+// are the logarithms of the response and, for -loglog, of the terms that are
+// not constant.  It sets logTerms and logResponse.
+func logTransforms(xt, yt string, vars map[string]struct{}) (string, string, error) {
+	xExprs, err := benchls.NewExpressions(xt, vars, consts)
+	if err != nil {
+		return "", "", err
+	}
+	logTerms = make([]logTerm, len(xExprs))
+	terms := make([]string, len(xExprs))
+	for i, x := range xExprs {
+		logTerms[i] = logTerm{src: x.String(), constant: constantTerm(x)}
+		terms[i] = x.String()
+		if flagLogLog && !logTerms[i].constant {
+			terms[i] = "math.Log(" + terms[i] + ")"
+		}
+	}
+	logResponse = yt
+	return strings.Join(terms, ", "), "math.Log(" + yt + ")", nil
+}
+
+// backTransforms sets logTerms, logResponse and backResponse for -back, which
+// needs yExpr to be a logarithm.
+func backTransforms(xExprs []benchls.Expression, yExpr benchls.Expression) error {
+	lr, ok := benchls.NewLogResponse(yExpr)
+	if !ok {
+		return fmt.Errorf("-back needs a ytransform that is a logarithm, like math.Log(Y), not %s", yExpr)
+	}
+	logTerms = make([]logTerm, len(xExprs))
+	for i, x := range xExprs {
+		logTerms[i] = logTerm{src: x.String(), constant: constantTerm(x)}
+	}
+	logResponse = lr.Inner
+	backResponse = lr
+	return nil
+}
+
+// backTransformed reports whether the coefficient b of term i is reported as
+// the factor e^b that it multiplies the response by.
+func backTransformed(i int) bool {
+	return logTerms != nil && (flagSemilogY || flagBack || logTerms[i].constant)
+}
+
+// factor returns the factor e^b, or that of the base of the -back response,
+// of the coefficient b, and the half-width of its confidence interval by the
+// delta method.
+func factor(b, cint float64) (float64, float64) {
+	base := math.E
+	if flagBack {
+		base = backResponse.Base
+	}
+	f := math.Pow(base, b)
+	return f, cint * f * math.Log(base)
+}
+
+// logHeading returns the heading of the coefficient of term i.  With
+// -loglog, Y = factor * N^(exponent of N), and with -semilogy,
+// Y = factor * (factor per N)^N.
+func logHeading(i int) string {
+	switch t := logTerms[i]; {
+	case t.constant:
+		return "factor"
+	case flagLogLog:
+		return "exponent of " + t.src
+	default:
+		return "factor per " + t.src
+	}
+}
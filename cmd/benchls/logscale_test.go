@@ -0,0 +1,132 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"math"
+	"testing"
+
+	"github.com/jonlawlor/benchls"
+)
+
+// logSample returns the sample of the terms xt and response yt of
+// y = f(N) for N = 10, 20, ..., 80, with a little multiplicative noise, and
+// its fit.
+func logSample(t *testing.T, xt, yt string, f func(n float64) float64) ([]benchls.Expression, benchls.Sample, *benchls.Fit) {
+	names := map[string]struct{}{"N": {}}
+	xExprs, err := benchls.NewExpressions(xt, names, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names["Y"] = struct{}{}
+	yExpr, err := benchls.NewExpression(yt, names, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := benchls.Sample{Min: map[string]float64{"N": 10}, Max: map[string]float64{"N": 80}}
+	for i := 1; i <= 8; i++ {
+		vars := map[string]float64{"N": 10 * float64(i)}
+		for _, x := range xExprs {
+			s.X = append(s.X, x.Eval(vars))
+		}
+		vars["Y"] = f(vars["N"]) * (1 + 0.001*float64(i%3-1))
+		s.Y = append(s.Y, yExpr.Eval(vars))
+		s.Vars = append(s.Vars, map[string]float64{"N": vars["N"]})
+	}
+	fit := benchls.NewFit(s, benchls.Options{Confidence: 0.95})
+	if fit == nil {
+		t.Fatal("cannot fit the sample")
+	}
+	return xExprs, s, fit
+}
+
+func TestLogTransforms(t *testing.T) {
+	defer func() {
+		flagLogLog, flagSemilogY, logTerms, logResponse = false, false, nil, ""
+	}()
+	names := map[string]struct{}{"N": {}}
+	for _, c := range []struct {
+		loglog   bool
+		xt       string
+		f        func(n float64) float64
+		headings []string
+		coefs    []float64 // the coefficients as they are reported
+	}{
+		// Y = 3 N^2
+		{true, "math.Log(N), 1.0", func(n float64) float64 { return 3 * n * n }, []string{"exponent of N", "factor"}, []float64{2, 3}},
+		// Y = 3 * 1.05^N
+		{false, "N, 1.0", func(n float64) float64 { return 3 * math.Pow(1.05, n) }, []string{"factor per N", "factor"}, []float64{1.05, 3}},
+	} {
+		flagLogLog, flagSemilogY = c.loglog, !c.loglog
+		xt, yt, err := logTransforms("N, 1.0", "Y", names)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if xt != c.xt || yt != "math.Log(Y)" {
+			t.Errorf("loglog %v: expected %s ~ math.Log(Y), got %s ~ %s", c.loglog, c.xt, xt, yt)
+		}
+		if logResponse != "Y" {
+			t.Errorf("loglog %v: expected the response Y, got %s", c.loglog, logResponse)
+		}
+
+		// the reported coefficients are those of the untransformed model
+		_, _, fit := logSample(t, xt, yt, c.f)
+		for i, b := range fit.Model {
+			if backTransformed(i) {
+				b, _ = factor(b, fit.Stats.CI[i])
+			}
+			if math.Abs(b-c.coefs[i]) > 1e-3*c.coefs[i] {
+				t.Errorf("loglog %v: expected the %s to be %g, got %g", c.loglog, logHeading(i), c.coefs[i], b)
+			}
+			if h := logHeading(i); h != c.headings[i] {
+				t.Errorf("loglog %v: expected the heading %s, got %s", c.loglog, c.headings[i], h)
+			}
+		}
+	}
+}
+
+func TestBackTransforms(t *testing.T) {
+	defer func() {
+		flagBack, logTerms, logResponse, backResponse = false, nil, "", benchls.LogResponse{}
+	}()
+	flagBack = true
+
+	// log2(Y) = 2 log2(N) + log2(3), so Y = 3 N^2
+	xExprs, s, fit := logSample(t, "math.Log2(N), 1.0", "math.Log2(Y)", func(n float64) float64 { return 3 * n * n })
+	names := map[string]struct{}{"N": {}, "Y": {}}
+	yExpr, err := benchls.NewExpression("math.Log2(Y)", names, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := backTransforms(xExprs, yExpr); err != nil {
+		t.Fatal(err)
+	}
+	if backResponse.Base != 2 || logResponse != "Y" {
+		t.Errorf("expected the base 2 logarithm of Y, got %+v", backResponse)
+	}
+	// the factors that a unit of each term multiplies Y by
+	for i, want := range []float64{4, 3} {
+		if !backTransformed(i) {
+			t.Errorf("expected coefficient %d to be back-transformed", i)
+		}
+		if f, _ := factor(fit.Model[i], fit.Stats.CI[i]); math.Abs(f-want) > 1e-3*want {
+			t.Errorf("expected the factor of %s to be %g, got %g", xExprs[i], want, f)
+		}
+	}
+
+	// the predictions are of Y
+	preds := predict(xExprs, s, fit, []map[string]float64{{"N": 100}})
+	if y := float64(preds[0].Y); math.Abs(y-3e4) > 1e-3*3e4 || !(float64(preds[0].Lo) < y && y < float64(preds[0].Hi)) {
+		t.Errorf("expected the prediction 30000 in its interval, got %+v", preds[0])
+	}
+
+	yExpr, err = benchls.NewExpression("Y", names, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := backTransforms(xExprs, yExpr); err == nil {
+		t.Error("expected -back to need a logarithm of the response")
+	}
+}
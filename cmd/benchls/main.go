@@ -0,0 +1,1570 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// benchls computes a least squares fit on groups of parameterized benchmarks.
+//
+// Usage:
+//
+//	benchls [options] bench.txt
+//	benchls -compare [options] old.txt new.txt
+//	benchls subcommand [options] [arguments]
+//
+// The input bench.txt file should contain the concatenated output of a number
+// of runs of ``go test -bench.'' Benchmarks that match the regexp in the
+// ``vars'' flag will be collected into a sample for fitting a least squares
+// regression.  Captures of vars that are not numbers name the group instead,
+// so -vars="/(?P<algo>[a-z]+)(?P<N>\\d+)-\\d+$" fits BenchmarkSort/quick1000-4
+// in the group BenchmarkSort/algo=quick.  With -categorical=algo the
+// algorithms are fit together instead, with an indicator term algo_quick that
+// is 1 for quick and 0 for the others, so -xt="N, N * algo_quick, 1.0,
+// algo_quick" fits a slope and an offset for each algorithm.
+//
+// The GOMAXPROCS suffix of each benchmark name, as in BenchmarkSort10-4, is
+// available to the transforms as the variable P unless vars captures a P of its
+// own.  If the input has ``cpu:'' configuration lines, the report notes which
+// cpu each group was measured on.  Other configuration lines, like ``pkg:'' or
+// ``goos:'', can split the groups with -group-by, as can P, to model each
+// GOMAXPROCS separately.  Metrics reported with
+// b.ReportMetric can be used as the response.
+//
+// The subcommands each take the options that apply to them, some under
+// shorter names, as listed by ``benchls subcommand -h'':
+//
+//	fit      fits the groups of benchmarks, like benchls without a subcommand
+//	compare  reports the change in each coefficient from old.txt to new.txt
+//	predict  predicts the responses at the input variables given by -at
+//	plot     plots the fits in the directory given by -dir, or as -heatmap or -html-report
+//	check    fails if a fit violates a -check threshold
+//	run      runs the benchmarks of packages with go test, and fits them
+//	serve    serves a dashboard of the fits of uploaded benchmarks over time
+//
+// An option that does not apply is an error, as it is on the flat command
+// line for the options of another mode, like -json with -infer.
+//
+// benchls run runs the benchmarks of the packages, or of the package in the
+// current directory, with ``go test -bench'' and fits its output, without an
+// intermediate file.  Its -bench flag selects the benchmarks to run, and its
+// -count flag how many times to run each.
+//
+// benchls serve listens on the address given by -listen for the output of
+// go test -bench POSTed to /upload, as by
+//
+//	curl --data-binary @bench.txt 'localhost:8080/upload?label=v1.2'
+//
+// stores each upload in the directory given by -store, and serves a dashboard
+// at / with the fits of every group in each upload, oldest first, like
+// -series.  It also serves the fits of the latest upload at /metrics, as
+// Prometheus gauges like those written by -prometheus.  The uploads are fit
+// on each request, with the flags that benchls serve was started with, which
+// cannot include -fit=nls.  A request whose fits
+// take longer than -timeout, a minute by default, fails with 504 Gateway
+// Timeout.
+//
+// The results of other benchmark harnesses are read with -input-format.  Their
+// parameters become the elements of sub-benchmark names, like
+// Benchmarkorg.sample.Sort/size=1000-1, so that -auto-vars finds them as
+// variables, and their times are converted to ns/op.  Google Benchmark's
+// positional arguments are kept, so BM_Sort/1024 has N=1024 like
+// BenchmarkSort/1024, and its cpu time is the metric cpu-ns/op.  criterion.rs
+// benchmarks are named like BenchmarkSort/quick/1024 for the function quick
+// of the group Sort with the value 1024, and each of the samples of its
+// raw.csv is a replicate.  pytest-benchmark's params are the variables of its
+// tests, its mean time is ns/op, and its median time the metric median-ns/op.
+//
+// Any other measurements can be fit from a CSV table, like
+//
+//	benchls -input-format=csv -x-cols=N,M -y-col=seconds -xt="N * M, 1.0" times.csv
+//
+// which finds the variables N and M and the response seconds in the columns
+// of those names.
+//
+// The samples and fits of each group can be written for Arrow-native tools
+// with -arrow, as the Arrow IPC files samples.arrow and fits.arrow.  There is
+// no Parquet output, and Arrow is not an -input-format.  The Arrow libraries
+// are large, so -arrow is only in a benchls built with the arrow build tag:
+//
+//	go get -tags arrow github.com/jonlawlor/benchls/cmd/benchls
+//
+// Benchmarks that call b.SetBytes report a throughput in MB/s, which implies
+// the number of bytes processed per op.  That is available to the transforms
+// as BytesPerOp, and multiplied by the number of iterations as TotalBytes.  It
+// can also be used as the response with -response=BytesPerOp.  Both are 0 for
+// benchmarks that do not report a throughput, which are left out of the fits
+// when BytesPerOp is the response.
+//
+// Example
+//
+// Suppose we collect benchmark results from running ``go test -bench=Sort''
+// on this package.
+//
+// The file bench.txt contains:
+//
+//   PASS
+//   BenchmarkSort10-4            	 1000000	      1008 ns/op
+//   BenchmarkSort100-4           	  200000	      8224 ns/op
+//   BenchmarkSort1000-4          	   10000	    152945 ns/op
+//   BenchmarkSort10000-4         	    1000	   1950999 ns/op
+//   BenchmarkSort100000-4        	      50	  25081946 ns/op
+//   BenchmarkSort1000000-4       	       5	 302228845 ns/op
+//   BenchmarkSort10000000-4      	       1	3631295293 ns/op
+//   BenchmarkStableSort10-4      	 1000000	      1260 ns/op
+//   BenchmarkStableSort100-4     	  100000	     16730 ns/op
+//   BenchmarkStableSort1000-4    	    5000	    362024 ns/op
+//   BenchmarkStableSort10000-4   	     300	   5731738 ns/op
+//   BenchmarkStableSort100000-4  	      20	  88171712 ns/op
+//   BenchmarkStableSort1000000-4 	       1	1205361782 ns/op
+//   BenchmarkStableSort10000000-4	       1	14349613704 ns/op
+//   ok  	github.com/jonlawlor/benchls	138.860s
+//
+// In these benchmarks, the suffix 10 .. 10000000 indicates how many items are
+// sorted in the benchmark.  benchls can estimate the relationship between the
+// number of elements to sort and how long it takes to perform the sort.
+// Assuming that the amount of time is proportional to n*log(n) and an offset,
+// we can run benchls with:
+//
+//    $ benchls -vars="/?(?P<N>\\d+)-\\d+$" -xtransform="math.Log(N) * N, 1.0" bench.txt
+//    group \ Y ~          math.Log(N) * N    1.0             R^2                 df  n
+//    BenchmarkSort        2.254e+01±6.4e-02  -2e+06±3.9e+06  0.9999949426719544   5  7
+//    BenchmarkStableSort  8.906e+01±1.8e-01  -7e+06±1.1e+07  0.9999973642760738   5  7
+//
+// Where the coefficient for BenchMarkSort's math.Log(N) * N is 2.653e+01 and the
+// intercept is -3e+06.  The numbers after the ``±'' indicate the 95% confidence
+// interval, or another level set with -confidence.  In this case the first
+// coefficient is significant to 3 decimal places, but the intercept is not
+// significant.  We can also see that in this particular benchmark comparing
+// sort.Sort of []int to sort.Stable of []int, sort.Stable takes approximately
+// 4x as long as sort.Sort.
+// The df and n columns are the residual degrees of freedom of each fit and
+// the number of observations it is based on.  Each replicate of a benchmark,
+// as from go test -count, is an observation unless -agg combines them.
+//
+// Other options are:
+//  -agg string
+//    	how to combine the replicates of a benchmark, like those of go test -count, "mean", "median", "min" or "all" to fit each replicate as its own observation (default "all")
+//  -arrow string
+//    	directory to write the samples and fits to as Arrow IPC files, if benchls is built with -tags arrow
+//  -auto-vars
+//    	find named input variables in key=value sub-benchmark names instead of using vars
+//  -back
+//    	report the coefficients and predictions of a ytransform that is a logarithm, like math.Log(Y), on the original scale of the response: each coefficient as the factor that a unit of its term multiplies the response by, and each prediction with its interval
+//  -baseline string
+//    	group to report the coefficients of every other group relative to, as ratios with confidence intervals
+//  -breakpoints int
+//    	fit each group piecewise, in segments split at up to this many breakpoints in the first term of xtransform, and report the segments (0 disables)
+//  -candidates string
+//    	candidate explanatory terms, separated by commas, like "N * N, N * math.Log(N), N, 1.0"; report the subset of them that fits each group best by BIC instead of fitting xtransform (at most 16 terms)
+//  -categorical string
+//    	input variables whose values are names, like algo in BenchmarkSort/algo=quick, separated by commas; the groups that differ only in them are fit together, and -xtransform gains an indicator term, like algo_quick, for each value but the first unless it has them already
+//  -check value
+//    	fail with exit status 1 if a fit violates this threshold, like "BenchmarkSort: coeff[0] < 30" or "*: r2 >= 0.99" (repeatable)
+//  -check-file string
+//    	file of -check thresholds, one per line
+//  -columns string
+//    	statistics to show in the report, separated by commas, from "coeffs" (the coefficients and their confidence intervals), "se" (their standard errors), "r2", "df" (the residual degrees of freedom), "n" (the number of observations) and "pkg" (the packages of the benchmarks) (default "coeffs,r2,df,n")
+//  -compare
+//    	fit the same model to two input files and report the change in each coefficient
+//  -confidence float
+//    	level of the confidence and prediction intervals (default 0.95)
+//  -const string
+//    	named constants for the transforms, separated by commas, like "B=4096, C=64"
+//  -crossover string
+//    	two groups, separated by a comma, like "BenchmarkSort,BenchmarkStableSort", to report where their fits predict the same response, searching from 1/1000 of the smallest observed value of their input variable to 1000 times the largest
+//  -cv string
+//    	cross validation of each group, "loo" for the leave-one-out error relative to the root mean square of the response, which, unlike R^2, grows when a model fits few observations by chance
+//  -db string
+//    	SQLite database to append the samples and fitted coefficients to, as a run with the time and -label
+//  -derive string
+//    	quantities derived from the coefficients, which are b0, b1 and so on, separated by commas, like "b1 / b0"; each is reported with its confidence interval by the delta method
+//  -dump-samples string
+//    	directory to write one CSV file of samples per group to
+//  -emit-go string
+//    	file to write a Go function per group, like PredictSort(n float64) float64, that predicts its response with the fitted coefficients
+//  -exclude string
+//    	leave out the groups whose names match this regexp
+//  -fit string
+//    	fitting method, "ols" for least squares, "robust" for a Huber loss that downweights outliers, or "nls" for the nonlinear -model (default "ols")
+//  -format string
+//    	table format, one of "text", "csv" or "tsv" (default "text")
+//  -formula string
+//    	the model as an R formula, like "log(Y) ~ log(N) + P", instead of -ytransform and -xtransform; terms are separated by +, a:b is a * b, a*b is a + b + a:b, arithmetic is protected by I(), and there is an intercept unless it has - 1
+//  -gof string
+//    	extra goodness of fit columns, separated by commas, from "adj" (adjusted R^2), "aic" and "bic"
+//  -group string
+//    	template of the group keys, like "{name}/{goos}", in which {name} is the group that -vars finds and each other {key} is the value of that configuration line, or P for the GOMAXPROCS suffix
+//  -group-by string
+//    	configuration lines, separated by commas, whose values split the groups, like "goos,pkg", or P for the GOMAXPROCS suffix
+//  -heatmap string
+//    	file to write an HTML heatmap of the fitted surface and residuals of two variable groups to
+//  -highlight string
+//    	mark the significance of each coefficient, "stars" for * (p < 1 - confidence), ** (p 5 times smaller) or *** (p 50 times smaller), which are p < 0.05, 0.01 and 0.001 at the default confidence, or "color" for ANSI colors in text on a terminal and CSS classes in HTML
+//  -html
+//    	print results as an HTML table
+//  -html-report string
+//    	file to write a self-contained HTML report to, with a sortable table and plots of the fit and residuals of each group
+//  -infer
+//    	report the best fitting complexity class of each group instead of fitting xtransform
+//  -input-format string
+//    	format of the input files, "go" for go test -bench output, "jmh" for the CSV or JSON results of the Java Microbenchmark Harness, "gbench" for the --benchmark_format=json output of Google Benchmark, "criterion" for the raw.csv of criterion.rs or its target/criterion directory, "pytest" for the JSON of pytest-benchmark, or "csv" for a table with a header, whose -x-cols are the input variables and -y-col the response (default "go")
+//  -interactions int
+//    	use every product of up to this many distinct input variables, and an intercept, as the explanatory terms instead of xtransform (0 disables)
+//  -json
+//    	print results as JSON, an object for each -response in turn
+//  -label string
+//    	labels of the -series input files, separated by commas, like commits or dates (default the file names), or the label of the run appended to -db
+//  -load-model string
+//    	file of fits saved by -save-model to report, predict with, or compare a single input file against with -compare, instead of reading an input file
+//  -loglog
+//    	fit the logarithm of the response to the logarithms of the xtransform terms, and report the exponent of each term and the constant factor
+//  -manifest
+//    	embed the flags, input hashes, version and random seed in the report
+//  -match string
+//    	only sample and fit the groups whose names match this regexp
+//  -matrix
+//    	compare the leading coefficient of each group across goos/goarch/cpu configurations
+//  -misspec string
+//    	input variable, like N, to order the residuals of each group by, to report their Durbin-Watson statistic and the p-value of a runs test of their signs, and to note the groups with too few runs, as when the transform is wrong
+//  -model string
+//    	nonlinear model for -fit=nls, like "a * math.Pow(N, b)"; the identifiers that are not input variables are its parameters
+//  -noise float
+//    	before the fits, report the coefficient of variation of the replicates of each benchmark, like those of go test -count, and warn about those that vary by more than this fraction, like 0.05 (0 disables)
+//  -numfmt string
+//    	number format of the coefficients and confidence intervals, a verb like "%.3g", "eng" for exponents that are multiples of 3, or "si" for SI prefixes, like 22.5 or 1.2M (default the significant digits in scientific notation)
+//  -outliers
+//    	list the observations that unduly influence each fit, by studentized residual and Cook's distance
+//  -per-element
+//    	also summarize each fit as its response per unit of each term and its fixed response, like "24 B per N + 1.1 KiB fixed"
+//  -plot string
+//    	directory to write a plot of the observations and fitted curve of each single variable group to
+//  -plotext string
+//    	file extension, and so the format, of the -plot plots: svg, png, pdf, eps, jpg or tif (default "svg")
+//  -plotlog
+//    	use log-log axes in plots
+//  -pool string
+//    	explanatory terms of a model fit to all of the groups jointly, separated by commas, in which the terms in group(), like "N, group(1.0)", have a coefficient for each group and the others one that all of the groups share, instead of -xtransform
+//  -powerlaw
+//    	report the exponent b and constant c of the power law Y = c * N^b of each group instead of fitting xtransform
+//  -predict string
+//    	predict the response of each group at these input variables, like "N=1e8,1e9" or "M=10;N=1e8"
+//  -prometheus string
+//    	file to write the coefficients, their confidence intervals and the R^2 of each group to as Prometheus gauges, for the node exporter's textfile collector
+//  -proto string
+//    	file to write the fits to as a protocol buffer, a Fits message of the schema in cmd/benchls/benchls.proto, for reading them in other languages
+//  -ranges
+//    	show the observed range of the input variables in each group
+//  -ref string
+//    	reference configuration for -matrix, like "linux/amd64/Intel Xeon"
+//  -relci
+//    	report each confidence interval as a percentage of its coefficient
+//  -repvar
+//    	weight each observation by the inverse of the variance of its benchmark's replicates, like those of go test -count, and fit by generalized least squares
+//  -residuals string
+//    	file to write the fitted value and residuals of every observation to ("-" for after the report)
+//  -response string
+//    	benchmark field to use as a response variable {"NsPerOp", "AllocedBytesPerOp", "AllocsPerOp", "MBPerS", "BytesPerOp"}, or the unit of any metric in the input, like "cachemisses/op"; several, separated by commas, are reported in turn (default "NsPerOp")
+//  -response-stat string
+//    	how to combine the replicates of a benchmark, like those of go test -count, "mean", "median", "min" or "all" to fit each replicate as its own observation (same as -agg) (default "all")
+//  -save-model string
+//    	file to save the fits, and the samples and flags they were made with, to
+//  -se string
+//    	standard errors, "ols" for the usual ones, or "hc1" for White's heteroskedasticity consistent ones, for when the variance grows with the inputs (default "ols")
+//  -seed int
+//    	seed for the random number generator used by stochastic methods (default a random seed)
+//  -semilogy
+//    	fit the logarithm of the response to the xtransform terms, and report the factor that each unit of a term multiplies the response by, and the constant factor
+//  -series
+//    	fit each of any number of input files, like the benchmarks of successive commits, and report the coefficients of every group in long format
+//  -show-equation
+//    	write the fit of each group as an equation after the report, like "BenchmarkSort: Y ≈ 22.5·N·log(N) − 2.0e6 ns"
+//  -sig
+//    	mark whether each coefficient is significantly different from zero
+//  -smear
+//    	with -back, multiply the predictions by Duan's smearing estimate, so that they estimate the mean of the response rather than its median
+//  -solver string
+//    	the least squares solver, "lapack" for LAPACK's QR, or "native" for a Householder QR written in Go, which needs no cgo or assembly (default "lapack")
+//  -sort string
+//    	order of the groups in the report, one of "name", "r2" or "coef" (largest first) (default "name")
+//  -stability int
+//    	number of random subsamples used to score the stability of the leading coefficient (0 disables)
+//  -stats string
+//    	"ci" for confidence intervals, or "full" to add t statistics, p-values and an F test (default "ci")
+//  -strict
+//    	exit with an error if none of the benchmark results of an input are sampled
+//  -summary
+//    	describe the fit of each group in a sentence after the report, relative to the first group or -baseline, like "StableSort is ~3.95× Sort per N·log(N); the intercept is not significant"
+//  -units
+//    	show the unit of each coefficient, like ns/N, derived from the response and the term (only with the default ytransform)
+//  -varpower
+//    	model the residual variance as a power of the fitted mean and refit with the implied weights
+//  -vars string
+//    	where to find named input variables in the benchmark names (default "/?(?P<N>\\d+)-\\d+$")
+//  -verbose
+//    	log how many of the benchmark results of each input are sampled, and how many are skipped and why
+//  -vif
+//    	show the variance inflation factor of each term, which is large when the terms are nearly collinear
+//  -watch
+//    	rerun whenever the input files change; they may be globs, like "results/*.txt", which are expanded on every run
+//  -weights string
+//    	weight of each observation for weighted least squares, an expression of the input variables, like "1 / (N * N)" when the variance of the response grows with the square of N
+//  -widen
+//    	multiply the prediction interval of each -predict point outside the observed range by how many times farther out than the range it is, like 100 for N=1e9 when the largest N is 1e7
+//  -worst
+//    	name the observation with the largest standardized residual in each group
+//  -x-cols string
+//    	columns of a -input-format=csv table that are the input variables, separated by commas, like "N,M"
+//  -xt string
+//    	how to construct the explanatory variables from the input variables, separated by commas (shorthand) (default "N, 1.0")
+//  -xt-for value
+//    	override xtransform for the groups whose names match a regexp, like "BenchmarkSort.*=N*math.Log(N), 1.0" (repeatable)
+//  -xtransform string
+//    	how to construct the explanatory variables from the input variables, separated by commas (default "N, 1.0")
+//  -y-col string
+//    	column of a -input-format=csv table that is the response, which is the default -response
+//  -yt string
+//    	how to transform the response variable (shorthand) (default "Y")
+//  -ytransform string
+//    	how to transform the response variable (default "Y")
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"math"
+	"math/rand"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jonlawlor/benchls"
+)
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: benchls [options] bench.txt\n")
+	fmt.Fprintf(os.Stderr, "       benchls -compare [options] old.txt new.txt\n")
+	fmt.Fprintf(os.Stderr, "       benchls subcommand [options] [arguments]\n")
+	fmt.Fprintf(os.Stderr, "performs a least squares fit on parameterized benchmarks\n")
+	fmt.Fprintf(os.Stderr, "subcommands, each with a subset of the options, see benchls subcommand -h:\n")
+	for _, name := range subcommandNames() {
+		fmt.Fprintf(os.Stderr, "   %-8s %s\n", name, subcommands[name].doc)
+	}
+	fmt.Fprintf(os.Stderr, "example:\n")
+	fmt.Fprintf(os.Stderr, "   benchls -vars=\"(?P<M>\\d+)x(?P<N>\\d+)-\\d+$\" -xt=\"math.Log(M), math.Log(N), 1.0\" -yt=\"math.Log(Y)\"\n")
+	fmt.Fprintf(os.Stderr, "options:\n")
+	flag.PrintDefaults()
+	os.Exit(2)
+}
+
+var (
+	flagInputMatch string
+	flagXTransform string
+	flagYTransform string
+	flagYVar       string
+	flagHTML       bool
+	flagJSON       bool
+	flagFormat     string
+	flagFit        string
+	flagInfer      bool
+	flagCands      string
+	flagCompare    bool
+	flagStability  int
+	flagManifest   bool
+	flagSeed       int64
+	flagArrow      string
+	flagWorst      bool
+	flagShowEq     bool
+	flagSummary    bool
+	flagMisspec    string
+	flagRelCI      bool
+	flagSig        bool
+	flagRanges     bool
+	flagVerbose    bool
+	flagStrict     bool
+	flagVarPower   bool
+	flagRepVar     bool
+	flagWeights    string
+	flagMatrix     bool
+	flagRef        string
+	flagDump       string
+	flagHeatmap    string
+	flagResiduals  string
+	flagPredict    string
+	flagWiden      bool
+	flagPlot       string
+	flagPlotExt    string
+	flagPlotLog    bool
+	flagSort       string
+	flagStats      string
+	flagGOF        string
+	flagDerive     string
+	flagCV         string
+	flagConfidence float64
+	flagGroupBy    string
+	flagGroup      string
+	flagAgg        string
+	flagNoise      float64
+	flagAutoVars   bool
+	flagModel      string
+	flagPowerLaw   bool
+	flagChecks     checkList
+	flagCheckFile  string
+	flagMatch      string
+	flagExclude    string
+	flagHTMLReport string
+	flagSE         string
+	flagSolver     string
+	flagOutliers   bool
+	flagVIF        bool
+	flagConst      string
+	flagCateg      string
+	flagPool       string
+	flagFormula    string
+	flagInteract   int
+	flagBreaks     int
+	flagCrossover  string
+	flagBaseline   string
+	flagSaveModel  string
+	flagLoadModel  string
+	flagSeries     bool
+	flagLabel      string
+	flagWatch      bool
+	flagXTFor      xtOverrides
+	flagEmitGo     string
+	flagDB         string
+	flagPrometheus string
+	flagProto      string
+	flagInFormat   string
+	flagXCols      string
+	flagYCol       string
+	flagColumns    string
+	flagHighlight  string
+	flagNumFmt     string
+	flagUnits      bool
+	flagLogLog     bool
+	flagSemilogY   bool
+	flagBack       bool
+	flagSmear      bool
+	flagPerElement bool
+)
+
+// fitOpts are the settings of the fits, from -confidence, -se and -solver.
+var fitOpts benchls.Options
+
+// consts are the named constants of -const, which the transforms may use.
+var consts map[string]float64
+
+// nonlinear is the parsed -model, for -fit=nls.
+var nonlinear *benchls.Nonlinear
+
+// derived are the parsed -derive quantities.
+var derived []benchls.Expression
+
+// weights is the parsed -weights, if it is set.
+var weights benchls.Expression
+
+// responses are the fields named by -response.  flagYVar is set to each in
+// turn.
+var responses []string
+
+func init() {
+	flag.StringVar(&flagInputMatch, "vars", `/?(?P<N>\d+)-\d+$`, "where to find named input variables in the benchmark names")
+	flag.BoolVar(&flagAutoVars, "auto-vars", false, "find named input variables in key=value sub-benchmark names instead of using vars")
+
+	flag.StringVar(&flagMatch, "match", "", "only sample and fit the groups whose names match this regexp")
+	flag.StringVar(&flagExclude, "exclude", "", "leave out the groups whose names match this regexp")
+
+	const (
+		defaultXTransform = "N, 1.0"
+		XTransformUsage   = "how to construct the explanatory variables from the input variables, separated by commas"
+	)
+	flag.StringVar(&flagXTransform, "xtransform", defaultXTransform, XTransformUsage)
+	flag.StringVar(&flagXTransform, "xt", defaultXTransform, XTransformUsage+" (shorthand)")
+	flag.Var(&flagXTFor, "xt-for", `override xtransform for the groups whose names match a regexp, like "BenchmarkSort.*=N*math.Log(N), 1.0" (repeatable)`)
+	flag.IntVar(&flagInteract, "interactions", 0, "use every product of up to this many distinct input variables, and an intercept, as the explanatory terms instead of xtransform (0 disables)")
+
+	flag.StringVar(&flagYVar, "response", "NsPerOp", `benchmark field to use as a response variable {"`+strings.Join(benchls.Responses, `", "`)+`"}, or the unit of any metric in the input, like "cachemisses/op"; several, separated by commas, are reported in turn`)
+
+	flag.StringVar(&flagPool, "pool", "", `explanatory terms of a model fit to all of the groups jointly, separated by commas, in which the terms in group(), like "N, group(1.0)", have a coefficient for each group and the others one that all of the groups share, instead of -xtransform`)
+	flag.StringVar(&flagFormula, "formula", "", `the model as an R formula, like "log(Y) ~ log(N) + P", instead of -ytransform and -xtransform; terms are separated by +, a:b is a * b, a*b is a + b + a:b, arithmetic is protected by I(), and there is an intercept unless it has - 1`)
+	flag.StringVar(&flagCateg, "categorical", "", `input variables whose values are names, like algo in BenchmarkSort/algo=quick, separated by commas; the groups that differ only in them are fit together, and -xtransform gains an indicator term, like algo_quick, for each value but the first unless it has them already`)
+	flag.StringVar(&flagConst, "const", "", `named constants for the transforms, separated by commas, like "B=4096, C=64"`)
+
+	const (
+		defaultYTransform = "Y"
+		YTransformUsage   = "how to transform the response variable"
+	)
+	flag.StringVar(&flagYTransform, "ytransform", defaultYTransform, YTransformUsage)
+	flag.StringVar(&flagYTransform, "yt", defaultYTransform, YTransformUsage+" (shorthand)")
+
+	flag.BoolVar(&flagHTML, "html", false, "print results as an HTML table")
+	flag.StringVar(&flagHTMLReport, "html-report", "", "file to write a self-contained HTML report to, with a sortable table and plots of the fit and residuals of each group")
+	flag.BoolVar(&flagJSON, "json", false, "print results as JSON, an object for each -response in turn")
+	flag.StringVar(&flagFormat, "format", "text", `table format, one of "text", "csv" or "tsv"`)
+
+	flag.BoolVar(&flagManifest, "manifest", false, "embed the flags, input hashes, version and random seed in the report")
+
+	flag.IntVar(&flagStability, "stability", 0, "number of random subsamples used to score the stability of the leading coefficient (0 disables)")
+
+	const aggUsage = `how to combine the replicates of a benchmark, like those of go test -count, "mean", "median", "min" or "all" to fit each replicate as its own observation`
+	flag.StringVar(&flagAgg, "agg", "all", aggUsage)
+	flag.StringVar(&flagAgg, "response-stat", "all", aggUsage+" (same as -agg)")
+	flag.Float64Var(&flagNoise, "noise", 0, "before the fits, report the coefficient of variation of the replicates of each benchmark, like those of go test -count, and warn about those that vary by more than this fraction, like 0.05 (0 disables)")
+	flag.StringVar(&flagInFormat, "input-format", "go", `format of the input files, "go" for go test -bench output, "jmh" for the CSV or JSON results of the Java Microbenchmark Harness, "gbench" for the --benchmark_format=json output of Google Benchmark, "criterion" for the raw.csv of criterion.rs or its target/criterion directory, "pytest" for the JSON of pytest-benchmark, or "csv" for a table with a header, whose -x-cols are the input variables and -y-col the response`)
+	flag.StringVar(&flagXCols, "x-cols", "", `columns of a -input-format=csv table that are the input variables, separated by commas, like "N,M"`)
+	flag.StringVar(&flagYCol, "y-col", "", "column of a -input-format=csv table that is the response, which is the default -response")
+	flag.StringVar(&flagGroup, "group", "", `template of the group keys, like "{name}/{goos}", in which {name} is the group that -vars finds and each other {key} is the value of that configuration line, or P for the GOMAXPROCS suffix`)
+	flag.StringVar(&flagGroupBy, "group-by", "", `configuration lines, separated by commas, whose values split the groups, like "goos,pkg", or P for the GOMAXPROCS suffix`)
+
+	flag.Var(&flagChecks, "check", `fail with exit status 1 if a fit violates this threshold, like "BenchmarkSort: coeff[0] < 30" or "*: r2 >= 0.99" (repeatable)`)
+	flag.StringVar(&flagCheckFile, "check-file", "", "file of -check thresholds, one per line")
+
+	flag.BoolVar(&flagCompare, "compare", false, "fit the same model to two input files and report the change in each coefficient")
+
+	flag.BoolVar(&flagSeries, "series", false, "fit each of any number of input files, like the benchmarks of successive commits, and report the coefficients of every group in long format")
+	flag.StringVar(&flagLabel, "label", "", "labels of the -series input files, separated by commas, like commits or dates (default the file names), or the label of the run appended to -db")
+
+	flag.StringVar(&flagSaveModel, "save-model", "", "file to save the fits, and the samples and flags they were made with, to")
+	flag.StringVar(&flagLoadModel, "load-model", "", "file of fits saved by -save-model to report, predict with, or compare a single input file against with -compare, instead of reading an input file")
+
+	flag.BoolVar(&flagMatrix, "matrix", false, "compare the leading coefficient of each group across goos/goarch/cpu configurations")
+	flag.StringVar(&flagRef, "ref", "", "reference configuration for -matrix, like \"linux/amd64/Intel Xeon\"")
+
+	flag.StringVar(&flagBaseline, "baseline", "", "group to report the coefficients of every other group relative to, as ratios with confidence intervals")
+	flag.StringVar(&flagCrossover, "crossover", "", `two groups, separated by a comma, like "BenchmarkSort,BenchmarkStableSort", to report where their fits predict the same response, searching from 1/1000 of the smallest observed value of their input variable to 1000 times the largest`)
+	flag.StringVar(&flagPredict, "predict", "", `predict the response of each group at these input variables, like "N=1e8,1e9" or "M=10;N=1e8"`)
+	flag.BoolVar(&flagWiden, "widen", false, "multiply the prediction interval of each -predict point outside the observed range by how many times farther out than the range it is, like 100 for N=1e9 when the largest N is 1e7")
+
+	flag.StringVar(&flagSort, "sort", "name", `order of the groups in the report, one of "name", "r2" or "coef" (largest first)`)
+
+	flag.StringVar(&flagColumns, "columns", "coeffs,r2,df,n", `statistics to show in the report, separated by commas, from "coeffs" (the coefficients and their confidence intervals), "se" (their standard errors), "r2", "df" (the residual degrees of freedom), "n" (the number of observations) and "pkg" (the packages of the benchmarks)`)
+
+	flag.BoolVar(&flagRanges, "ranges", false, "show the observed range of the input variables in each group")
+	flag.BoolVar(&flagVerbose, "verbose", false, "log how many of the benchmark results of each input are sampled, and how many are skipped and why")
+	flag.BoolVar(&flagStrict, "strict", false, "exit with an error if none of the benchmark results of an input are sampled")
+
+	flag.Float64Var(&flagConfidence, "confidence", 0.95, "level of the confidence and prediction intervals")
+
+	flag.StringVar(&flagSE, "se", "ols", `standard errors, "ols" for the usual ones, or "hc1" for White's heteroskedasticity consistent ones, for when the variance grows with the inputs`)
+	flag.StringVar(&flagSolver, "solver", "lapack", `the least squares solver, "lapack" for LAPACK's QR, or "native" for a Householder QR written in Go, which needs no cgo or assembly`)
+
+	flag.StringVar(&flagNumFmt, "numfmt", "", `number format of the coefficients and confidence intervals, a verb like "%.3g", "eng" for exponents that are multiples of 3, or "si" for SI prefixes, like 22.5 or 1.2M (default the significant digits in scientific notation)`)
+
+	flag.BoolVar(&flagPerElement, "per-element", false, `also summarize each fit as its response per unit of each term and its fixed response, like "24 B per N + 1.1 KiB fixed"`)
+	flag.BoolVar(&flagUnits, "units", false, "show the unit of each coefficient, like ns/N, derived from the response and the term (only with the default ytransform)")
+
+	flag.BoolVar(&flagRelCI, "relci", false, "report each confidence interval as a percentage of its coefficient")
+
+	flag.StringVar(&flagStats, "stats", "ci", `"ci" for confidence intervals, or "full" to add t statistics, p-values and an F test`)
+
+	flag.StringVar(&flagGOF, "gof", "", `extra goodness of fit columns, separated by commas, from "adj" (adjusted R^2), "aic" and "bic"`)
+	flag.StringVar(&flagDerive, "derive", "", `quantities derived from the coefficients, which are b0, b1 and so on, separated by commas, like "b1 / b0"; each is reported with its confidence interval by the delta method`)
+	flag.StringVar(&flagCV, "cv", "", `cross validation of each group, "loo" for the leave-one-out error relative to the root mean square of the response, which, unlike R^2, grows when a model fits few observations by chance`)
+
+	flag.BoolVar(&flagSig, "sig", false, "mark whether each coefficient is significantly different from zero")
+	flag.StringVar(&flagHighlight, "highlight", "", `mark the significance of each coefficient, "stars" for * (p < 1 - confidence), ** (p 5 times smaller) or *** (p 50 times smaller), which are p < 0.05, 0.01 and 0.001 at the default confidence, or "color" for ANSI colors in text on a terminal and CSS classes in HTML`)
+
+	flag.BoolVar(&flagVIF, "vif", false, "show the variance inflation factor of each term, which is large when the terms are nearly collinear")
+
+	flag.StringVar(&flagFit, "fit", "ols", `fitting method, "ols" for least squares, "robust" for a Huber loss that downweights outliers, or "nls" for the nonlinear -model`)
+	flag.StringVar(&flagModel, "model", "", `nonlinear model for -fit=nls, like "a * math.Pow(N, b)"; the identifiers that are not input variables are its parameters`)
+
+	flag.BoolVar(&flagLogLog, "loglog", false, "fit the logarithm of the response to the logarithms of the xtransform terms, and report the exponent of each term and the constant factor")
+	flag.BoolVar(&flagSemilogY, "semilogy", false, "fit the logarithm of the response to the xtransform terms, and report the factor that each unit of a term multiplies the response by, and the constant factor")
+	flag.BoolVar(&flagBack, "back", false, "report the coefficients and predictions of a ytransform that is a logarithm, like math.Log(Y), on the original scale of the response: each coefficient as the factor that a unit of its term multiplies the response by, and each prediction with its interval")
+	flag.BoolVar(&flagSmear, "smear", false, "with -back, multiply the predictions by Duan's smearing estimate, so that they estimate the mean of the response rather than its median")
+
+	flag.BoolVar(&flagInfer, "infer", false, "report the best fitting complexity class of each group instead of fitting xtransform")
+	flag.StringVar(&flagCands, "candidates", "", `candidate explanatory terms, separated by commas, like "N * N, N * math.Log(N), N, 1.0"; report the subset of them that fits each group best by BIC instead of fitting xtransform (at most 16 terms)`)
+
+	flag.IntVar(&flagBreaks, "breakpoints", 0, "fit each group piecewise, in segments split at up to this many breakpoints in the first term of xtransform, and report the segments (0 disables)")
+	flag.BoolVar(&flagPowerLaw, "powerlaw", false, "report the exponent b and constant c of the power law Y = c * N^b of each group instead of fitting xtransform")
+
+	flag.BoolVar(&flagVarPower, "varpower", false, "model the residual variance as a power of the fitted mean and refit with the implied weights")
+	flag.StringVar(&flagWeights, "weights", "", `weight of each observation for weighted least squares, an expression of the input variables, like "1 / (N * N)" when the variance of the response grows with the square of N`)
+	flag.BoolVar(&flagRepVar, "repvar", false, "weight each observation by the inverse of the variance of its benchmark's replicates, like those of go test -count, and fit by generalized least squares")
+
+	flag.BoolVar(&flagWorst, "worst", false, "name the observation with the largest standardized residual in each group")
+	flag.BoolVar(&flagSummary, "summary", false, `describe the fit of each group in a sentence after the report, relative to the first group or -baseline, like "StableSort is ~3.95× Sort per N·log(N); the intercept is not significant"`)
+	flag.BoolVar(&flagShowEq, "show-equation", false, `write the fit of each group as an equation after the report, like "BenchmarkSort: Y ≈ 22.5·N·log(N) − 2.0e6 ns"`)
+	flag.StringVar(&flagMisspec, "misspec", "", "input variable, like N, to order the residuals of each group by, to report their Durbin-Watson statistic and the p-value of a runs test of their signs, and to note the groups with too few runs, as when the transform is wrong")
+	flag.BoolVar(&flagOutliers, "outliers", false, "list the observations that unduly influence each fit, by studentized residual and Cook's distance")
+
+	flag.StringVar(&flagArrow, "arrow", "", "directory to write the samples and fits to as Arrow IPC files, if benchls is built with -tags arrow")
+
+	flag.StringVar(&flagHeatmap, "heatmap", "", "file to write an HTML heatmap of the fitted surface and residuals of two variable groups to")
+
+	flag.StringVar(&flagResiduals, "residuals", "", `file to write the fitted value and residuals of every observation to ("-" for after the report)`)
+
+	flag.StringVar(&flagPlot, "plot", "", "directory to write a plot of the observations and fitted curve of each single variable group to")
+	flag.StringVar(&flagPlotExt, "plotext", "svg", "file extension, and so the format, of the -plot plots: svg, png, pdf, eps, jpg or tif")
+	flag.BoolVar(&flagPlotLog, "plotlog", false, "use log-log axes in plots")
+
+	flag.StringVar(&flagDump, "dump-samples", "", "directory to write one CSV file of samples per group to")
+
+	flag.StringVar(&flagPrometheus, "prometheus", "", "file to write the coefficients, their confidence intervals and the R^2 of each group to as Prometheus gauges, for the node exporter's textfile collector")
+	flag.StringVar(&flagProto, "proto", "", "file to write the fits to as a protocol buffer, a Fits message of the schema in cmd/benchls/benchls.proto, for reading them in other languages")
+	flag.StringVar(&flagDB, "db", "", "SQLite database to append the samples and fitted coefficients to, as a run with the time and -label")
+	flag.StringVar(&flagEmitGo, "emit-go", "", "file to write a Go function per group, like PredictSort(n float64) float64, that predicts its response with the fitted coefficients")
+
+	flag.BoolVar(&flagWatch, "watch", false, "rerun whenever the input files change; they may be globs, like \"results/*.txt\", which are expanded on every run")
+
+	flag.Int64Var(&flagSeed, "seed", 0, "seed for the random number generator used by stochastic methods (default a random seed)")
+
+}
+
+func main() {
+	log.SetPrefix("benchls: ")
+	log.SetFlags(0)
+	flag.Usage = usage
+	// the subcommands have flag sets of their own
+	if len(os.Args) > 1 {
+		if sc, ok := subcommands[os.Args[1]]; ok {
+			sc.run(parseSubcommand(os.Args[1], os.Args[2:]))
+			return
+		}
+	}
+	flag.Parse()
+	cmdFit(flag.Args())
+}
+
+// prepare checks the flags, reads the input files named by args, or the
+// fits of -load-model, and parses the transforms, for a mode to fit and
+// report.  With -watch, it reruns benchls whenever the inputs change instead,
+// and does not return.
+func prepare(args []string) *job {
+	if err := checkMode(); err != nil {
+		log.Fatal(err)
+	}
+	if flagWatch {
+		if len(args) == 0 {
+			log.Fatal("-watch needs input files")
+		}
+		watch(withoutWatch(os.Args[1:], len(args)), args)
+	}
+	switch {
+	case flagCompare && flagLoadModel != "":
+		if len(args) != 1 {
+			log.Fatal("-compare with -load-model needs one input file")
+		}
+	case flagCompare:
+		if len(args) != 2 {
+			log.Fatal("-compare needs two input files")
+		}
+	case flagLoadModel != "":
+		if len(args) != 0 {
+			log.Fatal("-load-model takes no input file unless used with -compare")
+		}
+	case flagSeries:
+		if len(args) == 0 {
+			log.Fatal("-series needs at least one input file")
+		}
+	case len(args) == 0:
+		log.Fatal("no input file")
+	case len(args) > 1:
+		log.Fatal("too many input arguments")
+	}
+	inputNames = args
+
+	points, match, exclude := checkFlags()
+	// -seed=0 is a seed like any other
+	seed := flagSeed
+	seeded := false
+	visit(func(f *flag.Flag) { seeded = seeded || f.Name == "seed" })
+	if !seeded {
+		seed = time.Now().UnixNano()
+	}
+	var man *manifest
+	if flagManifest {
+		man = newManifest(seed)
+	}
+
+	// the loaded fits set the flags that they were made with
+	var loadedSamps map[string]benchls.Sample
+	var loadedFits map[string]*benchls.Fit
+	var err error
+	if flagLoadModel != "" {
+		if loadedSamps, loadedFits, err = loadModels(flagLoadModel); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	// read the benchmarks from the file, unless the fits were loaded instead
+	var benchSet benchls.Set
+	if flagLoadModel == "" {
+		if benchSet, err = readInput(args[0], man); err != nil {
+			log.Fatal(err)
+		}
+	}
+	all := benchSet
+	var afterSet benchls.Set
+	if flagCompare {
+		afterSet, err = readInput(args[len(args)-1], man)
+		if err != nil {
+			log.Fatal(err)
+		}
+		all = make(benchls.Set)
+		for _, set := range []benchls.Set{benchSet, afterSet} {
+			for name, bs := range set {
+				all[name] = append(all[name], bs...)
+			}
+		}
+	}
+	var series []seriesInput
+	if flagSeries {
+		labels, err := seriesLabels(args)
+		if err != nil {
+			log.Fatal(err)
+		}
+		series = append(series, seriesInput{labels[0], benchSet})
+		all = make(benchls.Set)
+		for name, bs := range benchSet {
+			all[name] = append(all[name], bs...)
+		}
+		for i, name := range args[1:] {
+			in := seriesInput{label: labels[i+1]}
+			if in.set, err = readInput(name, man); err != nil {
+				log.Fatal(err)
+			}
+			series = append(series, in)
+			for name, bs := range in.set {
+				all[name] = append(all[name], bs...)
+			}
+		}
+	}
+
+	// check that each Y is a valid name, or the unit of a metric in the input
+	units := benchls.Units(all)
+	responses = strings.Split(flagYVar, ",")
+	for i, y := range responses {
+		responses[i] = strings.TrimSpace(y)
+		found := len(args) == 0 // the response of the loaded fits was checked when they were saved
+		for _, valid := range append(benchls.Responses, units...) {
+			if valid == responses[i] {
+				found = true
+				break
+			}
+		}
+		if !found {
+			log.Fatalf("invalid response: %s, the input has units %q", responses[i], units)
+		}
+	}
+	if len(responses) > 1 {
+		// the report has a section for each response, but the files
+		// would be overwritten by each
+		if m := mode(); m != "" {
+			log.Fatalf("-%s needs a single -response", m)
+		}
+		exclusive("response", "crossover", "dump-samples", "plot", "heatmap", "arrow", "html-report", "emit-go", "prometheus", "proto")
+		if flagResiduals != "-" {
+			exclusive("response", "residuals")
+		}
+	}
+	flagYVar = responses[0]
+
+	// find the named variables in the input
+	var ex benchls.Extractor
+	if flagAutoVars {
+		ex = benchls.NewAutoExtractor(all)
+	} else {
+		ex = benchls.RegexpExtractor{Regexp: regexp.MustCompile(flagInputMatch)}
+	}
+	if flagCateg != "" {
+		if ex, flagXTransform, err = categorical(ex, all, flagXTransform); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if (flagVerbose || flagStrict) && flagLoadModel == "" {
+		inputs := []seriesInput{{label: args[0], set: benchSet}}
+		if flagCompare {
+			inputs = append(inputs, seriesInput{label: args[len(args)-1], set: afterSet})
+		}
+		if flagSeries {
+			inputs = inputs[:0]
+			for i, in := range series {
+				inputs = append(inputs, seriesInput{label: args[i], set: in.set})
+			}
+		}
+		for _, in := range inputs {
+			sum := summarizeSkips(in.set, ex, match, exclude)
+			if flagVerbose {
+				sum.log(in.label)
+			}
+			if flagStrict && sum.sampled == 0 {
+				log.Fatalf("%s: none of the benchmark results are sampled, check -vars, -match, -exclude and -response", in.label)
+			}
+		}
+	}
+	if match != nil || exclude != nil {
+		benchSet = filterGroups(benchSet, ex, match, exclude)
+		afterSet = filterGroups(afterSet, ex, match, exclude)
+		for i := range series {
+			series[i].set = filterGroups(series[i].set, ex, match, exclude)
+		}
+	}
+	// the same group in different packages is a different group
+	if flagGroupBy == "" && flagGroup == "" {
+		collide := pkgsCollide(benchSet, ex) || pkgsCollide(afterSet, ex)
+		for _, in := range series {
+			collide = collide || pkgsCollide(in.set, ex)
+		}
+		if collide {
+			flagGroupBy = "pkg"
+		}
+	}
+	varNames := ex.VarNames()
+	if _, exists := varNames["Y"]; exists {
+		log.Fatal("`Y` is reserved and cannot be used as a named expression in vars.")
+	}
+	// the GOMAXPROCS suffix is available as P, unless it is captured in vars
+	varNames["P"] = struct{}{}
+	// as are the bytes processed, from b.SetBytes
+	for _, name := range []string{"BytesPerOp", "TotalBytes"} {
+		if _, exists := varNames[name]; exists {
+			log.Fatalf("`%s` is reserved and cannot be used as a named expression in vars.", name)
+		}
+		varNames[name] = struct{}{}
+	}
+	if _, ok := varNames[flagMisspec]; flagMisspec != "" && !ok {
+		log.Fatalf("-misspec %s is not an input variable", flagMisspec)
+	}
+	// the loaded fits may have no input to find the variables in
+	for _, s := range loadedSamps {
+		for name := range s.Min {
+			varNames[name] = struct{}{}
+		}
+	}
+	if flagConst != "" {
+		if consts, err = parseConsts(flagConst); err != nil {
+			log.Fatal(err)
+		}
+		for name := range consts {
+			if _, exists := varNames[name]; exists || name == "Y" {
+				log.Fatalf("constant `%s` has the name of a variable", name)
+			}
+		}
+	}
+	exclusive("interactions", "xtransform", "xt")
+	if flagInteract > 0 {
+		flagXTransform = interactions(ex)
+	}
+	if flagLogLog || flagSemilogY {
+		if flagXTransform, flagYTransform, err = logTransforms(flagXTransform, flagYTransform, varNames); err != nil {
+			log.Fatal(err)
+		}
+	}
+	// construct the functions for explanatory and response
+	xExprs, err := benchls.NewExpressions(flagXTransform, varNames, consts)
+	if err != nil {
+		log.Fatal(err)
+	}
+	// the groups of each -xt-for override are fit with its own terms
+	parts, err := flagXTFor.split(benchSet, ex, xExprs, varNames)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// the report headings are the terms, or the parameters of a nonlinear model
+	terms := xExprs
+	if flagFit == "nls" {
+		if nonlinear, err = benchls.NewNonlinear(flagModel, varNames, consts); err != nil {
+			log.Fatal(err)
+		}
+		if len(nonlinear.Params) == 0 {
+			log.Fatalf("-model %q has no parameters", flagModel)
+		}
+		params := make(map[string]struct{})
+		for _, p := range nonlinear.Params {
+			params[p] = struct{}{}
+		}
+		if terms, err = benchls.NewExpressions(strings.Join(nonlinear.Params, ", "), params, consts); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if flagDerive != "" {
+		if derived, err = benchls.NewExpressions(flagDerive, benchls.CoefNames(len(terms)), consts); err != nil {
+			log.Fatal("invalid -derive: ", err)
+		}
+	}
+
+	if flagWeights != "" {
+		// the weights are of the inputs, not the response
+		if weights, err = benchls.NewExpression(flagWeights, varNames, consts); err != nil {
+			log.Fatal("invalid -weights: ", err)
+		}
+	}
+
+	varNames["Y"] = struct{}{}
+	yExpr, err := benchls.NewExpression(flagYTransform, varNames, consts)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if flagBack {
+		if err := backTransforms(xExprs, yExpr); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	return &job{
+		benchSet:    benchSet,
+		afterSet:    afterSet,
+		inputs:      series,
+		ex:          ex,
+		varNames:    varNames,
+		xExprs:      xExprs,
+		terms:       terms,
+		yExpr:       yExpr,
+		parts:       parts,
+		points:      points,
+		loadedSamps: loadedSamps,
+		loadedFits:  loadedFits,
+		man:         man,
+		seed:        seed,
+	}
+}
+
+// categorical returns the extractor of the -categorical variables of all,
+// and the terms xt with their indicators, unless xt already has some.
+func categorical(ex benchls.Extractor, all benchls.Set, xt string) (benchls.Extractor, string, error) {
+	names := strings.Split(flagCateg, ",")
+	for i := range names {
+		names[i] = strings.TrimSpace(names[i])
+	}
+	cat := benchls.NewCategorical(ex, all, names)
+	var indicators []string
+	used := false
+	for _, name := range names {
+		levels := cat.Levels(name)
+		if len(levels) == 0 {
+			return nil, "", fmt.Errorf("-categorical %s is not in any benchmark name, like %s=value", name, name)
+		}
+		for i, l := range levels {
+			used = used || strings.Contains(xt, benchls.Indicator(name, l))
+			if i > 0 {
+				indicators = append(indicators, benchls.Indicator(name, l))
+			}
+		}
+	}
+	if !used {
+		xt += ", " + strings.Join(indicators, ", ")
+	}
+	return cat, xt, nil
+}
+
+// interactions returns the terms of -interactions of the input variables of
+// ex.
+func interactions(ex benchls.Extractor) string {
+	var inputs []string
+	for name := range ex.VarNames() {
+		if name != "" {
+			inputs = append(inputs, name)
+		}
+	}
+	return benchls.Interactions(inputs, flagInteract)
+}
+
+// checkFlags checks the flags that do not depend on the input, and sets up
+// the fit options and terms that they describe.  It returns the points of
+// -predict and the -match and -exclude patterns.
+func checkFlags() (points []map[string]float64, match, exclude *regexp.Regexp) {
+	exclusive("html", "json")
+	if _, ok := separators[flagFormat]; !ok && flagFormat != "text" {
+		log.Fatal("invalid format: ", flagFormat)
+	}
+	exclusive("format", "html", "json")
+	// the residuals cannot be printed after the JSON, only to a file
+	exclusive("json", "residuals=-")
+	if flagSort != "name" && flagSort != "r2" && flagSort != "coef" {
+		log.Fatal("invalid sort: ", flagSort)
+	}
+	if flagHighlight != "" && flagHighlight != "stars" && flagHighlight != "color" {
+		log.Fatal("invalid highlight: ", flagHighlight)
+	}
+	if flagNumFmt != "" && flagNumFmt != "eng" && flagNumFmt != "si" {
+		// a single verb that formats a float64
+		if s := fmt.Sprintf(flagNumFmt, 1.0); !strings.Contains(flagNumFmt, "%") || strings.Contains(s, "%!") {
+			log.Fatal("invalid number format: ", flagNumFmt)
+		}
+	}
+	if flagStats != "ci" && flagStats != "full" {
+		log.Fatal("invalid stats: ", flagStats)
+	}
+	if !(flagConfidence > 0 && flagConfidence < 1) {
+		log.Fatal("invalid confidence level: ", flagConfidence)
+	}
+	fitOpts.Confidence = flagConfidence
+	if flagSE != "ols" && flagSE != "hc1" {
+		log.Fatal("invalid standard errors: ", flagSE)
+	}
+	fitOpts.HC1 = flagSE == "hc1"
+	if flagSolver != "lapack" && flagSolver != "native" {
+		log.Fatal("invalid solver: ", flagSolver)
+	}
+	fitOpts.Native = flagSolver == "native"
+	if flagNoise < 0 {
+		log.Fatal("-noise cannot be negative")
+	}
+	if flagCV != "" && flagCV != "loo" {
+		log.Fatal("invalid cross validation: ", flagCV)
+	}
+	// the groups would have different coefficients
+	exclusive("derive", "fit", "xt-for")
+	// the groups are compared by the same leading term
+	exclusive("summary", "xt-for")
+	exclusive("cv", "fit")
+	if flagGroup != "" && !strings.Contains(flagGroup, "{name}") {
+		log.Fatal("-group needs {name}, or the groups would be merged")
+	}
+	exclusive("group", "group-by")
+	if _, err := columns(); err != nil {
+		log.Fatal(err)
+	}
+	for _, name := range gofs() {
+		if _, ok := gofHeadings[name]; !ok {
+			log.Fatal("invalid goodness of fit measure: ", name)
+		}
+	}
+	if flagPredict != "" {
+		var err error
+		if points, err = parsePredict(flagPredict); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if flagWiden && flagPredict == "" {
+		log.Fatal("-widen needs -predict")
+	}
+	if flagCrossover != "" {
+		var err error
+		if crossoverGroups, err = parseCrossover(flagCrossover); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if flagLabel != "" && !flagSeries && flagDB == "" {
+		log.Fatal("-label needs -series or -db")
+	}
+	// the gauges are of the fits of a single model
+	exclusive("prometheus", "xt-for")
+	// like -prometheus, the message is of the fits of a single model
+	exclusive("proto", "xt-for")
+	if flagCheckFile != "" {
+		if err := flagChecks.readFile(flagCheckFile); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if flagBreaks < 0 {
+		log.Fatal("-breakpoints cannot be negative")
+	}
+	if _, ok := inputFormats[flagInFormat]; !ok && flagInFormat != "go" {
+		log.Fatal("invalid input format: ", flagInFormat)
+	}
+	if flagInFormat == "csv" {
+		if flagXCols == "" || flagYCol == "" {
+			log.Fatal("-input-format=csv needs -x-cols and -y-col")
+		}
+		// the variables are in key=value names, and the response is the
+		// -y-col, unless they are set
+		set := make(map[string]bool)
+		visit(func(f *flag.Flag) { set[f.Name] = true })
+		if !set["vars"] {
+			flagAutoVars = true
+		}
+		if !set["response"] {
+			flagYVar = flagYCol
+		}
+	} else if flagXCols != "" || flagYCol != "" {
+		log.Fatal("-x-cols and -y-col need -input-format=csv")
+	}
+	validAgg := false
+	for _, how := range benchls.Aggregations {
+		validAgg = validAgg || how == flagAgg
+	}
+	if !validAgg {
+		log.Fatalf("invalid -agg %q, must be one of %q", flagAgg, benchls.Aggregations)
+	}
+	if flagFit != "ols" && flagFit != "robust" && flagFit != "nls" {
+		log.Fatal("invalid fit: ", flagFit)
+	}
+	if (flagFit == "nls") != (flagModel != "") {
+		log.Fatal("-fit=nls and -model must be used together")
+	}
+	validExt := false
+	for _, ext := range plotExts {
+		validExt = validExt || ext == flagPlotExt
+	}
+	if !validExt {
+		log.Fatalf("invalid -plotext %q, must be one of %q", flagPlotExt, plotExts)
+	}
+	if flagArrow != "" && !haveArrow {
+		log.Fatal("-arrow needs benchls built with -tags arrow")
+	}
+	// these need a model that is linear in its coefficients
+	exclusive("fit=nls", "stability", "worst", "misspec", "show-equation", "summary", "outliers", "vif", "predict", "plot", "heatmap", "residuals", "arrow", "html-report", "crossover", "emit-go", "units", "per-element")
+	exclusive("loglog", "semilogy")
+	if flagSmear && !flagBack {
+		log.Fatal("-smear needs -back")
+	}
+	// the coefficients are reported as factors of the terms as written
+	exclusive("back", "loglog", "semilogy", "fit=nls", "xt-for", "per-element", "summary")
+	exclusive("formula", "xtransform", "xt", "ytransform", "yt", "interactions", "pool", "loglog", "semilogy")
+	if flagFormula != "" {
+		var err error
+		if flagXTransform, flagYTransform, err = benchls.Formula(flagFormula); err != nil {
+			log.Fatal("invalid -formula: ", err)
+		}
+	}
+	// the groups are fit once, together
+	exclusive("pool", "xtransform", "xt", "fit", "varpower", "repvar", "categorical", "interactions", "xt-for", "stability", "cv", "derive", "loglog", "load-model")
+	if flagPool != "" {
+		var err error
+		if flagXTransform, poolPerGroup, err = benchls.PoolTerms(flagPool); err != nil {
+			log.Fatal("invalid -pool: ", err)
+		}
+	}
+	// the indicator terms are 0 for all but one value
+	exclusive("categorical", "interactions", "loglog", "fit=nls")
+	// the terms are rewritten and reported as they were written
+	for _, name := range []string{"loglog", "semilogy"} {
+		exclusive(name, "fit=nls", "xt-for", "save-model", "load-model", "per-element", "summary")
+	}
+	exclusive("varpower", "fit")
+	// the replicates are needed to estimate their variance
+	exclusive("repvar", "fit", "varpower", "agg", "response-stat")
+	// the weights are given rather than estimated
+	exclusive("weights", "fit", "varpower", "repvar", "pool")
+	// the saved fits are of a single response, by a model linear in its terms
+	for _, name := range []string{"save-model", "load-model"} {
+		exclusive(name, "fit=nls")
+	}
+	exclusive("load-model", "stability", "interactions")
+	// each model is reported in a table of its own
+	exclusive("xt-for", "json", "fit=nls", "interactions", "baseline", "crossover", "save-model", "load-model", "heatmap", "html-report", "emit-go")
+	if flagResiduals != "-" {
+		exclusive("xt-for", "residuals")
+	}
+	if flagMatch != "" {
+		var err error
+		if match, err = regexp.Compile(flagMatch); err != nil {
+			log.Fatal("invalid -match: ", err)
+		}
+	}
+	if flagExclude != "" {
+		var err error
+		if exclude, err = regexp.Compile(flagExclude); err != nil {
+			log.Fatal("invalid -exclude: ", err)
+		}
+	}
+	return points, match, exclude
+}
+
+// usedFlags returns the flags that were set on the command line to other
+// than their defaults, by name, and by name=value, like "fit=nls".
+func usedFlags() map[string]bool {
+	used := make(map[string]bool)
+	visit(func(f *flag.Flag) {
+		if v := f.Value.String(); v != f.DefValue {
+			used[f.Name] = true
+			used[f.Name+"="+v] = true
+		}
+	})
+	return used
+}
+
+// exclusive exits if the flag name and any of others are used, naming the
+// first of others that is.  Each may be a name=value, like "fit=nls", to
+// be used only with that value.
+func exclusive(name string, others ...string) {
+	used := usedFlags()
+	if !used[name] {
+		return
+	}
+	for _, other := range others {
+		if used[other] {
+			log.Fatalf("-%s cannot be used with -%s", other, name)
+		}
+	}
+}
+
+// fitAndReport samples and fits the groups for the -response in flagYVar,
+// writes the requested outputs, and returns the fits.  With more than one
+// response, the report heading names the response.
+func fitAndReport(benchSet benchls.Set, ex benchls.Extractor, xExprs, terms []benchls.Expression, yExpr benchls.Expression, points []map[string]float64, man *manifest, seed int64) map[string]*benchls.Fit {
+	// collect the samples
+	samps := sampleGroups(benchSet, ex, xExprs, yExpr)
+	if len(samps) == 0 {
+		log.Print("no benchmarks have input variables that match -vars")
+	}
+
+	if flagNoise > 0 && !flagJSON {
+		if writeNoise(os.Stdout, sampleReplicates(benchSet, ex, xExprs, yExpr)) {
+			fmt.Println()
+		} else {
+			log.Print("-noise: no benchmark has replicates, like those of go test -count")
+		}
+	}
+
+	// estimate the parameters
+	fits := make(map[string]*benchls.Fit)
+	stabilities := make(map[string]float64)
+	powers := make(map[string]float64)
+	rng := rand.New(rand.NewSource(seed))
+
+	if poolPerGroup != nil {
+		pooled, err := benchls.Pooled(samps, poolPerGroup, fitOpts)
+		if err == benchls.ErrSingularFit {
+			log.Fatal("cannot fit the pooled model, some of its terms are linear combinations of the others")
+		} else if err != nil {
+			log.Fatal("cannot fit the pooled model, it ", err)
+		}
+		writeOutputs(xExprs, terms, yExpr, samps, pooled, stabilities, powers, points, man, seed)
+		return pooled
+	}
+
+	// visit the groups in a fixed order so that seeded results are reproducible
+	groups := make([]string, 0, len(samps))
+	for g := range samps {
+		groups = append(groups, g)
+	}
+	sort.Strings(groups)
+	for _, g := range groups {
+		// the report explains the groups that cannot be fit
+		var fitted benchls.Sample
+		if benchls.Underdetermined(samps[g], len(terms)) != nil {
+			fits[g] = nil
+			continue
+		}
+		fits[g], fitted, powers[g] = fitSample(samps[g])
+		if fits[g] != nil && flagStability > 0 {
+			stabilities[g] = benchls.Stability(fitted, flagStability, rng, fitOpts)
+		}
+	}
+	writeOutputs(xExprs, terms, yExpr, samps, fits, stabilities, powers, points, man, seed)
+	return fits
+}
+
+// writeOutputs writes the report of the fits, and the other requested
+// outputs.
+func writeOutputs(xExprs, terms []benchls.Expression, yExpr benchls.Expression, samps map[string]benchls.Sample, fits map[string]*benchls.Fit, stabilities, powers map[string]float64, points []map[string]float64, man *manifest, seed int64) {
+	if flagSaveModel != "" {
+		if err := saveModels(flagSaveModel, samps, fits); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if flagDump != "" {
+		if err := dumpSamples(flagDump, xExprs, yExpr, samps); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if flagHeatmap != "" {
+		if err := writeHeatmap(flagHeatmap, yExpr, samps, fits); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if flagPlot != "" {
+		if err := writePlots(flagPlot, flagPlotExt, xExprs, yExpr, samps, fits, flagPlotLog); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if flagArrow != "" {
+		if err := writeArrow(flagArrow, xExprs, yExpr, samps, fits); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if flagEmitGo != "" {
+		if err := writeGo(flagEmitGo, xExprs, yExpr, samps, fits); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if flagPrometheus != "" {
+		if err := writePrometheus(flagPrometheus, terms, samps, fits); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if flagProto != "" {
+		if err := writeProto(flagProto, terms, yExpr, samps, fits); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if flagDB != "" {
+		if err := writeDB(flagDB, terms, yExpr, samps, fits); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if flagHTMLReport != "" {
+		if err := writeHTMLReport(flagHTMLReport, xExprs, terms, yExpr, samps, fits, stabilities, powers, man, seed); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if flagResiduals != "" && flagResiduals != "-" {
+		if err := writeResiduals(flagResiduals, yExpr, samps, fits); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	// generate the report
+	if flagJSON {
+		if err := writeJSON(terms, yExpr, samps, fits, stabilities, powers, points, man, seed, os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+	} else {
+		writeReport(terms, yExpr, samps, fits, stabilities, powers, man, seed, os.Stdout)
+		if len(points) > 0 {
+			fmt.Println()
+			writePredictions(os.Stdout, xExprs, yExpr, samps, fits, points)
+		}
+		if flagPerElement {
+			fmt.Println()
+			writePerElement(os.Stdout, terms, yExpr, fits)
+		}
+		if flagBaseline != "" {
+			fmt.Println()
+			if err := writeRatios(os.Stdout, terms, fits); err != nil {
+				log.Fatal(err)
+			}
+		}
+		if crossoverGroups != nil {
+			rep, err := crossovers(xExprs, samps, fits)
+			if err != nil {
+				log.Fatal(err)
+			}
+			fmt.Println()
+			writeCrossovers(os.Stdout, yExpr, rep)
+		}
+		if flagOutliers {
+			fmt.Println()
+			writeOutliers(os.Stdout, samps, fits)
+		}
+		if flagResiduals == "-" {
+			fmt.Println()
+			if err := writeResiduals(flagResiduals, yExpr, samps, fits); err != nil {
+				log.Fatal(err)
+			}
+		}
+	}
+}
+
+// readInput reads the benchmarks from the named file, the output of benchls
+// run, or a criterion.rs directory, hashing it into man if it is not nil.
+func readInput(name string, man *manifest) (benchls.Set, error) {
+	var r io.Reader
+	if runOutput != nil && name == runName {
+		r = bytes.NewReader(runOutput)
+	} else if fi, err := os.Stat(name); err == nil && fi.IsDir() && flagInFormat == "criterion" {
+		raw, err := benchls.CriterionDir(name)
+		if err != nil {
+			return nil, err
+		}
+		r = bytes.NewReader(raw)
+	} else {
+		f, err := os.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+	var hashed func()
+	if man != nil {
+		r, hashed = man.hashingReader(name, r)
+	}
+	input, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if hashed != nil {
+		hashed()
+	}
+	if input, err = convertInput(input); err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+	return benchls.ReadSet(bytes.NewReader(input))
+}
+
+// inputFormats convert the results of other benchmark harnesses, by their
+// -input-format, to go test -bench output.
+var inputFormats = map[string]func(io.Reader) ([]byte, error){
+	"jmh":       benchls.JMH,
+	"gbench":    benchls.GoogleBenchmark,
+	"criterion": benchls.Criterion,
+	"pytest":    benchls.PytestBenchmark,
+	"csv": func(r io.Reader) ([]byte, error) {
+		return benchls.CSV(r, strings.Split(flagXCols, ","), flagYCol)
+	},
+}
+
+// convertInput converts input from the -input-format to go test -bench
+// output.
+func convertInput(input []byte) ([]byte, error) {
+	if convert, ok := inputFormats[flagInFormat]; ok {
+		return convert(bytes.NewReader(input))
+	}
+	return input, nil
+}
+
+var identifier = regexp.MustCompile(`^[\pL_][\pL\pN_]*$`)
+
+// parseConsts parses named constants, like "B=4096, C=64".
+func parseConsts(spec string) (map[string]float64, error) {
+	consts := make(map[string]float64)
+	for _, assign := range strings.Split(spec, ",") {
+		kv := strings.SplitN(assign, "=", 2)
+		if len(kv) != 2 || !identifier.MatchString(strings.TrimSpace(kv[0])) {
+			return nil, fmt.Errorf("invalid constant %q, want name=value", assign)
+		}
+		name := strings.TrimSpace(kv[0])
+		v, err := strconv.ParseFloat(strings.TrimSpace(kv[1]), 64)
+		if err != nil || math.IsInf(v, 0) || math.IsNaN(v) {
+			return nil, fmt.Errorf("invalid value for constant %s: %q", name, kv[1])
+		}
+		consts[name] = v
+	}
+	return consts, nil
+}
+
+// selected reports whether group matches match and not exclude, either of
+// which may be nil.
+func selected(group string, match, exclude *regexp.Regexp) bool {
+	return (match == nil || match.MatchString(group)) && (exclude == nil || !exclude.MatchString(group))
+}
+
+// filterGroups returns the benchmarks of benchSet whose groups are selected
+// by match and exclude.
+func filterGroups(benchSet benchls.Set, ex benchls.Extractor, match, exclude *regexp.Regexp) benchls.Set {
+	filtered := make(benchls.Set)
+	for name, bs := range benchSet {
+		if group, _, ok := ex.Extract(name); !ok || !selected(group, match, exclude) {
+			continue
+		}
+		filtered[name] = bs
+	}
+	return filtered
+}
+
+// poolPerGroup marks the terms of -pool that have a coefficient for each
+// group, or is nil without -pool.
+var poolPerGroup []bool
+
+// sampleGroups collects the samples of each group, keyed by the -group
+// template or split by the -group-by configuration lines, and combines
+// replicates as set by -agg.
+func sampleGroups(benchSet benchls.Set, ex benchls.Extractor, xExprs []benchls.Expression, yExpr benchls.Expression) map[string]benchls.Sample {
+	return aggregate(sampleReplicates(benchSet, ex, xExprs, yExpr))
+}
+
+// sampleReplicates samples the groups like sampleGroups, but keeps every
+// replicate as its own observation.
+func sampleReplicates(benchSet benchls.Set, ex benchls.Extractor, xExprs []benchls.Expression, yExpr benchls.Expression) map[string]benchls.Sample {
+	if flagGroup != "" {
+		keys, sets := benchls.SplitTemplate(benchSet, flagGroup)
+		samps := make(map[string]benchls.Sample)
+		for _, k := range keys {
+			for g, samp := range benchls.SampleGroup(sets[k], ex, xExprs, yExpr, flagYVar) {
+				samps[strings.Replace(k, "{name}", g, -1)] = samp
+			}
+		}
+		return samps
+	}
+	if flagGroupBy == "" {
+		return benchls.SampleGroup(benchSet, ex, xExprs, yExpr, flagYVar)
+	}
+	keys, sets := benchls.SplitBy(benchSet, strings.Split(flagGroupBy, ","))
+	samps := make(map[string]benchls.Sample)
+	for _, k := range keys {
+		for g, samp := range benchls.SampleGroup(sets[k], ex, xExprs, yExpr, flagYVar) {
+			samps[g+" "+k] = samp
+		}
+	}
+	return samps
+}
+
+// aggregate combines the replicates of each sample as set by -agg.
+func aggregate(samps map[string]benchls.Sample) map[string]benchls.Sample {
+	for g, samp := range samps {
+		samps[g], _ = benchls.Aggregate(samp, flagAgg)
+	}
+	return samps
+}
+
+// pkgsCollide reports whether any group has benchmarks in more than one
+// package.
+func pkgsCollide(benchSet benchls.Set, ex benchls.Extractor) bool {
+	pkgs := make(map[string]string)
+	for name, rs := range benchSet {
+		group, _, ok := ex.Extract(name)
+		if !ok {
+			continue
+		}
+		for _, res := range rs {
+			pkg := res.GetConfig("pkg")
+			if first, ok := pkgs[group]; ok && first != pkg {
+				return true
+			}
+			pkgs[group] = pkg
+		}
+	}
+	return false
+}
+
+// fitSample fits s with the requested method.  It returns the fit, which is
+// nil if it failed, the possibly weighted sample that the goodness of fit
+// describes, and the variance power if -varpower is set.
+func fitSample(s benchls.Sample) (*benchls.Fit, benchls.Sample, float64) {
+	fit, s, power, _ := fitSampleContext(context.Background(), s)
+	return fit, s, power
+}
+
+// fitSampleContext is fitSample, but stops fitting and returns ctx.Err(),
+// and no fit, once ctx is done.
+func fitSampleContext(ctx context.Context, s benchls.Sample) (*benchls.Fit, benchls.Sample, float64, error) {
+	var m benchls.Model
+	var power float64
+	switch {
+	case len(s.Y) == 0:
+		return nil, s, 0, nil
+	case flagFit == "nls":
+		fit, err := nonlinear.FitContext(ctx, s, fitOpts)
+		return fit, s, 0, err
+	case benchls.Underdetermined(s, len(s.X)/len(s.Y)) != nil:
+		return nil, s, 0, nil
+	case notFinite(s) >= 0:
+		return nil, s, 0, nil
+	case flagVarPower:
+		// the error is ctx.Err(), or the fit failed
+		m, s, power, _ = benchls.VarPowerContext(ctx, s, fitOpts)
+	case flagRepVar:
+		s = benchls.Weighted(s, benchls.ReplicateWeights(s))
+		m = benchls.Estimate(s, fitOpts)
+	case flagWeights != "":
+		w, err := benchls.ExprWeights(s, weights)
+		if err != nil {
+			return nil, s, 0, nil
+		}
+		s = benchls.Weighted(s, w)
+		m = benchls.Estimate(s, fitOpts)
+	case flagFit == "robust":
+		m, s, _ = benchls.RobustContext(ctx, s, fitOpts)
+	default:
+		fit, err := benchls.NewFitContext(ctx, s, fitOpts)
+		return fit, s, 0, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, s, power, err
+	}
+	if m == nil {
+		return nil, s, power, nil
+	}
+	return &benchls.Fit{Model: m, Stats: benchls.NewStats(m, s, fitOpts)}, s, power, nil
+}
+
+// stochastic reports whether any of the requested methods use random numbers.
+func stochastic() bool {
+	return flagStability > 0
+}
@@ -0,0 +1,195 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/jonlawlor/benchls"
+)
+
+func TestFitSampleContext(t *testing.T) {
+	_, _, samps, _ := testFits(t)
+	s := samps["BenchmarkFast"]
+	defer func() { flagFit = "ols" }()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for _, fit := range []string{"ols", "robust"} {
+		flagFit = fit
+		if got, _, _, err := fitSampleContext(context.Background(), s); got == nil || err != nil {
+			t.Errorf("%s: expected a fit, got %v, %v", fit, got, err)
+		}
+		if got, _, _, err := fitSampleContext(ctx, s); got != nil || err != context.Canceled {
+			t.Errorf("%s: expected no fit once canceled, got %v, %v", fit, got, err)
+		}
+	}
+	// a fit that fails is not an error
+	flagFit = "ols"
+	if got, _, _, err := fitSampleContext(context.Background(), samps["BenchmarkOne"]); got != nil || err != nil {
+		t.Errorf("expected no fit of BenchmarkOne, and no error, got %v, %v", got, err)
+	}
+}
+
+// seedInput is a go test output with some noise, so that the stability of
+// its fit depends on the subsamples.
+const seedInput = `
+BenchmarkSort10-4   	 1000000	      1130 ns/op
+BenchmarkSort20-4   	 1000000	      1790 ns/op
+BenchmarkSort30-4   	 1000000	      3350 ns/op
+BenchmarkSort40-4   	 1000000	      3720 ns/op
+BenchmarkSort50-4   	 1000000	      5610 ns/op
+BenchmarkSort60-4   	 1000000	      5890 ns/op
+BenchmarkSort70-4   	 1000000	      7480 ns/op
+BenchmarkSort80-4   	 1000000	      7710 ns/op
+`
+
+// captureStdout returns what f writes to os.Stdout.
+func captureStdout(t *testing.T, f func()) string {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+	done := make(chan []byte)
+	go func() {
+		out, _ := ioutil.ReadAll(r)
+		done <- out
+	}()
+	f()
+	w.Close()
+	return string(<-done)
+}
+
+func TestSeed(t *testing.T) {
+	dir, err := ioutil.TempDir("", "benchls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "bench.txt")
+	if err := ioutil.WriteFile(path, []byte(seedInput), 0666); err != nil {
+		t.Fatal(err)
+	}
+	set, err := readInput(path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := map[string]struct{}{"N": {}}
+	xExprs, err := benchls.NewExpressions("N, 1.0", names, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names["Y"] = struct{}{}
+	yExpr, err := benchls.NewExpression("Y", names, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ex := benchls.RegexpExtractor{Regexp: regexp.MustCompile(flagInputMatch)}
+
+	defer parseFlags(t, "-stability=20", "-seed=3")()
+	report := func(man *manifest, seed int64) string {
+		return captureStdout(t, func() { fitAndReport(set, ex, xExprs, xExprs, yExpr, nil, man, seed) })
+	}
+
+	// the same seed makes the same subsamples
+	first := report(nil, 3)
+	if again := report(nil, 3); again != first {
+		t.Errorf("expected the seed to reproduce the report\n%s\ngot\n%s", first, again)
+	}
+	if !strings.HasPrefix(first, "# seed 3\n") || !strings.Contains(first, "stability") {
+		t.Errorf("expected the report to start with the seed, and to have a stability, got\n%s", first)
+	}
+	other := report(nil, 4)
+	if !strings.HasPrefix(other, "# seed 4\n") {
+		t.Errorf("expected the report of another seed to start with it, got\n%s", other)
+	}
+	if strings.TrimPrefix(other, "# seed 4\n") == strings.TrimPrefix(first, "# seed 3\n") {
+		t.Errorf("expected another seed to make other subsamples, got the same report\n%s", other)
+	}
+
+	// the manifest records the seed
+	if got := report(newManifest(3), 3); !strings.Contains(got, "# flag -seed=\"3\"\n") || !strings.Contains(got, "# seed 3\n") {
+		t.Errorf("expected the manifest to record the seed, got\n%s", got)
+	}
+}
+
+// filterInput is a go test output of four groups, and a benchmark without
+// the variables of -vars.
+const filterInput = `
+BenchmarkSortInts10-4     	 1000000	      1000 ns/op
+BenchmarkSortStrings10-4  	 1000000	      2000 ns/op
+BenchmarkSearchInts10-4   	 1000000	        10 ns/op
+BenchmarkSearchStrings10-4	 1000000	        20 ns/op
+BenchmarkNoSize-4         	 1000000	         1 ns/op
+`
+
+// filterCases are -match and -exclude, and the groups that they select from
+// filterInput.
+var filterCases = []struct {
+	match, exclude string
+	want           string
+}{
+	{"", "", "BenchmarkSearchInts BenchmarkSearchStrings BenchmarkSortInts BenchmarkSortStrings"},
+	{"Sort", "", "BenchmarkSortInts BenchmarkSortStrings"},
+	{"", "Strings$", "BenchmarkSearchInts BenchmarkSortInts"},
+	// a group must match, and not be excluded
+	{"Sort", "Strings$", "BenchmarkSortInts"},
+	{"Ints", "Ints", ""},
+	{"Fill", "", ""},
+}
+
+// compileFilter compiles -match or -exclude, or returns nil if it is empty.
+func compileFilter(expr string) *regexp.Regexp {
+	if expr == "" {
+		return nil
+	}
+	return regexp.MustCompile(expr)
+}
+
+func TestSelected(t *testing.T) {
+	for _, c := range filterCases {
+		match, exclude := compileFilter(c.match), compileFilter(c.exclude)
+		var groups []string
+		for _, g := range []string{"BenchmarkSearchInts", "BenchmarkSearchStrings", "BenchmarkSortInts", "BenchmarkSortStrings"} {
+			if selected(g, match, exclude) {
+				groups = append(groups, g)
+			}
+		}
+		if got := strings.Join(groups, " "); got != c.want {
+			t.Errorf("-match=%q -exclude=%q: expected %q, got %q", c.match, c.exclude, c.want, got)
+		}
+	}
+}
+
+func TestFilterGroups(t *testing.T) {
+	set, err := benchls.ReadSet(strings.NewReader(filterInput))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ex := benchls.RegexpExtractor{Regexp: regexp.MustCompile(flagInputMatch)}
+	// the benchmark without the variables of -vars is always left out
+	for _, c := range filterCases {
+		match, exclude := compileFilter(c.match), compileFilter(c.exclude)
+		var groups []string
+		for name := range filterGroups(set, ex, match, exclude) {
+			group, _, _ := ex.Extract(name)
+			groups = append(groups, group)
+		}
+		sort.Strings(groups)
+		if got := strings.Join(groups, " "); got != c.want {
+			t.Errorf("-match=%q -exclude=%q: expected %q, got %q", c.match, c.exclude, c.want, got)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"crypto/sha256"
+	"flag"
+	"fmt"
+	"io"
+)
+
+// version is the benchls version recorded in manifests.  It can be set at
+// build time with -ldflags "-X main.version=...".
+var version = "devel"
+
+// manifest records everything needed to regenerate a report.
+type manifest struct {
+	Version string      `json:"version"`
+	Flags   []string    `json:"flags"`
+	Inputs  []inputHash `json:"inputs"`
+	Seed    int64       `json:"seed"`
+}
+
+// inputHash is the SHA-256 of an input file's contents.
+type inputHash struct {
+	Name   string `json:"name"`
+	SHA256 string `json:"sha256"`
+}
+
+// newManifest records the flags that were explicitly set on the command line.
+func newManifest(seed int64) *manifest {
+	m := &manifest{Version: version, Seed: seed}
+	visit(func(f *flag.Flag) {
+		m.Flags = append(m.Flags, fmt.Sprintf("-%s=%q", f.Name, f.Value.String()))
+	})
+	return m
+}
+
+// hashingReader wraps r so that its contents are hashed as they are read.
+// The returned function records the digest under name once r is consumed.
+func (m *manifest) hashingReader(name string, r io.Reader) (io.Reader, func()) {
+	h := sha256.New()
+	return io.TeeReader(r, h), func() {
+		m.Inputs = append(m.Inputs, inputHash{Name: name, SHA256: fmt.Sprintf("%x", h.Sum(nil))})
+	}
+}
+
+// lines returns the manifest as human readable lines.
+func (m *manifest) lines() []string {
+	ls := []string{"benchls version " + m.Version}
+	for _, f := range m.Flags {
+		ls = append(ls, "flag "+f)
+	}
+	for _, in := range m.Inputs {
+		ls = append(ls, "input "+in.Name+" sha256:"+in.SHA256)
+	}
+	ls = append(ls, fmt.Sprintf("seed %d", m.Seed))
+	return ls
+}
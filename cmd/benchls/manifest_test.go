@@ -0,0 +1,42 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestManifest(t *testing.T) {
+	dir, err := ioutil.TempDir("", "benchls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "bench.txt")
+	if err := ioutil.WriteFile(path, []byte("BenchmarkSort10-4 \t 1000000\t      1008 ns/op\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	defer parseFlags(t, "-seed=7", "-json", "-match=Sort")()
+	man := newManifest(7)
+	if _, err := readInput(path, man); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"benchls version devel",
+		`flag -json="true"`,
+		`flag -match="Sort"`,
+		`flag -seed="7"`,
+		"input " + path + " sha256:53a8b3cb58ce24c0e10ae4d77d598a38db6dcd9b5b03384ebe27b0ed2b1f4c6c",
+		"seed 7",
+	}
+	if got := man.lines(); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("expected the manifest\n%s\ngot\n%s", strings.Join(want, "\n"), strings.Join(got, "\n"))
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/jonlawlor/benchls"
+)
+
+// leadingCoefs returns the leading coefficient of each group fit on each of
+// the configurations keys of sets.  The groups that cannot be fit on a
+// configuration have no coefficient for it.
+func leadingCoefs(keys []string, sets map[string]benchls.Set, ex benchls.Extractor, xExprs []benchls.Expression, yExpr benchls.Expression) map[string]map[string]float64 {
+	leads := make(map[string]map[string]float64)
+	for _, k := range keys {
+		for g, samp := range aggregate(benchls.SampleGroup(sets[k], ex, xExprs, yExpr, flagYVar)) {
+			m := benchls.Estimate(samp, fitOpts)
+			if m == nil {
+				continue
+			}
+			if leads[g] == nil {
+				leads[g] = make(map[string]float64)
+			}
+			leads[g][k] = m[0]
+		}
+	}
+	return leads
+}
+
+// writeMatrix writes the leading coefficient of each group (rows) fit on each
+// configuration (columns).  If ref is not empty, each cell also shows the
+// relative difference from the ref configuration.
+func writeMatrix(xExprs []benchls.Expression, keys []string, leads map[string]map[string]float64, ref string, man *manifest, seed int64, w io.Writer) {
+	groups := make([]string, 0, len(leads))
+	for g := range leads {
+		groups = append(groups, g)
+	}
+	sort.Strings(groups)
+
+	table := []*row{newRow("group \\ " + xExprs[0].String())}
+	for _, k := range keys {
+		table[0].add(k)
+	}
+	for _, g := range groups {
+		r := newRow(g)
+		for _, k := range keys {
+			b, ok := leads[g][k]
+			if !ok {
+				r.add("~")
+				continue
+			}
+			cell := fmt.Sprintf("%.4g", b)
+			if rb, ok := leads[g][ref]; ok && k != ref {
+				cell += fmt.Sprintf(" (%+.1f%%)", 100*(b-rb)/rb)
+			}
+			r.add(cell)
+		}
+		table = append(table, r)
+	}
+
+	var buf bytes.Buffer
+	writeHeader(&buf, man, seed)
+	writeTable(&buf, table)
+	w.Write(buf.Bytes())
+}
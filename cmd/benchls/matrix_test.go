@@ -0,0 +1,81 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"math"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/jonlawlor/benchls"
+)
+
+func TestMatrix(t *testing.T) {
+	s := `
+goos: linux
+goarch: amd64
+BenchmarkSort10-4   	 1000000	      1000 ns/op
+BenchmarkSort100-4  	  100000	     10000 ns/op
+BenchmarkSort1000-4 	   10000	    100000 ns/op
+BenchmarkOnly10-4   	 1000000	      1000 ns/op
+goarch: arm64
+BenchmarkSort10-4   	 1000000	      1500 ns/op
+BenchmarkSort100-4  	  100000	     15000 ns/op
+BenchmarkSort1000-4 	   10000	    150000 ns/op
+`
+	benchSet, err := benchls.ReadSet(strings.NewReader(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys, sets := benchls.SplitConfigs(benchSet)
+	if strings.Join(keys, ",") != "linux/amd64,linux/arm64" {
+		t.Fatalf("expected the configurations linux/amd64 and linux/arm64, got %q", keys)
+	}
+	ex := benchls.RegexpExtractor{Regexp: regexp.MustCompile(`(?P<N>\d+)-\d+$`)}
+	xExprs, err := benchls.NewExpressions("N", ex.VarNames(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	yExpr, err := benchls.NewExpression("Y", map[string]struct{}{"Y": {}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	leads := leadingCoefs(keys, sets, ex, xExprs, yExpr)
+	for k, want := range map[string]float64{"linux/amd64": 100, "linux/arm64": 150} {
+		if got, ok := leads["BenchmarkSort"][k]; !ok || math.Abs(got-want) > 1e-9 {
+			t.Errorf("%s: expected the leading coefficient %g, got %g, %v", k, want, got, ok)
+		}
+	}
+	// BenchmarkOnly was only run on amd64
+	if _, ok := leads["BenchmarkOnly"]["linux/arm64"]; ok || len(leads["BenchmarkOnly"]) != 1 {
+		t.Errorf("expected BenchmarkOnly only on linux/amd64, got %v", leads["BenchmarkOnly"])
+	}
+
+	flagFormat = "csv"
+	defer func() { flagFormat = "text" }()
+	var buf bytes.Buffer
+	writeMatrix(xExprs, keys, leads, "linux/amd64", nil, 0, &buf)
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{
+		{"group \\ N", "linux/amd64", "linux/arm64"},
+		{"BenchmarkOnly", "100", "~"},
+		{"BenchmarkSort", "100", "150 (+50.0%)"},
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("expected %q, got %q", want, rows)
+	}
+	for i := range want {
+		if strings.Join(rows[i], "|") != strings.Join(want[i], "|") {
+			t.Errorf("row %d: expected %q, got %q", i, want[i], rows[i])
+		}
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/jonlawlor/benchls"
+)
+
+// binaryPrefixes are the units of memory, in powers of 1024.
+var binaryPrefixes = []string{"B", "KiB", "MiB", "GiB", "TiB"}
+
+// memoryFormat reports whether the response is a number of bytes, like
+// AllocedBytesPerOp, which is formatted with binary prefixes unless -numfmt
+// is set.
+func memoryFormat() bool {
+	return flagNumFmt == "" && flagYTransform == "Y" && responseUnit(flagYVar) == "B"
+}
+
+// memoryScale returns the power of 1024 to show a number of bytes of the size
+// of f in, and its unit.
+func memoryScale(f float64) (float64, string) {
+	k := 0
+	for k < len(binaryPrefixes)-1 && math.Abs(f) >= math.Pow(1024, float64(k+1)) {
+		k++
+	}
+	return math.Pow(1024, float64(k)), binaryPrefixes[k]
+}
+
+// significant formats f in fixed point with the given number of significant
+// digits.
+func significant(f float64, digits int) string {
+	if f == 0 || math.IsNaN(f) || math.IsInf(f, 0) {
+		return strconv.FormatFloat(f, 'g', -1, 64)
+	}
+	d := digits - 1 - int(math.Floor(math.Log10(math.Abs(f))))
+	if d < 0 {
+		d = 0
+	}
+	return strconv.FormatFloat(f, 'f', d, 64)
+}
+
+// byteSize formats f bytes with the given number of significant digits, like
+// "1.1 KiB".
+func byteSize(f float64, digits int) string {
+	scale, unit := memoryScale(f)
+	return significant(f/scale, digits) + " " + unit
+}
+
+// memoryCoefficient formats b bytes and its confidence interval in the same
+// unit, like "1.10±0.20 KiB".
+func memoryCoefficient(b, cint float64, digits int) string {
+	scale, unit := memoryScale(math.Max(math.Abs(b), cint))
+	return significant(b/scale, digits) + "±" + significant(cint/scale, 2) + " " + unit
+}
+
+// writePerElement writes each fit as the response per unit of each term and
+// the fixed response, like "24 B per N + 1.1 KiB fixed".
+func writePerElement(w io.Writer, terms []benchls.Expression, yExpr benchls.Expression, fits map[string]*benchls.Fit) {
+	unit := ""
+	if yExpr.String() == "Y" {
+		unit = " " + responseUnit(flagYVar)
+	}
+	table := []*row{newRow("group", yExpr.String()+" ≈")}
+	for _, g := range sortedGroups(fits, flagSort) {
+		fit := fits[g]
+		if fit == nil {
+			table = append(table, newRow(g, "~"))
+			continue
+		}
+		var sum []string
+		for i, b := range fit.Model {
+			op := "+ "
+			if b < 0 {
+				op = "- "
+			}
+			if i == 0 {
+				op = strings.TrimPrefix(op, "+ ")
+				op = strings.Replace(op, "- ", "-", 1)
+			}
+			v := fmt.Sprintf("%.3g", math.Abs(b)) + unit
+			if memoryFormat() {
+				v = byteSize(math.Abs(b), 3)
+			}
+			if constantTerm(terms[i]) {
+				sum = append(sum, op+v+" fixed")
+			} else {
+				sum = append(sum, op+v+" per "+terms[i].String())
+			}
+		}
+		table = append(table, newRow(g, strings.Join(sum, " ")))
+	}
+
+	var buf bytes.Buffer
+	writeTable(&buf, table)
+	w.Write(buf.Bytes())
+}
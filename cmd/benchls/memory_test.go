@@ -0,0 +1,74 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"reflect"
+	"testing"
+
+	"github.com/jonlawlor/benchls"
+)
+
+func TestWritePerElement(t *testing.T) {
+	names := map[string]struct{}{"N": {}}
+	terms, err := benchls.NewExpressions("N, 1.0", names, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names["Y"] = struct{}{}
+	fits := map[string]*benchls.Fit{
+		"BenchmarkAppend": {Model: benchls.Model{24, 1126.4}},
+		"BenchmarkCopy":   {Model: benchls.Model{3, -2}},
+		"BenchmarkFree":   {Model: benchls.Model{-0.5, 2}},
+		"BenchmarkOne":    nil,
+	}
+	flagFormat = "csv"
+	defer func() { flagFormat, flagYVar = "text", "NsPerOp" }()
+	for _, c := range []struct {
+		yVar, yt string
+		want     [][]string
+	}{
+		{"NsPerOp", "Y", [][]string{
+			{"group", "Y ≈"},
+			{"BenchmarkAppend", "24 ns per N + 1.13e+03 ns fixed"},
+			{"BenchmarkCopy", "3 ns per N - 2 ns fixed"},
+			{"BenchmarkFree", "-0.5 ns per N + 2 ns fixed"},
+			{"BenchmarkOne", "~"},
+		}},
+		// numbers of bytes have binary prefixes
+		{"AllocedBytesPerOp", "Y", [][]string{
+			{"group", "Y ≈"},
+			{"BenchmarkAppend", "24.0 B per N + 1.10 KiB fixed"},
+			{"BenchmarkCopy", "3.00 B per N - 2.00 B fixed"},
+			{"BenchmarkFree", "-0.500 B per N + 2.00 B fixed"},
+			{"BenchmarkOne", "~"},
+		}},
+		// the unit of a transformed response is unknown
+		{"NsPerOp", "math.Log(Y)", [][]string{
+			{"group", "math.Log(Y) ≈"},
+			{"BenchmarkAppend", "24 per N + 1.13e+03 fixed"},
+			{"BenchmarkCopy", "3 per N - 2 fixed"},
+			{"BenchmarkFree", "-0.5 per N + 2 fixed"},
+			{"BenchmarkOne", "~"},
+		}},
+	} {
+		yExpr, err := benchls.NewExpression(c.yt, names, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		flagYVar = c.yVar
+		var buf bytes.Buffer
+		writePerElement(&buf, terms, yExpr, fits)
+		got, err := csv.NewReader(&buf).ReadAll()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s of %s: expected\n%q\ngot\n%q", c.yt, c.yVar, c.want, got)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/jonlawlor/benchls"
+)
+
+// modelSettings are the flags that determine the groups, samples and terms of
+// the fits, which saved fits have to be loaded with.
+var modelSettings = []string{"vars", "auto-vars", "group-by", "const", "xtransform", "ytransform", "response"}
+
+// savedModels are the fits written by -save-model and read by -load-model.
+// The samples are saved with them, so that their statistics and predictions
+// can be recomputed.
+type savedModels struct {
+	Settings map[string]string     `json:"settings"`
+	Groups   map[string]savedGroup `json:"groups"`
+}
+
+// savedGroup is the fit of one group, and the sample it describes.
+type savedGroup struct {
+	Model []float64          `json:"model"`
+	X     []float64          `json:"x"`
+	Y     []float64          `json:"y"`
+	Names []string           `json:"names"`
+	Min   map[string]float64 `json:"min"`
+	Max   map[string]float64 `json:"max"`
+}
+
+// saveModels writes the groups that could be fit to path.
+func saveModels(path string, samps map[string]benchls.Sample, fits map[string]*benchls.Fit) error {
+	saved := savedModels{Settings: make(map[string]string), Groups: make(map[string]savedGroup)}
+	for _, name := range modelSettings {
+		saved.Settings[name] = flag.Lookup(name).Value.String()
+	}
+	for g, fit := range fits {
+		if fit == nil {
+			continue
+		}
+		s := samps[g]
+		saved.Groups[g] = savedGroup{Model: fit.Model, X: s.X, Y: s.Y, Names: s.Names, Min: s.Min, Max: s.Max}
+	}
+	b, err := json.MarshalIndent(saved, "", "\t")
+	if err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// loadModels reads the fits saved in path, and sets the flags they were fit
+// with.  It is an error to have set any of those flags differently.
+func loadModels(path string) (map[string]benchls.Sample, map[string]*benchls.Fit, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+	var saved savedModels
+	if err := json.NewDecoder(f).Decode(&saved); err != nil {
+		return nil, nil, fmt.Errorf("%s: %v", path, err)
+	}
+
+	set := make(map[string]bool)
+	visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "xt":
+			set["xtransform"] = true
+		case "yt":
+			set["ytransform"] = true
+		default:
+			set[f.Name] = true
+		}
+	})
+	for _, name := range modelSettings {
+		v, ok := saved.Settings[name]
+		if !ok {
+			return nil, nil, fmt.Errorf("%s: missing the setting of -%s", path, name)
+		}
+		fl := flag.Lookup(name)
+		if set[name] && fl.Value.String() != v {
+			return nil, nil, fmt.Errorf("-%s is %q, but the models in %s were fit with %q", name, fl.Value.String(), path, v)
+		}
+		if err := fl.Value.Set(v); err != nil {
+			return nil, nil, fmt.Errorf("%s: invalid -%s: %v", path, name, err)
+		}
+	}
+
+	samps := make(map[string]benchls.Sample)
+	fits := make(map[string]*benchls.Fit)
+	for g, sg := range saved.Groups {
+		s := benchls.Sample{X: sg.X, Y: sg.Y, Names: sg.Names, Min: sg.Min, Max: sg.Max}
+		if len(s.Y) == 0 || len(s.X) != len(s.Y)*len(sg.Model) {
+			return nil, nil, fmt.Errorf("%s: group %s has %d terms but %d values for %d observations", path, g, len(sg.Model), len(s.X), len(s.Y))
+		}
+		samps[g] = s
+		fits[g] = &benchls.Fit{Model: sg.Model, Stats: benchls.NewStats(sg.Model, s, fitOpts)}
+	}
+	return samps, fits, nil
+}
@@ -0,0 +1,69 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSaveLoadModels(t *testing.T) {
+	_, _, samps, fits := testFits(t)
+	dir, err := ioutil.TempDir("", "benchls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "models.json")
+	if err := saveModels(path, samps, fits); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, loadedFits, err := loadModels(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the group that could not be fit is not saved
+	if len(loadedFits) != 2 || loadedFits["BenchmarkOne"] != nil {
+		t.Fatalf("expected the 2 fits, got %v", loadedFits)
+	}
+	for _, g := range []string{"BenchmarkFast", "BenchmarkSlow"} {
+		want, got := fits[g], loadedFits[g]
+		for i := range want.Model {
+			if got.Model[i] != want.Model[i] || got.Stats.CI[i] != want.Stats.CI[i] {
+				t.Errorf("%s: expected %v ± %v, got %v ± %v", g, want.Model, want.Stats.CI, got.Model, got.Stats.CI)
+				break
+			}
+		}
+		if len(loaded[g].Y) != len(samps[g].Y) || loaded[g].Max["N"] != samps[g].Max["N"] {
+			t.Errorf("%s: expected the sample to be saved, got %+v", g, loaded[g])
+		}
+	}
+
+	// the models can only be used with the flags they were fit with
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other := strings.Replace(string(b), `"xtransform": "N, 1.0"`, `"xtransform": "N * N, 1.0"`, 1)
+	if other == string(b) {
+		t.Fatalf("expected the saved settings to have xtransform, got %s", b)
+	}
+	if err := ioutil.WriteFile(path, []byte(other), 0666); err != nil {
+		t.Fatal(err)
+	}
+	xt := flag.Lookup("xtransform").Value.String()
+	defer flag.Set("xtransform", xt)
+	if err := flag.Set("xtransform", xt); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := loadModels(path); err == nil || !strings.Contains(err.Error(), "-xtransform") {
+		t.Errorf("expected an error for a different -xtransform, got %v", err)
+	}
+}
@@ -0,0 +1,179 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/jonlawlor/benchls"
+)
+
+// job is what prepare reads and parses from the flags and input files, for
+// a mode to fit and report.
+type job struct {
+	benchSet, afterSet benchls.Set
+	inputs             []seriesInput // of -series
+	ex                 benchls.Extractor
+	varNames           map[string]struct{}
+	xExprs, terms      []benchls.Expression
+	yExpr              benchls.Expression
+	parts              []part
+	points             []map[string]float64
+
+	// the fits of -load-model, and the samples they were fit to
+	loadedSamps map[string]benchls.Sample
+	loadedFits  map[string]*benchls.Fit
+
+	man  *manifest
+	seed int64
+}
+
+// input returns the only input variable, which the complexity classes and
+// power laws are in terms of.
+func (j *job) input() string {
+	var inputs []string
+	for name := range j.ex.VarNames() {
+		if name != "" {
+			inputs = append(inputs, name)
+		}
+	}
+	if len(inputs) != 1 {
+		log.Fatalf("-infer and -powerlaw need exactly one input variable, have %q", inputs)
+	}
+	return inputs[0]
+}
+
+// infer reports the best fitting complexity class of each group.
+func (j *job) infer() {
+	cands, err := benchls.Infer(j.benchSet, j.ex, j.input(), j.yExpr, flagYVar, fitOpts)
+	if err != nil {
+		log.Fatal(err)
+	}
+	writeInfer(j.yExpr, cands, j.man, j.seed, os.Stdout)
+}
+
+// powerLaw reports the power law of each group.
+func (j *job) powerLaw() {
+	input := j.input()
+	pls, err := benchls.PowerLaws(j.benchSet, j.ex, input, j.yExpr, flagYVar, fitOpts)
+	if err != nil {
+		log.Fatal(err)
+	}
+	writePowerLaws(input, j.yExpr, pls, j.man, j.seed, os.Stdout)
+}
+
+// candidates reports the subset of the -candidates terms that fits each
+// group best.
+func (j *job) candidates() {
+	cands, err := benchls.NewExpressions(flagCands, j.varNames, consts)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(cands) > benchls.MaxCandidates {
+		log.Fatalf("-candidates has %d terms, more than %d", len(cands), benchls.MaxCandidates)
+	}
+	subsets := make(map[string][]benchls.Subset)
+	for g, samp := range sampleGroups(j.benchSet, j.ex, cands, j.yExpr) {
+		if subsets[g], err = benchls.BestSubsets(samp, fitOpts); err != nil {
+			log.Fatal(err)
+		}
+	}
+	writeSubsets(cands, j.yExpr, subsets, j.man, j.seed, os.Stdout)
+}
+
+// compare reports the change in each coefficient from the first input, or
+// the loaded fits, to the last.
+func (j *job) compare() {
+	before := j.loadedFits
+	if before == nil {
+		before = make(map[string]*benchls.Fit)
+		for g, samp := range sampleGroups(j.benchSet, j.ex, j.xExprs, j.yExpr) {
+			before[g], _, _ = fitSample(samp)
+		}
+	}
+	after := make(map[string]*benchls.Fit)
+	for g, samp := range sampleGroups(j.afterSet, j.ex, j.xExprs, j.yExpr) {
+		after[g], _, _ = fitSample(samp)
+	}
+	writeCompare(j.xExprs, j.yExpr, before, after, j.man, j.seed, os.Stdout)
+}
+
+// series reports the coefficients of every group in each of the -series
+// inputs.
+func (j *job) series() {
+	labels := make([]string, len(j.inputs))
+	fits := make([]map[string]*benchls.Fit, len(j.inputs))
+	for i, in := range j.inputs {
+		labels[i] = in.label
+		fits[i] = make(map[string]*benchls.Fit)
+		for g, samp := range sampleGroups(in.set, j.ex, j.xExprs, j.yExpr) {
+			fits[i][g], _, _ = fitSample(samp)
+		}
+	}
+	writeSeries(j.terms, j.yExpr, labels, fits, j.man, j.seed, os.Stdout)
+}
+
+// breakpoints reports the piecewise fit of each group.
+func (j *job) breakpoints() {
+	pws := make(map[string]*benchls.Piecewise)
+	for g, samp := range sampleGroups(j.benchSet, j.ex, j.xExprs, j.yExpr) {
+		pws[g] = benchls.Segment(samp, 0, flagBreaks, fitOpts)
+	}
+	writeBreakpoints(j.xExprs, j.yExpr, pws, j.man, j.seed, os.Stdout)
+}
+
+// matrix reports the leading coefficient of each group in each
+// configuration.
+func (j *job) matrix() {
+	keys, sets := benchls.SplitConfigs(j.benchSet)
+	if flagRef != "" && sets[flagRef] == nil {
+		log.Fatalf("unknown reference configuration %q, have %q", flagRef, keys)
+	}
+	leads := leadingCoefs(keys, sets, j.ex, j.xExprs, j.yExpr)
+	writeMatrix(j.xExprs, keys, leads, flagRef, j.man, j.seed, os.Stdout)
+}
+
+// report reports the loaded fits, or fits and reports each response, and
+// the groups of each model, in turn.  It exits with status 1 if a fit of any
+// response violates a -check threshold, after the report is written.
+func (j *job) report() {
+	var fails []string
+	if j.loadedFits != nil {
+		writeOutputs(j.xExprs, j.terms, j.yExpr, j.loadedSamps, j.loadedFits, nil, nil, j.points, j.man, j.seed)
+		fails = flagChecks.failures(j.loadedFits)
+	} else {
+		for i, y := range responses {
+			flagYVar = y
+			fits := make(map[string]*benchls.Fit)
+			for k, p := range j.parts {
+				if i > 0 || k > 0 {
+					fmt.Println()
+				}
+				pTerms := j.terms
+				if len(flagXTFor) > 0 {
+					pTerms = p.xExprs
+				}
+				for g, fit := range fitAndReport(p.set, j.ex, p.xExprs, pTerms, j.yExpr, j.points, j.man, j.seed) {
+					fits[g] = fit
+				}
+			}
+			for _, f := range flagChecks.failures(fits) {
+				if len(responses) > 1 {
+					f = y + ": " + f
+				}
+				fails = append(fails, f)
+			}
+		}
+	}
+
+	if len(fails) > 0 {
+		for _, f := range fails {
+			log.Print(f)
+		}
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,21 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !arrow
+// +build !arrow
+
+package main
+
+import (
+	"errors"
+
+	"github.com/jonlawlor/benchls"
+)
+
+// haveArrow is whether benchls was built with -tags arrow, to write -arrow.
+const haveArrow = false
+
+func writeArrow(dir string, xExprs []benchls.Expression, yExpr benchls.Expression, samps map[string]benchls.Sample, fits map[string]*benchls.Fit) error {
+	return errors.New("-arrow needs benchls built with -tags arrow")
+}
@@ -0,0 +1,56 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+
+	"github.com/jonlawlor/benchls"
+)
+
+// writeNoise writes the coefficient of variation of the replicates of each
+// benchmark, by group, and notes the groups with benchmarks that vary by more
+// than -noise.  It returns whether there were any replicates.
+func writeNoise(w io.Writer, samps map[string]benchls.Sample) bool {
+	groups := make([]string, 0, len(samps))
+	for g := range samps {
+		groups = append(groups, g)
+	}
+	sort.Strings(groups)
+
+	table := []*row{newRow("group", "benchmark", "runs", "mean", "CV")}
+	var notes []string
+	for _, g := range groups {
+		noise := benchls.ReplicateNoise(samps[g])
+		noisy, worst := 0, 0.0
+		for _, r := range noise {
+			mean := fmt.Sprintf("%.4g", r.Mean)
+			if memoryFormat() {
+				mean = byteSize(r.Mean, 4)
+			}
+			table = append(table, newRow(g, r.Name, fmt.Sprint(r.N), mean, percent(r.CV)))
+			if r.CV > flagNoise {
+				noisy++
+				worst = math.Max(worst, r.CV)
+			}
+		}
+		if noisy > 0 {
+			notes = append(notes, fmt.Sprintf("%s: %d of %d benchmarks vary by more than -noise %s, up to %s, so its confidence intervals may be meaningless", g, noisy, len(noise), percent(flagNoise), percent(worst)))
+		}
+	}
+	if len(table) == 1 {
+		return false
+	}
+
+	var buf bytes.Buffer
+	writeTable(&buf, table)
+	writeNotes(&buf, notes)
+	w.Write(buf.Bytes())
+	return true
+}
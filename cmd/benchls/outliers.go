@@ -0,0 +1,60 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/jonlawlor/benchls"
+)
+
+// outlier is an observation that unduly influences the fit of its group.
+type outlier struct {
+	Name        string `json:"name"`
+	Studentized number `json:"studentized"`
+	Leverage    number `json:"leverage"`
+	Cook        number `json:"cook"`
+}
+
+func outliers(s benchls.Sample, fit *benchls.Fit) []outlier {
+	var outs []outlier
+	for _, in := range benchls.Outliers(fit.Model, s) {
+		outs = append(outs, outlier{in.Name, number(in.Studentized), number(in.Leverage), number(in.Cook)})
+	}
+	return outs
+}
+
+// writeOutliers writes the outliers of each group that could be fit.
+func writeOutliers(w io.Writer, samps map[string]benchls.Sample, fits map[string]*benchls.Fit) {
+	groups := make([]string, 0, len(fits))
+	for g, fit := range fits {
+		if fit != nil {
+			groups = append(groups, g)
+		}
+	}
+	sort.Strings(groups)
+
+	table := []*row{newRow("group", "outlier", "studentized", "leverage", "Cook's D")}
+	for _, g := range groups {
+		for _, o := range outliers(samps[g], fits[g]) {
+			r := newRow(g, o.Name)
+			r.mark(noteClass)
+			r.add(fmt.Sprintf("%.2f", float64(o.Studentized)))
+			r.add(fmt.Sprintf("%.2f", float64(o.Leverage)))
+			r.add(fmt.Sprintf("%.3g", float64(o.Cook)))
+			table = append(table, r)
+		}
+	}
+	if len(table) == 1 {
+		table = append(table, newRow("~", "none"))
+	}
+
+	var buf bytes.Buffer
+	writeTable(&buf, table)
+	w.Write(buf.Bytes())
+}
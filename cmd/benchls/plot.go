@@ -0,0 +1,126 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"image/color"
+	"math"
+	"path/filepath"
+
+	"github.com/jonlawlor/benchls"
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
+)
+
+// plotExts are the file extensions of the formats that -plot can write.
+var plotExts = []string{"svg", "png", "pdf", "eps", "jpg", "tif"}
+
+// the size of each plot that -plot writes
+const plotImageWidth, plotImageHeight = 6 * vg.Inch, 4.5 * vg.Inch
+
+// plotCurvePoints is the number of points the fitted curve is evaluated at.
+const plotCurvePoints = 200
+
+var (
+	obsColor   = color.RGBA{R: 178, G: 34, B: 34, A: 255}  // firebrick
+	curveColor = color.RGBA{R: 70, G: 130, B: 180, A: 255} // steelblue
+)
+
+// writePlots writes a plot file per group to dir, showing the observations
+// and the fitted curve against the group's input variable, in the format of
+// the file extension ext.  Groups with some other number of input variables,
+// or that could not be fit, are skipped.
+func writePlots(dir, ext string, xExprs []benchls.Expression, yExpr benchls.Expression, samps map[string]benchls.Sample, fits map[string]*benchls.Fit, logScale bool) error {
+	for g, fit := range fits {
+		s := samps[g]
+		var names []string
+		for name := range s.Min {
+			names = append(names, name)
+		}
+		if fit == nil || len(names) != 1 {
+			continue
+		}
+		p, err := newPlot(g, names[0], xExprs, yExpr, s, fit.Model, logScale)
+		if err != nil {
+			return err
+		}
+		if err := p.Save(plotImageWidth, plotImageHeight, filepath.Join(dir, fileName(g)+"."+ext)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// newPlot plots the observations of s and the curve of m against the input
+// variable v.  On log-log axes, the observations that are not positive are
+// left out, and the curve is cut off where it is not.
+func newPlot(group, v string, xExprs []benchls.Expression, yExpr benchls.Expression, s benchls.Sample, m benchls.Model, logScale bool) (*plot.Plot, error) {
+	obs := make(plotter.XYs, 0, len(s.Y))
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for i, y := range s.Y {
+		x := s.Vars[i][v]
+		if logScale && (x <= 0 || y <= 0) {
+			continue
+		}
+		obs = append(obs, plotter.XY{X: x, Y: y})
+		lo, hi = math.Min(lo, x), math.Max(hi, x)
+	}
+
+	p := plot.New()
+	p.Title.Text = group
+	p.X.Label.Text = v
+	p.Y.Label.Text = yExpr.String()
+	if len(obs) == 0 {
+		// there is nothing to draw, on log-log axes or otherwise
+		return p, nil
+	}
+	if logScale {
+		p.X.Scale, p.Y.Scale = plot.LogScale{}, plot.LogScale{}
+		p.X.Tick.Marker, p.Y.Tick.Marker = plot.LogTicks{}, plot.LogTicks{}
+	}
+
+	f := curve(v, xExprs, s, m)
+	line := plotter.NewFunction(func(x float64) float64 {
+		y := f(x)
+		if logScale && !(y > 0) {
+			// far below the plot, so that the line is clipped at its edge
+			y = math.SmallestNonzeroFloat64
+		}
+		return y
+	})
+	line.XMin, line.XMax = lo, hi
+	line.Samples = plotCurvePoints
+	line.Color = curveColor
+	line.Width = vg.Points(2)
+
+	sc, err := plotter.NewScatter(obs)
+	if err != nil {
+		return nil, err
+	}
+	sc.GlyphStyle = draw.GlyphStyle{Color: obsColor, Radius: vg.Points(3), Shape: draw.CircleGlyph{}}
+	p.Add(line, sc)
+	return p, nil
+}
+
+// curve returns the fitted curve of m against the input variable v, which
+// holds the other variables, like P, at their values in the first
+// observation of s.
+func curve(v string, xExprs []benchls.Expression, s benchls.Sample, m benchls.Model) func(x float64) float64 {
+	base := s.Vars[0]
+	return func(x float64) float64 {
+		vars := make(map[string]float64, len(base))
+		for k, val := range base {
+			vars[k] = val
+		}
+		vars[v] = x
+		var y float64
+		for j, xExpr := range xExprs {
+			y += m[j] * xExpr.Eval(vars)
+		}
+		return y
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWritePlots(t *testing.T) {
+	xExprs, yExpr, samps, fits := testFits(t)
+	// a response that is not positive is left off of log-log axes
+	samps["BenchmarkSlow"].Y[0] = -1
+
+	for _, test := range []struct {
+		ext      string
+		logScale bool
+	}{
+		{"svg", false},
+		{"svg", true},
+		{"png", false},
+		{"png", true},
+		{"pdf", false},
+	} {
+		dir, err := ioutil.TempDir("", "benchls")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+		if err := writePlots(dir, test.ext, xExprs, yExpr, samps, fits, test.logScale); err != nil {
+			t.Fatalf("%s, log %v: %v", test.ext, test.logScale, err)
+		}
+		files, err := filepath.Glob(filepath.Join(dir, "*"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		// BenchmarkOne could not be fit
+		if len(files) != 2 {
+			t.Errorf("%s, log %v: expected 2 plots, got %q", test.ext, test.logScale, files)
+		}
+		for _, g := range []string{"BenchmarkFast", "BenchmarkSlow"} {
+			b, err := ioutil.ReadFile(filepath.Join(dir, g+"."+test.ext))
+			if err != nil {
+				t.Error(err)
+				continue
+			}
+			switch test.ext {
+			case "svg":
+				if !bytes.Contains(b, []byte("<svg")) || !bytes.Contains(b, []byte(g)) {
+					t.Errorf("%s, log %v: expected an SVG titled %s, got %.100q", g, test.logScale, g, b)
+				}
+			case "png":
+				if _, err := png.Decode(bytes.NewReader(b)); err != nil {
+					t.Errorf("%s, log %v: %v", g, test.logScale, err)
+				}
+			case "pdf":
+				if !bytes.HasPrefix(b, []byte("%PDF")) {
+					t.Errorf("%s: expected a PDF, got %.20q", g, b)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,38 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/jonlawlor/benchls"
+)
+
+// writePowerLaws writes the exponent of the power law of each group, and the
+// constant factor it implies.
+func writePowerLaws(v string, yExpr benchls.Expression, pls map[string]benchls.PowerLaw, man *manifest, seed int64, w io.Writer) {
+	groups := make([]string, 0, len(pls))
+	for g := range pls {
+		groups = append(groups, g)
+	}
+	sort.Strings(groups)
+
+	table := []*row{newRow("group \\ "+yExpr.String()+" ~ c * "+v+"^b", "b", "c", "log R^2")}
+	for _, g := range groups {
+		pl := pls[g]
+		table = append(table, newRow(g,
+			fmt.Sprintf("%.3f±%.3f", pl.Exponent, pl.ExponentCI),
+			fmt.Sprintf("%.3g [%.3g, %.3g]", pl.Constant, pl.ConstantLo, pl.ConstantHi),
+			fmt.Sprintf("%.6g", pl.Fit.Stats.RSquared)))
+	}
+
+	var buf bytes.Buffer
+	writeHeader(&buf, man, seed)
+	writeTable(&buf, table)
+	w.Write(buf.Bytes())
+}
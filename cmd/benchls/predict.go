@@ -0,0 +1,141 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jonlawlor/benchls"
+)
+
+// parsePredict parses points to predict at, like "M=10;N=1e8,1e9", into
+// every combination of the listed values of each variable.
+func parsePredict(spec string) ([]map[string]float64, error) {
+	points := []map[string]float64{{}}
+	for _, assign := range strings.Split(spec, ";") {
+		kv := strings.SplitN(assign, "=", 2)
+		if len(kv) != 2 || strings.TrimSpace(kv[0]) == "" {
+			return nil, fmt.Errorf("invalid prediction %q, want name=value[,value...]", assign)
+		}
+		name := strings.TrimSpace(kv[0])
+		var next []map[string]float64
+		for _, v := range strings.Split(kv[1], ",") {
+			f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value for %s: %q", name, v)
+			}
+			for _, p := range points {
+				q := map[string]float64{name: f}
+				for k, v := range p {
+					q[k] = v
+				}
+				next = append(next, q)
+			}
+		}
+		points = next
+	}
+	return points, nil
+}
+
+// pointString formats a point like "M=10, N=1e+08".
+func pointString(p map[string]float64) string {
+	names := make([]string, 0, len(p))
+	for name := range p {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = fmt.Sprintf("%s=%g", name, p[name])
+	}
+	return strings.Join(parts, ", ")
+}
+
+// prediction is the predicted response of one group at one point.  With
+// -back, Y is on the original scale of the response, and the prediction
+// interval, whose half-width PI is on the scale of the fit, is [Lo, Hi].
+type prediction struct {
+	At    map[string]float64 `json:"at"`
+	Y     number             `json:"y"`
+	PI    number             `json:"pi"` // prediction interval half-width
+	Lo    number             `json:"lo,omitempty"`
+	Hi    number             `json:"hi,omitempty"`
+	Extra string             `json:"extrapolation,omitempty"`
+}
+
+// predict evaluates the fit of s at each of the points.
+func predict(xExprs []benchls.Expression, s benchls.Sample, fit *benchls.Fit, points []map[string]float64) []prediction {
+	preds := make([]prediction, len(points))
+	smear := 1.0
+	if flagSmear {
+		smear = backResponse.Smearing(fit.Model, s)
+	}
+	for i, p := range points {
+		vars := map[string]float64{"P": 1}
+		for k, v := range p {
+			vars[k] = v
+		}
+		x := make([]float64, len(xExprs))
+		for j, xExpr := range xExprs {
+			x[j] = xExpr.Eval(vars)
+		}
+		y, pi := benchls.Predict(fit.Model, s, x, fitOpts)
+		if flagWiden {
+			pi *= benchls.Widening(s, p)
+		}
+		preds[i] = prediction{At: p, Y: number(y), PI: number(pi), Extra: benchls.Extrapolation(s, p)}
+		if flagBack {
+			// the interval of the median is not smeared
+			preds[i].Y = number(backResponse.Inverse(y) * smear)
+			preds[i].Lo, preds[i].Hi = number(backResponse.Inverse(y-pi)), number(backResponse.Inverse(y+pi))
+		}
+	}
+	return preds
+}
+
+// writePredictions writes the predicted response of each group that could be
+// fit at each of the points, noting predictions outside the observed range.
+func writePredictions(w io.Writer, xExprs []benchls.Expression, yExpr benchls.Expression, samps map[string]benchls.Sample, fits map[string]*benchls.Fit, points []map[string]float64) {
+	groups := make([]string, 0, len(fits))
+	for g, fit := range fits {
+		if fit != nil {
+			groups = append(groups, g)
+		}
+	}
+	sort.Strings(groups)
+
+	table := []*row{newRow("group", "at", yExpr.String(), "±", "extrapolation")}
+	if flagBack {
+		table[0] = newRow("group", "at", backResponse.Inner, "interval", "extrapolation")
+	}
+	for _, g := range groups {
+		for _, p := range predict(xExprs, samps[g], fits[g], points) {
+			y, pi := fmt.Sprintf("%.4g", float64(p.Y)), fmt.Sprintf("%.2g", float64(p.PI))
+			if memoryFormat() {
+				y, pi = byteSize(float64(p.Y), 4), byteSize(float64(p.PI), 2)
+			}
+			if flagBack {
+				lo, hi := fmt.Sprintf("%.4g", float64(p.Lo)), fmt.Sprintf("%.4g", float64(p.Hi))
+				if memoryFormat() {
+					lo, hi = byteSize(float64(p.Lo), 4), byteSize(float64(p.Hi), 4)
+				}
+				pi = "[" + lo + ", " + hi + "]"
+			}
+			r := newRow(g, pointString(p.At), y, pi, p.Extra)
+			r.mark(noteClass)
+			r.trim()
+			table = append(table, r)
+		}
+	}
+
+	var buf bytes.Buffer
+	writeTable(&buf, table)
+	w.Write(buf.Bytes())
+}
@@ -0,0 +1,102 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jonlawlor/benchls"
+)
+
+// writePrometheus writes the fitted coefficients, their confidence intervals
+// and the R^2 of each group to path as Prometheus gauges, in the text
+// exposition format read by the node exporter's textfile collector.  The file
+// is replaced in one step, so that a collector never reads part of it.
+// Groups that could not be fit are left out.
+func writePrometheus(path string, terms []benchls.Expression, samps map[string]benchls.Sample, fits map[string]*benchls.Fit) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(path), ".benchls")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(promGauges(terms, samps, fits))
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		// the collector only reads files that it has permission to
+		err = os.Chmod(tmp.Name(), 0644)
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
+
+// promGauges returns the gauges of writePrometheus.
+func promGauges(terms []benchls.Expression, samps map[string]benchls.Sample, fits map[string]*benchls.Fit) []byte {
+	groups := make([]string, 0, len(fits))
+	for g, fit := range fits {
+		if fit != nil {
+			groups = append(groups, g)
+		}
+	}
+	sort.Strings(groups)
+
+	var buf bytes.Buffer
+	gauge := func(name, help string, value func(g string, fit *benchls.Fit)) {
+		fmt.Fprintf(&buf, "# HELP %s %s\n# TYPE %s gauge\n", name, help, name)
+		for _, g := range groups {
+			value(g, fits[g])
+		}
+	}
+	perTerm := func(name string, v func(fit *benchls.Fit, j int) float64) func(string, *benchls.Fit) {
+		return func(g string, fit *benchls.Fit) {
+			for j := range fit.Model {
+				fmt.Fprintf(&buf, "%s{group=%s,response=%s,term=%s} %s\n", name,
+					promLabel(g), promLabel(flagYVar), promLabel(terms[j].String()), promFloat(v(fit, j)))
+			}
+		}
+	}
+	gauge("benchls_coefficient", "Fitted coefficient of each term of the model of a group of benchmarks.",
+		perTerm("benchls_coefficient", func(fit *benchls.Fit, j int) float64 { return fit.Model[j] }))
+	gauge("benchls_coefficient_ci", "Half-width of the confidence interval of each coefficient.",
+		perTerm("benchls_coefficient_ci", func(fit *benchls.Fit, j int) float64 { return fit.Stats.CI[j] }))
+	gauge("benchls_r_squared", "Coefficient of determination of the model of a group of benchmarks.", func(g string, fit *benchls.Fit) {
+		fmt.Fprintf(&buf, "benchls_r_squared{group=%s,response=%s} %s\n", promLabel(g), promLabel(flagYVar), promFloat(fit.Stats.RSquared))
+	})
+	gauge("benchls_observations", "Number of observations the model of a group of benchmarks was fit to.", func(g string, fit *benchls.Fit) {
+		fmt.Fprintf(&buf, "benchls_observations{group=%s,response=%s} %d\n", promLabel(g), promLabel(flagYVar), len(samps[g].Y))
+	})
+	return buf.Bytes()
+}
+
+// promLabel quotes s as a Prometheus label value.
+func promLabel(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
+
+// promFloat formats f as a Prometheus sample value.
+func promFloat(f float64) string {
+	switch {
+	case math.IsNaN(f):
+		return "NaN"
+	case math.IsInf(f, 1):
+		return "+Inf"
+	case math.IsInf(f, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
@@ -0,0 +1,73 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWritePrometheus(t *testing.T) {
+	xExprs, _, samps, fits := testFits(t)
+	flagYVar = "NsPerOp"
+	defer func() { flagYVar = "NsPerOp" }()
+	dir, err := ioutil.TempDir("", "benchls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "benchls.prom")
+	if err := writePrometheus(path, xExprs, samps, fits); err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prom := string(b)
+
+	for _, name := range []string{"benchls_coefficient", "benchls_coefficient_ci", "benchls_r_squared", "benchls_observations"} {
+		if !strings.Contains(prom, "# TYPE "+name+" gauge\n") {
+			t.Errorf("expected the gauge %s, got\n%s", name, prom)
+		}
+	}
+	for _, want := range []string{
+		`benchls_coefficient{group="BenchmarkFast",response="NsPerOp",term="N"} `,
+		`benchls_coefficient{group="BenchmarkSlow",response="NsPerOp",term="1.0"} `,
+		`benchls_observations{group="BenchmarkSlow",response="NsPerOp"} 8` + "\n",
+	} {
+		if !strings.Contains(prom, want) {
+			t.Errorf("expected %s, got\n%s", want, prom)
+		}
+	}
+	// the groups that could not be fit are left out
+	if strings.Contains(prom, "BenchmarkOne") {
+		t.Errorf("expected no gauges of BenchmarkOne, got\n%s", prom)
+	}
+	// the file is complete and readable by the collector
+	if fi, err := os.Stat(path); err != nil {
+		t.Error(err)
+	} else if fi.Mode().Perm() != 0644 {
+		t.Errorf("expected mode 0644, got %v", fi.Mode())
+	}
+	if entries, _ := ioutil.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("expected no temporary files to be left, got %d files", len(entries))
+	}
+}
+
+func TestPromValues(t *testing.T) {
+	if got, want := promLabel("a\"b\\c\nd"), `"a\"b\\c\nd"`; got != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+	for f, want := range map[float64]string{1.5: "1.5", math.NaN(): "NaN", math.Inf(1): "+Inf", math.Inf(-1): "-Inf"} {
+		if got := promFloat(f); got != want {
+			t.Errorf("%g: expected %s, got %s", f, want, got)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"math"
+	"sort"
+
+	"github.com/jonlawlor/benchls"
+)
+
+// The wire types of the protocol buffer encoding.
+const (
+	protoVarint  = 0
+	protoFixed64 = 1
+	protoBytes   = 2
+)
+
+// writeProto writes the fits of each group to path as a Fits message of
+// benchls.proto, with the groups in the order of their names.  Groups that
+// could not be fit have an error instead of coefficients.
+func writeProto(path string, terms []benchls.Expression, yExpr benchls.Expression, samps map[string]benchls.Sample, fits map[string]*benchls.Fit) error {
+	groups := make([]string, 0, len(samps))
+	for g := range samps {
+		groups = append(groups, g)
+	}
+	sort.Strings(groups)
+
+	var m protoMessage
+	m.string(1, flagYVar)
+	m.string(2, yExpr.String())
+	for _, x := range terms {
+		m.bytes(3, []byte(x.String()))
+	}
+	m.double(4, flagConfidence)
+	for _, g := range groups {
+		s := samps[g]
+		var gm protoMessage
+		gm.string(1, g)
+		gm.uint(2, uint64(len(s.Y)))
+		if fit := fits[g]; fit == nil {
+			gm.string(3, "cannot fit, "+fitFailure(s, terms))
+		} else {
+			gm.doubles(4, fit.Model)
+			gm.doubles(5, fit.Stats.CI)
+			gm.doubles(6, fit.Stats.SE)
+			gm.doubles(7, fit.Stats.P)
+			gm.double(8, fit.Stats.RSquared)
+			gm.uint(9, uint64(fit.Stats.DOF))
+		}
+		gm.doubleMap(10, s.Min)
+		gm.doubleMap(11, s.Max)
+		m.bytes(5, gm.Bytes())
+	}
+	return ioutil.WriteFile(path, m.Bytes(), 0666)
+}
+
+// protoMessage is a protocol buffer message being encoded.  Fields that have
+// their zero value are left out, as in proto3.
+type protoMessage struct {
+	bytes.Buffer
+}
+
+func (m *protoMessage) key(field, wire int) {
+	m.varint(uint64(field<<3 | wire))
+}
+
+func (m *protoMessage) varint(v uint64) {
+	var b [binary.MaxVarintLen64]byte
+	m.Write(b[:binary.PutUvarint(b[:], v)])
+}
+
+// uint encodes an integer field, which is a uint64 or an int64 that is not
+// negative.
+func (m *protoMessage) uint(field int, v uint64) {
+	if v != 0 {
+		m.key(field, protoVarint)
+		m.varint(v)
+	}
+}
+
+func (m *protoMessage) double(field int, f float64) {
+	if bits := math.Float64bits(f); bits != 0 {
+		m.key(field, protoFixed64)
+		var b [8]byte
+		binary.LittleEndian.PutUint64(b[:], bits)
+		m.Write(b[:])
+	}
+}
+
+// bytes encodes a length delimited field, which is a string, an embedded
+// message or an element of a repeated one.
+func (m *protoMessage) bytes(field int, p []byte) {
+	m.key(field, protoBytes)
+	m.varint(uint64(len(p)))
+	m.Write(p)
+}
+
+func (m *protoMessage) string(field int, s string) {
+	if s != "" {
+		m.bytes(field, []byte(s))
+	}
+}
+
+// doubles encodes a repeated double field, packed.
+func (m *protoMessage) doubles(field int, fs []float64) {
+	if len(fs) == 0 {
+		return
+	}
+	b := make([]byte, 8*len(fs))
+	for i, f := range fs {
+		binary.LittleEndian.PutUint64(b[8*i:], math.Float64bits(f))
+	}
+	m.bytes(field, b)
+}
+
+// doubleMap encodes a map<string, double> field, as entries in the order of
+// their keys.
+func (m *protoMessage) doubleMap(field int, vs map[string]float64) {
+	keys := make([]string, 0, len(vs))
+	for k := range vs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		var entry protoMessage
+		entry.string(1, k)
+		entry.double(2, vs[k])
+		m.bytes(field, entry.Bytes())
+	}
+}
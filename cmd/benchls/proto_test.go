@@ -0,0 +1,175 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// protoField is a decoded field of a protocol buffer message.  Varint and
+// fixed64 fields have a value, and length delimited fields have bytes.
+type protoField struct {
+	wire  int
+	value uint64
+	bytes []byte
+}
+
+// decodeProto returns the fields of the message b by field number, in the
+// order they were encoded.
+func decodeProto(t *testing.T, b []byte) map[int][]protoField {
+	fields := make(map[int][]protoField)
+	for len(b) > 0 {
+		key, n := binary.Uvarint(b)
+		if n <= 0 {
+			t.Fatalf("invalid key in %x", b)
+		}
+		b = b[n:]
+		f := protoField{wire: int(key & 7)}
+		switch f.wire {
+		case protoVarint:
+			if f.value, n = binary.Uvarint(b); n <= 0 {
+				t.Fatalf("invalid varint in %x", b)
+			}
+			b = b[n:]
+		case protoFixed64:
+			if len(b) < 8 {
+				t.Fatalf("short fixed64 in %x", b)
+			}
+			f.value, b = binary.LittleEndian.Uint64(b), b[8:]
+		case protoBytes:
+			l, n := binary.Uvarint(b)
+			if n <= 0 || uint64(len(b)-n) < l {
+				t.Fatalf("invalid length in %x", b)
+			}
+			f.bytes, b = b[n:n+int(l)], b[n+int(l):]
+		default:
+			t.Fatalf("unexpected wire type %d", f.wire)
+		}
+		fields[int(key>>3)] = append(fields[int(key>>3)], f)
+	}
+	return fields
+}
+
+func TestProtoMessage(t *testing.T) {
+	// the examples of the protocol buffer encoding documentation, and the
+	// zero values that proto3 leaves out
+	for _, test := range []struct {
+		encode func(m *protoMessage)
+		want   []byte
+	}{
+		{func(m *protoMessage) { m.uint(1, 150) }, []byte{0x08, 0x96, 0x01}},
+		{func(m *protoMessage) { m.string(2, "testing") }, []byte{0x12, 0x07, 't', 'e', 's', 't', 'i', 'n', 'g'}},
+		{func(m *protoMessage) { m.double(3, 1) }, []byte{0x19, 0, 0, 0, 0, 0, 0, 0xf0, 0x3f}},
+		{func(m *protoMessage) { m.doubles(4, []float64{1, 0}) }, []byte{0x22, 0x10, 0, 0, 0, 0, 0, 0, 0xf0, 0x3f, 0, 0, 0, 0, 0, 0, 0, 0}},
+		{func(m *protoMessage) { m.doubleMap(5, map[string]float64{"N": 1}) }, []byte{0x2a, 0x0c, 0x0a, 0x01, 'N', 0x11, 0, 0, 0, 0, 0, 0, 0xf0, 0x3f}},
+		{func(m *protoMessage) { m.uint(1, 0); m.string(2, ""); m.double(3, 0); m.doubles(4, nil) }, nil},
+	} {
+		var m protoMessage
+		test.encode(&m)
+		if !bytes.Equal(m.Bytes(), test.want) {
+			t.Errorf("expected % x, got % x", test.want, m.Bytes())
+		}
+	}
+}
+
+func TestWriteProto(t *testing.T) {
+	xExprs, yExpr, samps, fits := testFits(t)
+	flagYVar = "NsPerOp"
+	defer func() { flagYVar = "NsPerOp" }()
+	dir, err := ioutil.TempDir("", "benchls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "fits.pb")
+	if err := writeProto(path, xExprs, yExpr, samps, fits); err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the field numbers are those of benchls.proto
+	msg := decodeProto(t, b)
+	if f := msg[1]; len(f) != 1 || string(f[0].bytes) != "NsPerOp" {
+		t.Errorf("expected response NsPerOp, got %v", f)
+	}
+	if f := msg[2]; len(f) != 1 || string(f[0].bytes) != "Y" {
+		t.Errorf("expected ytransform Y, got %v", f)
+	}
+	if f := msg[3]; len(f) != 2 || string(f[0].bytes) != "N" || string(f[1].bytes) != "1.0" {
+		t.Errorf("expected terms N and 1.0, got %v", f)
+	}
+	if f := msg[4]; len(f) != 1 || math.Float64frombits(f[0].value) != 0.95 {
+		t.Errorf("expected confidence 0.95, got %v", f)
+	}
+	if len(msg[5]) != 3 {
+		t.Fatalf("expected 3 groups, got %d", len(msg[5]))
+	}
+
+	doubles := func(f []protoField) []float64 {
+		if len(f) != 1 || f[0].wire != protoBytes {
+			t.Fatalf("expected a packed repeated double, got %v", f)
+		}
+		fs := make([]float64, len(f[0].bytes)/8)
+		for i := range fs {
+			fs[i] = math.Float64frombits(binary.LittleEndian.Uint64(f[0].bytes[8*i:]))
+		}
+		return fs
+	}
+	// the groups are in the order of their names
+	for i, name := range []string{"BenchmarkFast", "BenchmarkOne", "BenchmarkSlow"} {
+		g := decodeProto(t, msg[5][i].bytes)
+		if f := g[1]; len(f) != 1 || string(f[0].bytes) != name {
+			t.Errorf("expected group %d to be %s, got %v", i, name, f)
+			continue
+		}
+		if f := g[2]; len(f) != 1 || f[0].value != uint64(len(samps[name].Y)) {
+			t.Errorf("%s: expected %d observations, got %v", name, len(samps[name].Y), f)
+		}
+		if len(g[11]) != 1 {
+			t.Errorf("%s: expected the max of N, got %v", name, g[11])
+		} else if max := decodeProto(t, g[11][0].bytes); len(max[1]) != 1 || string(max[1][0].bytes) != "N" || len(max[2]) != 1 || math.Float64frombits(max[2][0].value) != samps[name].Max["N"] {
+			t.Errorf("%s: expected the max of N to be %g, got %v", name, samps[name].Max["N"], max)
+		}
+
+		fit := fits[name]
+		if fit == nil {
+			if len(g[3]) != 1 || len(g[4]) != 0 {
+				t.Errorf("%s: expected an error instead of coefficients, got %v", name, g)
+			}
+			continue
+		}
+		if len(g[3]) != 0 {
+			t.Errorf("%s: expected no error, got %s", name, g[3][0].bytes)
+		}
+		for field, want := range map[int][]float64{4: fit.Model, 5: fit.Stats.CI, 6: fit.Stats.SE, 7: fit.Stats.P} {
+			got := doubles(g[field])
+			if len(got) != len(want) {
+				t.Errorf("%s: expected field %d to be %v, got %v", name, field, want, got)
+				continue
+			}
+			for j := range want {
+				if got[j] != want[j] {
+					t.Errorf("%s: expected field %d to be %v, got %v", name, field, want, got)
+					break
+				}
+			}
+		}
+		if f := g[8]; len(f) != 1 || math.Float64frombits(f[0].value) != fit.Stats.RSquared {
+			t.Errorf("%s: expected r_squared %g, got %v", name, fit.Stats.RSquared, f)
+		}
+		if f := g[9]; len(f) != 1 || f[0].value != uint64(fit.Stats.DOF) {
+			t.Errorf("%s: expected dof %d, got %v", name, fit.Stats.DOF, f)
+		}
+	}
+}
@@ -0,0 +1,805 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// report.go was adapted from benchstat, at https://github.com/rsc/benchstat
+// Its license follows:
+
+// Copyright (c) 2009 The Go Authors. All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are
+// met:
+//
+//    * Redistributions of source code must retain the above copyright
+// notice, this list of conditions and the following disclaimer.
+//    * Redistributions in binary form must reproduce the above
+// copyright notice, this list of conditions and the following disclaimer
+// in the documentation and/or other materials provided with the
+// distribution.
+//    * Neither the name of Google Inc. nor the names of its
+// contributors may be used to endorse or promote products derived from
+// this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+// "AS IS" AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+// LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+// A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT
+// OWNER OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL,
+// SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT
+// LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE,
+// DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"html"
+	"io"
+	"log"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/jonlawlor/benchls"
+)
+
+type row struct {
+	cols    []string
+	classes []string // of the highlighted columns
+}
+
+func newRow(cols ...string) *row {
+	return &row{cols: cols}
+}
+
+func (r *row) add(col string) {
+	r.cols = append(r.cols, col)
+}
+
+// mark highlights the last column with a class.
+func (r *row) mark(class string) {
+	for len(r.classes) < len(r.cols) {
+		r.classes = append(r.classes, "")
+	}
+	r.classes[len(r.cols)-1] = class
+}
+
+// class returns the class of column i, or "" if it is not highlighted.
+func (r *row) class(i int) string {
+	if i < len(r.classes) {
+		return r.classes[i]
+	}
+	return ""
+}
+
+func (r *row) trim() {
+	for len(r.cols) > 0 && r.cols[len(r.cols)-1] == "" {
+		r.cols = r.cols[:len(r.cols)-1]
+	}
+}
+
+// percent formats a fraction as a percentage with two significant digits.
+func percent(f float64) string {
+	p := 100 * f
+	if math.Abs(p) < 100 {
+		return fmt.Sprintf("%.2g%%", p)
+	}
+	return fmt.Sprintf("%.0f%%", p)
+}
+
+// relCI formats the half-width of a confidence interval as a percentage of
+// its coefficient, or "~" if the coefficient is zero.
+func relCI(b, cint float64) string {
+	if b == 0 {
+		return "~"
+	}
+	return "±" + percent(cint/math.Abs(b))
+}
+
+// gofHeadings are the column headings of the -gof measures.
+var gofHeadings = map[string]string{
+	"adj": "adj R^2",
+	"aic": "AIC",
+	"bic": "BIC",
+}
+
+// gofs returns the -gof measures, in the order they were given.
+func gofs() []string {
+	if flagGOF == "" {
+		return nil
+	}
+	names := strings.Split(flagGOF, ",")
+	for i, name := range names {
+		names[i] = strings.TrimSpace(name)
+	}
+	return names
+}
+
+// gof returns the named -gof measure.
+func gof(name string, st benchls.Stats) float64 {
+	switch name {
+	case "adj":
+		return st.AdjRSquared
+	case "aic":
+		return st.AIC
+	case "bic":
+		return st.BIC
+	}
+	panic("unknown goodness of fit measure: " + name)
+}
+
+// sigClass returns the -highlight class of a coefficient with p-value p.
+// A coefficient is significant if p < 1 - -confidence, which agrees with
+// -sig, and more so if p is 5 or 50 times smaller, the conventional 0.01 and
+// 0.001 at the default confidence.
+func sigClass(p float64) string {
+	alpha := 1 - flagConfidence
+	switch {
+	case p < alpha/50:
+		return "sig3"
+	case p < alpha/5:
+		return "sig2"
+	case p < alpha:
+		return "sig1"
+	}
+	return "insig"
+}
+
+// The stars, ANSI colors and CSS styles of the -highlight classes.
+var (
+	sigStars = map[string]string{"sig3": " ***", "sig2": " **", "sig1": " *"}
+	sigANSI  = map[string]string{"sig3": "\x1b[1;32m", "sig2": "\x1b[32m", "sig1": "\x1b[33m", "insig": "\x1b[2m"}
+)
+
+// ansiTerminal is whether stdout is a terminal, which is the only place that
+// -highlight=color writes ANSI colors to.  Redirected text has no colors.
+var ansiTerminal = isTerminal(os.Stdout)
+
+// isTerminal reports whether f is a character device, like a terminal.
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// noteClass is the class of a column of text, like the extrapolation of a
+// prediction or the name of an outlier, which is aligned to the left rather
+// than the right.
+const noteClass = "note"
+
+const (
+	ansiReset = "\x1b[0m"
+	sigCSS    = ".benchls .sig3 { color: green; font-weight: bold; } .benchls .sig2 { color: green; } .benchls .sig1 { color: olive; } .benchls .insig { color: gray; }"
+)
+
+// reportColumns are the statistics that -columns can show.
+var reportColumns = []string{"coeffs", "se", "r2", "df", "n", "pkg"}
+
+// columns returns the set of -columns, or an error if one of them is not in
+// reportColumns.  The report shows them in the order of reportColumns,
+// whatever their order in -columns.
+func columns() (map[string]bool, error) {
+	cols := make(map[string]bool)
+	for _, name := range strings.Split(flagColumns, ",") {
+		name = strings.TrimSpace(name)
+		valid := false
+		for _, c := range reportColumns {
+			valid = valid || name == c
+		}
+		if !valid {
+			return nil, fmt.Errorf("invalid column: %q, the columns are %q", name, reportColumns)
+		}
+		cols[name] = true
+	}
+	return cols, nil
+}
+
+// groupOrder orders groups by name, descending R squared ("r2") or
+// descending leading coefficient ("coef").  Groups that could not be fit come
+// last, and ties are broken by name.
+type groupOrder struct {
+	groups []string
+	fits   map[string]*benchls.Fit
+	by     string
+}
+
+func (o groupOrder) Len() int      { return len(o.groups) }
+func (o groupOrder) Swap(i, j int) { o.groups[i], o.groups[j] = o.groups[j], o.groups[i] }
+func (o groupOrder) Less(i, j int) bool {
+	fi, fj := o.fits[o.groups[i]], o.fits[o.groups[j]]
+	if (fi == nil) != (fj == nil) {
+		return fj == nil
+	}
+	if fi != nil {
+		switch o.by {
+		case "r2":
+			if fi.Stats.RSquared != fj.Stats.RSquared {
+				return fi.Stats.RSquared > fj.Stats.RSquared
+			}
+		case "coef":
+			if fi.Model[0] != fj.Model[0] {
+				return fi.Model[0] > fj.Model[0]
+			}
+		}
+	}
+	return o.groups[i] < o.groups[j]
+}
+
+// sortedGroups returns the groups of fits in the order given by by.
+func sortedGroups(fits map[string]*benchls.Fit, by string) []string {
+	groups := make([]string, 0, len(fits))
+	for g := range fits {
+		groups = append(groups, g)
+	}
+	sort.Sort(groupOrder{groups: groups, fits: fits, by: by})
+	return groups
+}
+
+// varRange describes the observed range of the input variables of a sample,
+// like "N ∈ [10, 1e+07], 7 points", or "N = 10" if N has a single value.
+func varRange(s benchls.Sample) string {
+	names := make([]string, 0, len(s.Min))
+	for name := range s.Min {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var parts []string
+	for _, name := range names {
+		if s.Min[name] == s.Max[name] {
+			parts = append(parts, fmt.Sprintf("%s = %g", name, s.Min[name]))
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("%s ∈ [%g, %g]", name, s.Min[name], s.Max[name]))
+	}
+	points := fmt.Sprintf("%d points", len(s.Y))
+	if len(s.Y) == 1 {
+		points = "1 point"
+	}
+	return strings.Join(append(parts, points), ", ")
+}
+
+// coefficient formats b and its confidence interval, truncating b to the
+// digits that are significant, or with -numfmt.  Numbers of bytes are
+// formatted with binary prefixes.
+func coefficient(b, cint float64) string {
+	bLog := math.Log10(math.Abs(b))
+	cintLog := math.Log10(cint)
+	digits := 2 // if b is not significant
+	if logDiff := bLog - cintLog + 1; logDiff > 0 {
+		// an exact fit has no interval, so use all of the digits
+		digits = int(math.Min(logDiff, 16)) + 1
+	}
+	switch {
+	case memoryFormat():
+		return memoryCoefficient(b, cint, digits)
+	case flagNumFmt == "eng" || flagNumFmt == "si":
+		return engineering(b, digits, flagNumFmt == "si") + "±" + engineering(cint, 2, flagNumFmt == "si")
+	case flagNumFmt != "":
+		return fmt.Sprintf(flagNumFmt, b) + "±" + fmt.Sprintf(flagNumFmt, cint)
+	}
+	return fmt.Sprintf("%.*e±%.1e", digits-1, b, cint)
+}
+
+// siPrefixes are the SI prefixes of the powers of 1000 from 1e-24 to 1e24.
+var siPrefixes = []string{"y", "z", "a", "f", "p", "n", "µ", "m", "", "k", "M", "G", "T", "P", "E", "Z", "Y"}
+
+// engineering formats f with the given number of significant digits and an
+// exponent that is a multiple of 3, like 22.5e+00, or with the SI prefix of
+// that exponent, like 22.5 or 1.2M.
+func engineering(f float64, digits int, si bool) string {
+	if f == 0 || math.IsNaN(f) || math.IsInf(f, 0) {
+		return fmt.Sprintf("%g", f)
+	}
+	exp := 3 * int(math.Floor(math.Log10(math.Abs(f))/3))
+	m := f / math.Pow(10, float64(exp))
+	decimals := func() int {
+		d := digits - 1 - int(math.Floor(math.Log10(math.Abs(m))))
+		if d < 0 {
+			return 0
+		}
+		return d
+	}
+	// rounding may carry the mantissa into the next power of 1000, where it
+	// is rounded, so that 999.5 has the digits of 1.00k rather than 0.9995k
+	if r, _ := strconv.ParseFloat(strconv.FormatFloat(m, 'f', decimals(), 64), 64); math.Abs(r) >= 1000 {
+		exp += 3
+		m = r / 1000
+	}
+	mant := strconv.FormatFloat(m, 'f', decimals(), 64)
+	if i := exp/3 + 8; si && i >= 0 && i < len(siPrefixes) {
+		return mant + siPrefixes[i]
+	}
+	return fmt.Sprintf("%se%+03d", mant, exp)
+}
+
+// delimitedNumber formats f for -format=csv or tsv, with all of its digits.
+func delimitedNumber(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+func writeReport(xExprs []benchls.Expression, yExpr benchls.Expression, samps map[string]benchls.Sample, fits map[string]*benchls.Fit, stabilities, powers map[string]float64, man *manifest, seed int64, w io.Writer) {
+	// writes the model fits and rsquares to the Writer
+	table := reportTable(xExprs, yExpr, samps, fits, stabilities, powers)
+	var buf bytes.Buffer
+	writeHeader(&buf, man, seed)
+	writeTable(&buf, table)
+	if flagShowEq {
+		writeNotes(&buf, equations(xExprs, yExpr, fits))
+	}
+	if flagSummary {
+		writeNotes(&buf, summaries(xExprs, yExpr, fits))
+	}
+	writeNotes(&buf, fitNotes(xExprs, samps, fits))
+
+	w.Write(buf.Bytes())
+}
+
+// reportTable returns the rows of the report, starting with the heading.
+func reportTable(xExprs []benchls.Expression, yExpr benchls.Expression, samps map[string]benchls.Sample, fits map[string]*benchls.Fit, stabilities, powers map[string]float64) []*row {
+	var table []*row
+	xs := make([]string, len(xExprs))
+	for i, xExpr := range xExprs {
+		xs[i] = xExpr.String()
+	}
+	y := yExpr.String()
+	if logTerms != nil {
+		y = logResponse
+	}
+	if len(responses) > 1 {
+		y += " [" + flagYVar + "]"
+	}
+	cols, _ := columns() // checked by checkFlags
+	var units []string
+	if flagUnits {
+		units = coefUnits(xExprs, yExpr)
+	}
+	// delimited output is for spreadsheets, so each coefficient and its
+	// confidence interval are numbers in columns of their own
+	_, delimited := separators[flagFormat]
+	heading := []string{"group \\ " + y + " ~"}
+	for i, x := range xs {
+		if logTerms != nil {
+			x = logHeading(i)
+		}
+		switch {
+		case cols["coeffs"] && delimited && units != nil:
+			heading = append(heading, x+" ("+units[i]+")", x+" ±")
+		case cols["coeffs"] && delimited:
+			heading = append(heading, x, x+" ±")
+		case cols["coeffs"]:
+			heading = append(heading, x)
+		}
+		if cols["se"] && cols["coeffs"] {
+			heading = append(heading, "se")
+		} else if cols["se"] {
+			heading = append(heading, "se("+x+")")
+		}
+		if flagRelCI {
+			heading = append(heading, "±%")
+		}
+		if flagSig {
+			heading = append(heading, "sig")
+		}
+		if flagStats == "full" {
+			heading = append(heading, "t", "p")
+		}
+	}
+	for _, d := range derived {
+		if delimited {
+			heading = append(heading, d.String(), d.String()+" ±")
+		} else {
+			heading = append(heading, d.String())
+		}
+	}
+	if cols["r2"] {
+		heading = append(heading, "R^2")
+	}
+	if cols["df"] {
+		heading = append(heading, "df")
+	}
+	if flagStats == "full" {
+		heading = append(heading, "F", "p(F)")
+	}
+	for _, name := range gofs() {
+		heading = append(heading, gofHeadings[name])
+	}
+	if flagCV != "" {
+		heading = append(heading, "LOO err")
+	}
+	if flagVIF {
+		for _, x := range xs {
+			heading = append(heading, "VIF("+x+")")
+		}
+	}
+	if flagStability > 0 {
+		heading = append(heading, "stability")
+	}
+	if flagVarPower {
+		heading = append(heading, "var power")
+	}
+	if flagWorst {
+		heading = append(heading, "worst point")
+	}
+	if flagMisspec != "" {
+		heading = append(heading, "DW", "p(runs)")
+	}
+	// columns after these describe the sample rather than the fit
+	fitCols := len(heading)
+	if cols["n"] {
+		heading = append(heading, "n")
+	}
+	if flagRanges {
+		heading = append(heading, "range")
+	}
+	// annotate the cpu that produced each group, if it is known
+	showCPU := false
+	for _, s := range samps {
+		if len(s.CPUs) > 0 {
+			showCPU = true
+		}
+	}
+	if showCPU {
+		heading = append(heading, "cpu")
+	}
+	if cols["pkg"] {
+		heading = append(heading, "pkg")
+	}
+	for _, group := range sortedGroups(fits, flagSort) {
+		fit := fits[group]
+
+		if len(table) == 0 {
+			table = append(table, newRow(heading...))
+		}
+
+		r := newRow(group)
+		if fit == nil {
+			// put a placeholder
+			for len(r.cols) < fitCols {
+				r.add("~")
+			}
+		} else {
+			for i, b := range fit.Model {
+				cint := fit.Stats.CI[i]
+				if backTransformed(i) {
+					b, cint = factor(b, cint)
+				}
+				if cols["coeffs"] && delimited {
+					r.add(delimitedNumber(b))
+					r.add(delimitedNumber(cint))
+				} else if cols["coeffs"] {
+					c := coefficient(b, cint)
+					switch {
+					case units != nil && memoryFormat():
+						// the coefficient has a binary prefix of B
+						c += strings.TrimPrefix(units[i], "B")
+					case units != nil:
+						c += " " + units[i]
+					}
+					class := sigClass(fit.Stats.P[i])
+					switch flagHighlight {
+					case "stars":
+						r.add(c + sigStars[class])
+					case "color":
+						r.add(c)
+						r.mark(class)
+					default:
+						r.add(c)
+					}
+				}
+				if cols["se"] {
+					r.add(fmt.Sprintf("%.2g", fit.Stats.SE[i]))
+				}
+				if flagRelCI {
+					r.add(relCI(b, cint))
+				}
+				if flagSig {
+					// significant if the confidence interval excludes zero
+					if math.Abs(b) > cint {
+						r.add("yes")
+					} else {
+						r.add("no")
+					}
+				}
+				if flagStats == "full" {
+					r.add(fmt.Sprintf("%.3g", fit.Stats.T[i]))
+					r.add(fmt.Sprintf("%.2g", fit.Stats.P[i]))
+				}
+			}
+			for _, d := range derived {
+				dv := benchls.Derive(d, fit.Model, samps[group], fitOpts)
+				if delimited {
+					r.add(delimitedNumber(dv.Value))
+					r.add(delimitedNumber(dv.CI))
+				} else {
+					r.add(coefficient(dv.Value, dv.CI))
+				}
+			}
+			if cols["r2"] {
+				r.add(fmt.Sprintf("%g", fit.Stats.RSquared))
+			}
+			if cols["df"] {
+				r.add(strconv.Itoa(fit.Stats.DOF))
+			}
+			if flagStats == "full" {
+				r.add(fmt.Sprintf("%.4g", fit.Stats.F))
+				r.add(fmt.Sprintf("%.2g", fit.Stats.FP))
+			}
+			for _, name := range gofs() {
+				r.add(fmt.Sprintf("%.6g", gof(name, fit.Stats)))
+			}
+			if flagCV != "" {
+				r.add(percent(benchls.LeaveOneOut(fit.Model, samps[group]).Rel))
+			}
+			if flagVIF {
+				vifs := benchls.VIF(samps[group])
+				for i := range xs {
+					if vifs == nil {
+						r.add("~")
+						continue
+					}
+					r.add(fmt.Sprintf("%.3g", vifs[i]))
+				}
+			}
+			if flagStability > 0 {
+				r.add(percent(stabilities[group]))
+			}
+			if flagVarPower {
+				r.add(fmt.Sprintf("%.3g", powers[group]))
+			}
+			if flagWorst {
+				if name, z := benchls.Worst(fit.Model, samps[group]); name != "" {
+					r.add(fmt.Sprintf("%s (%.1fσ)", name, z))
+				} else {
+					r.add("~")
+				}
+			}
+			if flagMisspec != "" {
+				mis := benchls.Misspecified(fit.Model, samps[group], flagMisspec)
+				r.add(fmt.Sprintf("%.3g", mis.DurbinWatson))
+				r.add(fmt.Sprintf("%.2g", mis.RunsP))
+			}
+		}
+		if cols["n"] {
+			r.add(strconv.Itoa(len(samps[group].Y)))
+		}
+		if flagRanges {
+			r.add(varRange(samps[group]))
+		}
+		if showCPU {
+			r.add(strings.Join(samps[group].CPUs, ", "))
+		}
+		if cols["pkg"] {
+			r.add(strings.Join(samps[group].Pkgs, ", "))
+		}
+
+		table = append(table, r)
+	}
+	return table
+}
+
+// fitNotes explains why each group that could not be fit failed, and with
+// -misspec, which of the groups that were fit have residuals with too few runs
+// of the same sign, in the -sort order.
+func fitNotes(terms []benchls.Expression, samps map[string]benchls.Sample, fits map[string]*benchls.Fit) []string {
+	var notes []string
+	for _, g := range sortedGroups(fits, flagSort) {
+		if fits[g] == nil {
+			notes = append(notes, g+": cannot fit, "+fitFailure(samps[g], terms))
+			continue
+		}
+		if flagVIF && benchls.VIF(samps[g]) == nil {
+			notes = append(notes, g+": the variance inflation factors cannot be computed, the terms are collinear")
+		}
+		if flagMisspec == "" {
+			continue
+		}
+		if mis := benchls.Misspecified(fits[g].Model, samps[g], flagMisspec); mis.RunsP < 1-flagConfidence {
+			notes = append(notes, fmt.Sprintf("%s: the residuals ordered by %s have too few runs of the same sign (%d, p=%.2g), check -xtransform and -ytransform", g, flagMisspec, mis.Runs, mis.RunsP))
+		}
+	}
+	return notes
+}
+
+// fitFailure explains why s could not be fit with terms.
+func fitFailure(s benchls.Sample, terms []benchls.Expression) string {
+	if err := benchls.Underdetermined(s, len(terms)); err != nil {
+		return "it " + err.Error()
+	}
+	if i := notFinite(s); i >= 0 {
+		return fmt.Sprintf("the terms or response of %s are not finite, check -xtransform and -ytransform", s.Names[i])
+	}
+	if flagFit == "nls" {
+		return "the nonlinear least squares did not converge"
+	}
+	if flagWeights != "" {
+		if _, err := benchls.ExprWeights(s, weights); err != nil {
+			return "the " + err.Error() + ", check -weights"
+		}
+	}
+	if cols := benchls.Collinear(s); len(cols) > 0 {
+		names := make([]string, 0, len(cols))
+		for _, j := range cols {
+			if j < len(terms) {
+				names = append(names, terms[j].String())
+			}
+		}
+		return fmt.Sprintf("%q are linear combinations of the terms before them in -xtransform", names)
+	}
+	return "the least squares problem is singular"
+}
+
+// notFinite returns the index of the first observation of s with a term or
+// response that is NaN or infinite, or -1 if there is none.
+func notFinite(s benchls.Sample) int {
+	k := len(s.X) / len(s.Y)
+	for i, y := range s.Y {
+		finite := !math.IsNaN(y) && !math.IsInf(y, 0)
+		for _, x := range s.X[i*k : (i+1)*k] {
+			finite = finite && !math.IsNaN(x) && !math.IsInf(x, 0)
+		}
+		if !finite {
+			return i
+		}
+	}
+	return -1
+}
+
+// writeNotes writes notes that follow the table.  Delimited output has no
+// room for them, so there they are logged instead.
+func writeNotes(buf *bytes.Buffer, notes []string) {
+	if len(notes) == 0 {
+		return
+	}
+	switch _, delimited := separators[flagFormat]; {
+	case delimited:
+		for _, n := range notes {
+			log.Print(n)
+		}
+	case flagHTML:
+		fmt.Fprintf(buf, "<ul class='benchls-notes'>\n")
+		for _, n := range notes {
+			fmt.Fprintf(buf, "<li>%s</li>\n", html.EscapeString(n))
+		}
+		fmt.Fprintf(buf, "</ul>\n")
+	default:
+		fmt.Fprintf(buf, "\n")
+		for _, n := range notes {
+			fmt.Fprintf(buf, "%s\n", n)
+		}
+	}
+}
+
+// separators are the field separators of the delimited output formats.
+var separators = map[string]rune{
+	"csv": ',',
+	"tsv": '\t',
+}
+
+// writeHeader writes the manifest, or the random seed if anything depends on
+// it, as comments.  Delimited output has no comments, so it is omitted there.
+func writeHeader(buf *bytes.Buffer, man *manifest, seed int64) {
+	if _, ok := separators[flagFormat]; ok {
+		return
+	}
+	if man != nil {
+		for _, l := range man.lines() {
+			if flagHTML {
+				// "--" may not appear inside an HTML comment
+				fmt.Fprintf(buf, "<!-- %s -->\n", strings.Replace(l, "--", "- -", -1))
+			} else {
+				fmt.Fprintf(buf, "# %s\n", l)
+			}
+		}
+	} else if stochastic() {
+		// the seed is needed to reproduce stochastic results
+		if flagHTML {
+			fmt.Fprintf(buf, "<!-- seed %d -->\n", seed)
+		} else {
+			fmt.Fprintf(buf, "# seed %d\n", seed)
+		}
+	}
+}
+
+// writeTable formats the table, whose first row is the heading, as text or
+// HTML.
+func writeTable(buf *bytes.Buffer, table []*row) {
+	if len(table) == 0 {
+		return
+	}
+	numColumn := 0
+	for _, row := range table {
+		if numColumn < len(row.cols) {
+			numColumn = len(row.cols)
+		}
+	}
+
+	max := make([]int, numColumn)
+	for _, row := range table {
+		for i, s := range row.cols {
+			n := utf8.RuneCountInString(s)
+			if max[i] < n {
+				max[i] = n
+			}
+		}
+	}
+
+	if flagHTML {
+		fmt.Fprintf(buf, "<style>.benchls tbody td:nth-child(1n+2) { text-align: right; padding: 0em 1em; } .benchls td.%s { text-align: left; }", noteClass)
+		if flagHighlight == "color" {
+			fmt.Fprintf(buf, " %s", sigCSS)
+		}
+		fmt.Fprintf(buf, "</style>\n")
+		fmt.Fprintf(buf, "<table class='benchls'>\n")
+		printRow := func(row *row, tag string) {
+			fmt.Fprintf(buf, "<tr>")
+			for i, cell := range row.cols {
+				fmt.Fprintf(buf, "<%s%s>%s</%s>", tag, classAttr(row.class(i)), html.EscapeString(cell), tag)
+			}
+			fmt.Fprintf(buf, "\n")
+		}
+		printRow(table[0], "th")
+		for _, row := range table[1:] {
+			printRow(row, "td")
+		}
+		fmt.Fprintf(buf, "</table>\n")
+	} else if comma, ok := separators[flagFormat]; ok {
+		cw := csv.NewWriter(buf)
+		cw.Comma = comma
+		for _, row := range table {
+			cw.Write(row.cols)
+		}
+		cw.Flush()
+	} else {
+
+		// headings
+		row := table[0]
+		for i, s := range row.cols {
+			switch i {
+			case 0:
+				fmt.Fprintf(buf, "%-*s", max[i], s)
+			default:
+				fmt.Fprintf(buf, "  %-*s", max[i], s)
+			case len(row.cols) - 1:
+				fmt.Fprintf(buf, "  %s\n", s)
+			}
+		}
+
+		// data
+		for _, row := range table[1:] {
+			for i, s := range row.cols {
+				switch {
+				case i == 0:
+					fmt.Fprintf(buf, "%-*s", max[i], s)
+				case row.class(i) == noteClass && i == len(row.cols)-1:
+					fmt.Fprintf(buf, "  %s", s)
+				case row.class(i) == noteClass:
+					fmt.Fprintf(buf, "  %-*s", max[i], s)
+				default:
+					if c := sigANSI[row.class(i)]; c != "" && ansiTerminal {
+						fmt.Fprintf(buf, "  %s%*s%s", c, max[i], s, ansiReset)
+					} else {
+						fmt.Fprintf(buf, "  %*s", max[i], s)
+					}
+				}
+			}
+			fmt.Fprintf(buf, "\n")
+		}
+	}
+
+}
+
+// classAttr returns the HTML class attribute of a highlighted cell.
+func classAttr(class string) string {
+	if class == "" {
+		return ""
+	}
+	return " class='" + class + "'"
+}
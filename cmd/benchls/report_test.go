@@ -0,0 +1,399 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/jonlawlor/benchls"
+)
+
+func TestDelimitedReport(t *testing.T) {
+	xExprs, yExpr, samps, fits := testFits(t)
+	for format, comma := range separators {
+		flagFormat = format
+		var buf bytes.Buffer
+		writeReport(xExprs, yExpr, samps, fits, nil, nil, nil, 0, &buf)
+		r := csv.NewReader(&buf)
+		r.Comma = comma
+		rows, err := r.ReadAll()
+		if err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+		if len(rows) != 4 {
+			t.Fatalf("%s: expected a heading and 3 groups, got %q", format, rows)
+		}
+		if h := rows[0]; len(h) < 5 || h[1] != "N" || h[2] != "N ±" || h[3] != "1.0" || h[4] != "1.0 ±" {
+			t.Errorf("%s: expected a column for each coefficient and interval, got %q", format, h)
+		}
+		// each coefficient and interval is a number with all of its digits
+		var row []string
+		for _, r := range rows[1:] {
+			if r[0] == "BenchmarkFast" {
+				row = r
+			}
+		}
+		if row == nil {
+			t.Fatalf("%s: expected a row for BenchmarkFast, got %q", format, rows)
+		}
+		fast := fits["BenchmarkFast"]
+		for i, want := range []float64{fast.Model[0], fast.Stats.CI[0], fast.Model[1], fast.Stats.CI[1]} {
+			got, err := strconv.ParseFloat(row[i+1], 64)
+			if err != nil || math.Float64bits(got) != math.Float64bits(want) {
+				t.Errorf("%s: expected column %d to be %v, got %q", format, i+1, want, row[i+1])
+			}
+		}
+	}
+	flagFormat = "text"
+}
+
+func TestSortedGroups(t *testing.T) {
+	_, _, _, fits := testFits(t)
+	// Slow has the larger slope, and the groups that cannot be fit are last
+	for by, want := range map[string][]string{
+		"name": {"BenchmarkFast", "BenchmarkSlow", "BenchmarkOne"},
+		"coef": {"BenchmarkSlow", "BenchmarkFast", "BenchmarkOne"},
+	} {
+		got := sortedGroups(fits, by)
+		if len(got) != len(want) {
+			t.Errorf("%s: expected %q, got %q", by, want, got)
+			continue
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("%s: expected %q, got %q", by, want, got)
+				break
+			}
+		}
+	}
+
+	fits["BenchmarkFast"].Stats.RSquared, fits["BenchmarkSlow"].Stats.RSquared = 0.5, 0.9
+	if got := sortedGroups(fits, "r2"); got[0] != "BenchmarkSlow" || got[1] != "BenchmarkFast" {
+		t.Errorf("r2: expected the best fit first, got %q", got)
+	}
+	// ties are broken by name
+	fits["BenchmarkFast"].Stats.RSquared = 0.9
+	if got := sortedGroups(fits, "r2"); got[0] != "BenchmarkFast" {
+		t.Errorf("r2: expected ties in name order, got %q", got)
+	}
+}
+
+func TestVIFColumns(t *testing.T) {
+	xExprs, yExpr, samps, fits := testFits(t)
+	flagVIF, flagFormat = true, "csv"
+	defer func() { flagVIF, flagFormat = false, "text" }()
+	var buf bytes.Buffer
+	writeReport(xExprs, yExpr, samps, fits, nil, nil, nil, 0, &buf)
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	col := make(map[string]int)
+	for i, h := range rows[0] {
+		col[h] = i
+	}
+	for _, r := range rows[1:] {
+		vifs := benchls.VIF(samps[r[0]])
+		for i, x := range []string{"VIF(N)", "VIF(1.0)"} {
+			j, ok := col[x]
+			if !ok {
+				t.Fatalf("expected a %s column, got %q", x, rows[0])
+			}
+			if fits[r[0]] == nil {
+				if r[j] != "~" {
+					t.Errorf("%s: expected no %s, got %q", r[0], x, r[j])
+				}
+				continue
+			}
+			if got, err := strconv.ParseFloat(r[j], 64); err != nil || math.Abs(got-vifs[i]) > 1e-3*vifs[i] {
+				t.Errorf("%s: expected %s to be %.3g, got %q", r[0], x, vifs[i], r[j])
+			}
+		}
+	}
+}
+
+func TestRelCI(t *testing.T) {
+	for _, c := range []struct {
+		b, cint float64
+		want    string
+	}{
+		{4, 1, "±25%"},
+		{-4, 1, "±25%"},
+		{0.5, 2, "±400%"},
+		{3, 0.0123, "±0.41%"},
+		// the interval is no fraction of a zero coefficient
+		{0, 1, "~"},
+	} {
+		if got := relCI(c.b, c.cint); got != c.want {
+			t.Errorf("relCI(%g, %g): expected %s, got %s", c.b, c.cint, c.want, got)
+		}
+	}
+
+	xExprs, yExpr, samps, fits := testFits(t)
+	flagRelCI, flagFormat = true, "csv"
+	defer func() { flagRelCI, flagFormat = false, "text" }()
+	var buf bytes.Buffer
+	writeReport(xExprs, yExpr, samps, fits, nil, nil, nil, 0, &buf)
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cols []int
+	for i, h := range rows[0] {
+		if h == "±%" {
+			cols = append(cols, i)
+		}
+	}
+	if len(cols) != len(xExprs) {
+		t.Fatalf("expected a ±%% column per term, got %q", rows[0])
+	}
+	for _, r := range rows[1:] {
+		fit := fits[r[0]]
+		if fit == nil {
+			continue
+		}
+		for i, j := range cols {
+			want := 100 * fit.Stats.CI[i] / math.Abs(fit.Model[i])
+			got, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimPrefix(r[j], "±"), "%"), 64)
+			if err != nil || math.Abs(got-want) > 0.01*want {
+				t.Errorf("%s: expected the interval of %s to be ±%.2g%%, got %q", r[0], xExprs[i], want, r[j])
+			}
+		}
+	}
+}
+
+func TestSigClass(t *testing.T) {
+	defer func() { flagConfidence = 0.95 }()
+	for _, c := range []struct {
+		confidence float64
+		p          []float64
+		want       []string
+	}{
+		{0.95, []float64{0.9, 0.0501, 0.0499, 0.0101, 0.0099, 0.00101, 0.00099, 0}, []string{"insig", "insig", "sig1", "sig1", "sig2", "sig2", "sig3", "sig3"}},
+		// the thresholds follow -confidence
+		{0.99, []float64{0.0499, 0.0101, 0.0099, 0.0021, 0.0019, 0.00021, 0.00019}, []string{"insig", "insig", "sig1", "sig1", "sig2", "sig2", "sig3"}},
+	} {
+		flagConfidence = c.confidence
+		for i, p := range c.p {
+			if got := sigClass(p); got != c.want[i] {
+				t.Errorf("confidence %g: expected p = %g to be %s, got %s", c.confidence, p, c.want[i], got)
+			}
+		}
+	}
+}
+
+func TestVarRange(t *testing.T) {
+	for _, c := range []struct {
+		s    benchls.Sample
+		want string
+	}{
+		{
+			benchls.Sample{Y: make([]float64, 7), Min: map[string]float64{"N": 10}, Max: map[string]float64{"N": 1e7}},
+			"N ∈ [10, 1e+07], 7 points",
+		},
+		// the variables are in name order
+		{
+			benchls.Sample{Y: make([]float64, 4), Min: map[string]float64{"N": 1, "K": 0.5}, Max: map[string]float64{"N": 100, "K": 2}},
+			"K ∈ [0.5, 2], N ∈ [1, 100], 4 points",
+		},
+		{
+			benchls.Sample{Y: make([]float64, 3), Min: map[string]float64{"N": 8, "K": 4}, Max: map[string]float64{"N": 512, "K": 4}},
+			"K = 4, N ∈ [8, 512], 3 points",
+		},
+		{
+			benchls.Sample{Y: make([]float64, 1), Min: map[string]float64{"N": 10}, Max: map[string]float64{"N": 10}},
+			"N = 10, 1 point",
+		},
+		{benchls.Sample{}, "0 points"},
+	} {
+		if got := varRange(c.s); got != c.want {
+			t.Errorf("expected %q, got %q", c.want, got)
+		}
+	}
+}
+
+func TestColumns(t *testing.T) {
+	defer func() { flagColumns = "coeffs,r2,df,n" }()
+	for _, c := range []struct {
+		columns string
+		want    string // the heading of the report
+	}{
+		{"coeffs,r2,df,n", "group \\ Y ~,N,1.0,R^2,df,n"},
+		{"n", "group \\ Y ~,n"},
+		// in the order of reportColumns, with each se after its coefficient
+		{" n, se ,coeffs", "group \\ Y ~,N,se,1.0,se,n"},
+		{"df,r2", "group \\ Y ~,R^2,df"},
+	} {
+		flagColumns = c.columns
+		cols, err := columns()
+		if err != nil {
+			t.Errorf("-columns=%q: %v", c.columns, err)
+			continue
+		}
+		for _, name := range strings.Split(c.columns, ",") {
+			if !cols[strings.TrimSpace(name)] {
+				t.Errorf("-columns=%q: expected %s to be selected, got %v", c.columns, name, cols)
+			}
+		}
+		xExprs, yExpr, samps, fits := testFits(t)
+		table := reportTable(xExprs, yExpr, samps, fits, nil, nil)
+		if got := strings.Join(table[0].cols, ","); got != c.want {
+			t.Errorf("-columns=%q: expected the heading %q, got %q", c.columns, c.want, got)
+		}
+	}
+
+	for _, bad := range []string{"coeffs,r2,cv", "", "coeffs,"} {
+		flagColumns = bad
+		if _, err := columns(); err == nil || !strings.Contains(err.Error(), "invalid column") {
+			t.Errorf("-columns=%q: expected an invalid column, got %v", bad, err)
+		}
+	}
+}
+
+func TestHighlightColors(t *testing.T) {
+	defer func(term bool) {
+		ansiTerminal, flagHighlight, flagFormat, flagHTML = term, "", "text", false
+	}(ansiTerminal)
+	flagHighlight = "color"
+	table := func() []*row {
+		r := newRow("BenchmarkFast", "3.0±0.1")
+		r.mark("sig3")
+		r.add("0.99")
+		return []*row{newRow("group", "N", "R^2"), r}
+	}
+	for _, c := range []struct {
+		term   bool
+		format string
+		html   bool
+		colors bool
+	}{
+		{true, "text", false, true},
+		// only a terminal has colors
+		{false, "text", false, false},
+		{true, "csv", false, false},
+		{true, "tsv", false, false},
+		{true, "text", true, false},
+	} {
+		ansiTerminal, flagFormat, flagHTML = c.term, c.format, c.html
+		var buf bytes.Buffer
+		writeTable(&buf, table())
+		out := buf.String()
+		if got := strings.Contains(out, "\x1b["); got != c.colors {
+			t.Errorf("terminal %v, format %s, html %v: expected colors %v, got %q", c.term, c.format, c.html, c.colors, out)
+		}
+		if c.colors && !strings.Contains(out, sigANSI["sig3"]+"3.0±0.1"+ansiReset) {
+			t.Errorf("expected the coefficient to be colored, got %q", out)
+		}
+		if c.html && !strings.Contains(out, "<td class='sig3'>3.0±0.1</td>") {
+			t.Errorf("expected the coefficient to have a CSS class, got %q", out)
+		}
+	}
+}
+
+func TestEngineering(t *testing.T) {
+	for _, c := range []struct {
+		f       float64
+		digits  int
+		eng, si string
+	}{
+		{22.5, 3, "22.5e+00", "22.5"},
+		{1234, 3, "1.23e+03", "1.23k"},
+		{-1234, 3, "-1.23e+03", "-1.23k"},
+		{1.2e6, 2, "1.2e+06", "1.2M"},
+		{-0.0045, 2, "-4.5e-03", "-4.5m"},
+		{0.000123456, 4, "123.5e-06", "123.5µ"},
+		// rounding carries into the next prefix
+		{999.5, 3, "1.00e+03", "1.00k"},
+		{-999.5, 3, "-1.00e+03", "-1.00k"},
+		{999.4, 3, "999e+00", "999"},
+		{0, 3, "0", "0"},
+		{math.NaN(), 3, "NaN", "NaN"},
+		{math.Inf(1), 3, "+Inf", "+Inf"},
+		{math.Inf(-1), 3, "-Inf", "-Inf"},
+		// without a prefix, the exponent is kept
+		{1e30, 2, "1.0e+30", "1.0e+30"},
+	} {
+		if got := engineering(c.f, c.digits, false); got != c.eng {
+			t.Errorf("engineering(%g, %d, false): expected %s, got %s", c.f, c.digits, c.eng, got)
+		}
+		if got := engineering(c.f, c.digits, true); got != c.si {
+			t.Errorf("engineering(%g, %d, true): expected %s, got %s", c.f, c.digits, c.si, got)
+		}
+	}
+}
+
+func TestWritePredictionNotes(t *testing.T) {
+	xExprs, yExpr, samps, fits := testFits(t)
+	// the notes of the groups have different lengths
+	samps["BenchmarkSlow"].Max["N"] = 320
+	var buf bytes.Buffer
+	writePredictions(&buf, xExprs, yExpr, samps, fits, []map[string]float64{{"N": 50}, {"N": 800}})
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 5 {
+		t.Fatalf("expected a heading and 4 predictions, got\n%s", buf.String())
+	}
+	// the heading ± is a single column of two bytes
+	col := utf8.RuneCountInString(lines[0][:strings.Index(lines[0], "extrapolation")])
+	for _, line := range lines[1:] {
+		if strings.HasSuffix(line, " ") {
+			t.Errorf("expected no trailing spaces, got %q", line)
+		}
+		i := strings.Index(line, "N=800 is")
+		switch {
+		case strings.Contains(line, "N=50 ") && i >= 0:
+			t.Errorf("expected no note within the observed range, got %q", line)
+		case strings.Contains(line, "N=800 ") && (i < 0 || utf8.RuneCountInString(line[:i]) != col):
+			t.Errorf("expected the note under its heading, at column %d, got\n%s", col, buf.String())
+		}
+	}
+}
+
+func TestWriteOutliers(t *testing.T) {
+	samps := make(map[string]benchls.Sample)
+	fits := make(map[string]*benchls.Fit)
+	for g, bad := range map[string]int{"BenchmarkSort": 4, "BenchmarkSortWithAMuchLongerName": 10} {
+		var s benchls.Sample
+		for n := 1; n <= 10; n++ {
+			y := 2*float64(n) + 1 + 0.01*math.Sin(float64(n))
+			if n == bad {
+				y += 5
+			}
+			s.X = append(s.X, float64(n), 1.0)
+			s.Y = append(s.Y, y)
+			s.Names = append(s.Names, g+"/"+strconv.Itoa(n))
+		}
+		samps[g], fits[g] = s, benchls.NewFit(s, benchls.Options{})
+	}
+	var buf bytes.Buffer
+	writeOutliers(&buf, samps, fits)
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	col := strings.Index(lines[0], "outlier")
+	for _, bad := range []string{"BenchmarkSort/4", "BenchmarkSortWithAMuchLongerName/10"} {
+		found := false
+		for _, line := range lines[1:] {
+			if i := strings.Index(line, "  "+bad+" "); i >= 0 {
+				found = true
+				if i+2 != col {
+					t.Errorf("expected %s under its heading at %d, got it at %d in\n%s", bad, col, i+2, buf.String())
+				}
+			}
+		}
+		if !found {
+			t.Errorf("expected %s to be an outlier, got\n%s", bad, buf.String())
+		}
+	}
+	for _, line := range lines[1:] {
+		if len(line) != len(lines[0]) {
+			t.Errorf("expected every row to end with the Cook's D heading, got\n%s", buf.String())
+			break
+		}
+	}
+}
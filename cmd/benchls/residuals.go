@@ -0,0 +1,56 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/jonlawlor/benchls"
+)
+
+// writeResiduals writes the fitted value, residual and standardized residual
+// of every observation in the groups that could be fit, to stdout if path is
+// "-" and otherwise to the named file.
+func writeResiduals(path string, yExpr benchls.Expression, samps map[string]benchls.Sample, fits map[string]*benchls.Fit) error {
+	groups := make([]string, 0, len(fits))
+	for g, fit := range fits {
+		if fit != nil {
+			groups = append(groups, g)
+		}
+	}
+	sort.Strings(groups)
+
+	table := []*row{newRow("group", "name", yExpr.String(), "fitted", "residual", "std residual")}
+	for _, g := range groups {
+		s := samps[g]
+		// residuals are of the unweighted sample, so that outliers stand out
+		res, std := benchls.Standardized(fits[g].Model, s)
+		for i, y := range s.Y {
+			table = append(table, newRow(g, s.Names[i],
+				fmt.Sprintf("%.4g", y),
+				fmt.Sprintf("%.4g", y-res[i]),
+				fmt.Sprintf("%.3g", res[i]),
+				fmt.Sprintf("%.2f", std[i])))
+		}
+	}
+
+	var buf bytes.Buffer
+	writeTable(&buf, table)
+	var w io.Writer = os.Stdout
+	if path != "-" {
+		f, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
@@ -0,0 +1,65 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/csv"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestWriteResiduals(t *testing.T) {
+	dir, err := ioutil.TempDir("", "benchls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "residuals.csv")
+
+	_, yExpr, samps, fits := testFits(t)
+	flagFormat = "csv"
+	defer func() { flagFormat = "text" }()
+	if err := writeResiduals(path, yExpr, samps, fits); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	rows, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"group", "name", "Y", "fitted", "residual", "std residual"}; len(rows) == 0 || !reflect.DeepEqual(rows[0], want) {
+		t.Fatalf("expected the heading %q, got %q", want, rows)
+	}
+	// a row per observation of the groups that could be fit, in group order
+	if len(rows) != 1+8+8 || rows[1][0] != "BenchmarkFast" || rows[len(rows)-1][0] != "BenchmarkSlow" {
+		t.Fatalf("expected the 16 observations of Fast and Slow, got %q", rows)
+	}
+	next := make(map[string]int)
+	for _, r := range rows[1:] {
+		g := r[0]
+		i := next[g]
+		next[g]++
+		s, model := samps[g], fits[g].Model
+		fitted := model[0]*s.X[2*i] + model[1]*s.X[2*i+1]
+		if r[1] != s.Names[i] {
+			t.Errorf("%s: expected observation %d to be %s, got %s", g, i, s.Names[i], r[1])
+		}
+		for j, want := range map[int]float64{2: s.Y[i], 3: fitted, 4: s.Y[i] - fitted} {
+			got, err := strconv.ParseFloat(r[j], 64)
+			if err != nil || math.Abs(got-want) > 1e-3*math.Max(1, math.Abs(want)) {
+				t.Errorf("%s: expected the %s of %s to be %.4g, got %q", g, rows[0][j], s.Names[i], want, r[j])
+			}
+		}
+	}
+}
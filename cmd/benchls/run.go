@@ -0,0 +1,65 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// The flags of benchls run, which are only defined for it.
+var (
+	flagRunBench string
+	flagRunCount int
+)
+
+// runName and runOutput are the command run by benchls run and its output,
+// which is read as the input of that name.
+var (
+	runName   string
+	runOutput []byte
+)
+
+// runFlags defines the flags of benchls run.
+func runFlags() {
+	flag.StringVar(&flagRunBench, "bench", ".", "run: regexp of the benchmarks to run, passed to go test")
+	flag.IntVar(&flagRunCount, "count", 1, "run: number of times to run each benchmark, passed to go test")
+}
+
+// goCommand runs the go command with args, and writes its output to stdout
+// and its errors to os.Stderr.
+func goCommand(args []string, stdout io.Writer) error {
+	cmd := exec.Command("go", args...)
+	cmd.Stdout, cmd.Stderr = stdout, os.Stderr
+	return cmd.Run()
+}
+
+// runBenchmarks runs the benchmarks of the packages with go test, by way of
+// goCmd, which is usually goCommand, and keeps its output as the input named
+// runName.
+func runBenchmarks(packages []string, goCmd func(args []string, stdout io.Writer) error) error {
+	if len(packages) == 0 {
+		packages = []string{"."}
+	}
+	if flagRunCount < 1 {
+		return fmt.Errorf("invalid -count %d", flagRunCount)
+	}
+	args := append([]string{"test", "-run=^$", "-bench=" + flagRunBench, "-benchmem", "-count=" + strconv.Itoa(flagRunCount)}, packages...)
+	runName = "go " + strings.Join(args, " ")
+
+	var out bytes.Buffer
+	if err := goCmd(args, &out); err != nil {
+		os.Stderr.Write(out.Bytes())
+		return fmt.Errorf("%s: %v", runName, err)
+	}
+	runOutput = out.Bytes()
+	return nil
+}
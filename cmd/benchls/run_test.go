@@ -0,0 +1,63 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestRunBenchmarks(t *testing.T) {
+	defer func() {
+		flagRunBench, flagRunCount = ".", 1
+		runName, runOutput = "", nil
+	}()
+	const output = "BenchmarkSort10-4   	 1000000	      1008 ns/op\nPASS\n"
+
+	for _, test := range []struct {
+		packages []string
+		bench    string
+		count    int
+		fail     bool
+		want     string // the command, or the error
+	}{
+		{nil, ".", 1, false, "go test -run=^$ -bench=. -benchmem -count=1 ."},
+		{[]string{"sort", "./..."}, "Sort", 5, false, "go test -run=^$ -bench=Sort -benchmem -count=5 sort ./..."},
+		{nil, ".", 0, false, "invalid -count 0"},
+		{[]string{"sort"}, ".", 1, true, "go test -run=^$ -bench=. -benchmem -count=1 sort: exit status 1"},
+	} {
+		flagRunBench, flagRunCount = test.bench, test.count
+		runName, runOutput = "", nil
+		var ran []string
+		err := runBenchmarks(test.packages, func(args []string, stdout io.Writer) error {
+			ran = args
+			if test.fail {
+				return errors.New("exit status 1")
+			}
+			_, err := io.WriteString(stdout, output)
+			return err
+		})
+		switch {
+		case test.count < 1 || test.fail:
+			if err == nil || err.Error() != test.want {
+				t.Errorf("%q: expected the error %q, got %v", test.packages, test.want, err)
+			}
+			if runOutput != nil {
+				t.Errorf("%q: expected no output after an error, got %q", test.packages, runOutput)
+			}
+		case err != nil:
+			t.Errorf("%q: %v", test.packages, err)
+		default:
+			if got := "go " + strings.Join(ran, " "); got != test.want || runName != test.want {
+				t.Errorf("%q: expected to run and name the input %s, ran %s named %s", test.packages, test.want, got, runName)
+			}
+			if string(runOutput) != output {
+				t.Errorf("%q: expected the output %q, got %q", test.packages, output, runOutput)
+			}
+		}
+	}
+}
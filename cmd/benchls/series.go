@@ -0,0 +1,66 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/jonlawlor/benchls"
+)
+
+// seriesInput is one of the -series input files, and its label, like the
+// commit or date it was measured at.
+type seriesInput struct {
+	label string
+	set   benchls.Set
+}
+
+// seriesLabels returns the -label of each input file, which defaults to its
+// name.
+func seriesLabels(files []string) ([]string, error) {
+	if flagLabel == "" {
+		return files, nil
+	}
+	labels := strings.Split(flagLabel, ",")
+	if len(labels) != len(files) {
+		return nil, fmt.Errorf("-label has %d labels for %d input files", len(labels), len(files))
+	}
+	for i, l := range labels {
+		labels[i] = strings.TrimSpace(l)
+	}
+	return labels, nil
+}
+
+// writeSeries writes the fit of each group of each input in long format, one
+// row per label, group and term, in the order of the inputs.  The groups
+// that could not be fit are left out.
+func writeSeries(terms []benchls.Expression, yExpr benchls.Expression, labels []string, fits []map[string]*benchls.Fit, man *manifest, seed int64, w io.Writer) {
+	table := []*row{newRow("label", "group", yExpr.String()+" ~", "coefficient", "ci", "R^2")}
+	for i, label := range labels {
+		groups := make([]string, 0, len(fits[i]))
+		for g, fit := range fits[i] {
+			if fit != nil {
+				groups = append(groups, g)
+			}
+		}
+		sort.Strings(groups)
+		for _, g := range groups {
+			fit := fits[i][g]
+			for j, b := range fit.Model {
+				table = append(table, newRow(label, g, terms[j].String(),
+					fmt.Sprintf("%g", b), fmt.Sprintf("%g", fit.Stats.CI[j]), fmt.Sprintf("%g", fit.Stats.RSquared)))
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	writeHeader(&buf, man, seed)
+	writeTable(&buf, table)
+	w.Write(buf.Bytes())
+}
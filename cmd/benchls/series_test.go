@@ -0,0 +1,84 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/jonlawlor/benchls"
+)
+
+func TestSeriesLabels(t *testing.T) {
+	defer func() { flagLabel = "" }()
+	files := []string{"old.txt", "new.txt"}
+	for _, test := range []struct {
+		label, want, err string
+	}{
+		{"", "old.txt,new.txt", ""},
+		{"v1.0, v1.1", "v1.0,v1.1", ""},
+		{"v1.0", "", "-label has 1 labels for 2 input files"},
+	} {
+		flagLabel = test.label
+		labels, err := seriesLabels(files)
+		if test.err != "" {
+			if err == nil || err.Error() != test.err {
+				t.Errorf("%q: expected the error %q, got %v", test.label, test.err, err)
+			}
+			continue
+		}
+		if err != nil || strings.Join(labels, ",") != test.want {
+			t.Errorf("%q: expected the labels %s, got %q, %v", test.label, test.want, labels, err)
+		}
+	}
+}
+
+func TestWriteSeries(t *testing.T) {
+	xExprs, yExpr, _, fits := testFits(t)
+	// the later input only has BenchmarkFast
+	later := map[string]*benchls.Fit{"BenchmarkFast": fits["BenchmarkFast"]}
+
+	flagFormat = "csv"
+	defer func() { flagFormat = "text" }()
+	var buf bytes.Buffer
+	writeSeries(xExprs, yExpr, []string{"v1.0", "v1.1"}, []map[string]*benchls.Fit{fits, later}, nil, 0, &buf)
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h := strings.Join(rows[0], ","); h != "label,group,Y ~,coefficient,ci,R^2" {
+		t.Errorf("unexpected heading %s", h)
+	}
+	// a row per term of each group that could be fit, in the order of the
+	// inputs and then by group
+	want := [][3]string{
+		{"v1.0", "BenchmarkFast", "N"},
+		{"v1.0", "BenchmarkFast", "1.0"},
+		{"v1.0", "BenchmarkSlow", "N"},
+		{"v1.0", "BenchmarkSlow", "1.0"},
+		{"v1.1", "BenchmarkFast", "N"},
+		{"v1.1", "BenchmarkFast", "1.0"},
+	}
+	if len(rows) != len(want)+1 {
+		t.Fatalf("expected %d rows, got %q", len(want)+1, rows)
+	}
+	for i, w := range want {
+		r := rows[i+1]
+		if r[0] != w[0] || r[1] != w[1] || r[2] != w[2] {
+			t.Errorf("row %d: expected %q, got %q", i+1, w, r)
+			continue
+		}
+		fit := fits[w[1]]
+		j := i % 2
+		for k, v := range []float64{fit.Model[j], fit.Stats.CI[j], fit.Stats.RSquared} {
+			if got, err := strconv.ParseFloat(r[3+k], 64); err != nil || got != v {
+				t.Errorf("row %d: expected column %d to be %g, got %q", i+1, 3+k, v, r[3+k])
+			}
+		}
+	}
+}
@@ -0,0 +1,319 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"html"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jonlawlor/benchls"
+)
+
+// The flags of benchls serve, which are only defined for it.
+var (
+	flagServeListen  string
+	flagServeStore   string
+	flagServeTimeout time.Duration
+)
+
+// maxUpload is the largest benchmark output that benchls serve accepts.
+const maxUpload = 32 << 20
+
+// uploadTime is the layout of the time at the start of each stored upload's
+// file name, which sorts in the order of the uploads.
+const uploadTime = "20060102T150405.000000000"
+
+// serveFlags defines the flags of benchls serve.
+func serveFlags() {
+	flag.StringVar(&flagServeListen, "listen", ":8080", "serve: address to serve the uploads and the dashboard on")
+	flag.StringVar(&flagServeStore, "store", "benchls-uploads", "serve: directory to store the uploaded benchmarks in")
+	flag.DurationVar(&flagServeTimeout, "timeout", time.Minute, "serve: longest time to fit the uploads for a request, or 0 for no limit")
+}
+
+// serve accepts go test output POSTed to /upload, with an optional label
+// parameter like the commit it was measured at, stores each upload in
+// -store, and serves a dashboard of the fits of every upload at /, and the
+// fits of the latest upload as Prometheus gauges at /metrics.  Both fit the
+// uploads with the flags of benchls serve on each request, so that they are
+// always up to date with the stored uploads.  The fits are stopped when the
+// request is canceled or takes longer than -timeout.
+func serve() {
+	if err := os.MkdirAll(flagServeStore, 0777); err != nil {
+		log.Fatal(err)
+	}
+	http.HandleFunc("/upload", upload)
+	http.HandleFunc("/metrics", metrics)
+	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		dashboard(w, r)
+	})
+	log.Printf("serving the dashboard of %s on %s", flagServeStore, flagServeListen)
+	log.Fatal(http.ListenAndServe(flagServeListen, nil))
+}
+
+// unsafeLabel matches the characters that are not kept in an upload's label,
+// which is part of its file name and of the -label flag.
+var unsafeLabel = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// upload stores the benchmarks in the body of a POST request.
+func upload(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		w.Header().Set("Allow", "POST")
+		http.Error(w, "upload benchmarks with POST", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxUpload))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	converted, err := convertInput(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if set, err := benchls.ReadSet(bytes.NewReader(converted)); err != nil || len(set) == 0 {
+		http.Error(w, "the upload has no benchmarks", http.StatusBadRequest)
+		return
+	}
+
+	name := time.Now().UTC().Format(uploadTime)
+	if label := strings.Trim(unsafeLabel.ReplaceAllString(r.FormValue("label"), "-"), "-"); label != "" {
+		name += "-" + label
+	}
+	name += ".txt"
+	// the dashboard must not read a partly written upload
+	tmp, err := ioutil.TempFile(flagServeStore, ".upload")
+	if err == nil {
+		_, err = tmp.Write(body)
+		if cerr := tmp.Close(); err == nil {
+			err = cerr
+		}
+		if err == nil {
+			err = os.Rename(tmp.Name(), filepath.Join(flagServeStore, name))
+		}
+		if err != nil {
+			os.Remove(tmp.Name())
+		}
+	}
+	if err != nil {
+		log.Print(err)
+		http.Error(w, "could not store the upload", http.StatusInternalServerError)
+		return
+	}
+	log.Print("stored ", name)
+	w.WriteHeader(http.StatusCreated)
+	fmt.Fprintln(w, name)
+}
+
+// dashboard writes the fits of the stored uploads, oldest first, as an HTML
+// page.
+func dashboard(w http.ResponseWriter, r *http.Request) {
+	files, err := filepath.Glob(filepath.Join(flagServeStore, "*.txt"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	sort.Strings(files)
+
+	var body bytes.Buffer
+	status := http.StatusOK
+	if len(files) == 0 {
+		fmt.Fprintf(&body, "<p>There are no uploads yet.  POST the output of go test -bench to /upload?label=commit.</p>\n")
+	} else {
+		labels := make([]string, len(files))
+		for i, f := range files {
+			labels[i] = uploadLabel(filepath.Base(f))
+		}
+		ctx, cancel := fitContext(r)
+		defer cancel()
+		if up, err := fitUploads(ctx, files); err != nil {
+			status = fitStatus(err)
+			fmt.Fprintf(&body, "<pre>%s</pre>\n", html.EscapeString(fitError(err)))
+		} else {
+			writeSeries(up.xExprs, up.yExpr, labels, up.fits, nil, 0, &body)
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	fmt.Fprintf(w, "<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>benchls</title>\n</head>\n<body>\n")
+	fmt.Fprintf(w, "<h1>benchls</h1>\n<p>%d uploads in %s</p>\n", len(files), html.EscapeString(flagServeStore))
+	w.Write(body.Bytes())
+	fmt.Fprintf(w, "</body>\n</html>\n")
+}
+
+// metrics writes the fits of the latest upload as Prometheus gauges.
+func metrics(w http.ResponseWriter, r *http.Request) {
+	files, err := filepath.Glob(filepath.Join(flagServeStore, "*.txt"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	if len(files) == 0 {
+		return
+	}
+	sort.Strings(files)
+
+	ctx, cancel := fitContext(r)
+	defer cancel()
+	up, err := fitUploads(ctx, files[len(files)-1:])
+	if err != nil {
+		http.Error(w, fitError(err), fitStatus(err))
+		return
+	}
+	w.Write(promGauges(up.xExprs, up.samps[0], up.fits[0]))
+}
+
+// fitContext returns the context to fit the uploads for r in, which is done
+// when r is canceled or after -timeout.
+func fitContext(r *http.Request) (context.Context, context.CancelFunc) {
+	if flagServeTimeout <= 0 {
+		return context.WithCancel(r.Context())
+	}
+	return context.WithTimeout(r.Context(), flagServeTimeout)
+}
+
+// fitStatus returns the HTTP status of the error of fitUploads.
+func fitStatus(err error) int {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return http.StatusGatewayTimeout
+	}
+	return http.StatusInternalServerError
+}
+
+// fitError returns the message of the error of fitUploads.
+func fitError(err error) string {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Sprintf("the fits took longer than -timeout %v", flagServeTimeout)
+	}
+	return err.Error()
+}
+
+// uploads are the samples and fits of the groups of each upload.
+type uploads struct {
+	xExprs []benchls.Expression
+	yExpr  benchls.Expression
+	samps  []map[string]benchls.Sample
+	fits   []map[string]*benchls.Fit
+}
+
+// The -match and -exclude patterns of benchls serve.
+var serveMatch, serveExclude *regexp.Regexp
+
+// fitUploads reads the uploaded files, and samples and fits the groups of
+// each with the same terms, like benchls -series.  It stops fitting, and
+// returns ctx.Err(), once ctx is done.
+func fitUploads(ctx context.Context, files []string) (*uploads, error) {
+	sets := make([]benchls.Set, len(files))
+	all := make(benchls.Set)
+	for i, name := range files {
+		set, err := readInput(name, nil)
+		if err != nil {
+			return nil, err
+		}
+		sets[i] = set
+		for name, bs := range set {
+			all[name] = append(all[name], bs...)
+		}
+	}
+	units := benchls.Units(all)
+	valid := false
+	for _, y := range append(benchls.Responses, units...) {
+		valid = valid || y == flagYVar
+	}
+	if !valid {
+		return nil, fmt.Errorf("invalid response: %s, the uploads have units %q", flagYVar, units)
+	}
+
+	var ex benchls.Extractor
+	if flagAutoVars {
+		ex = benchls.NewAutoExtractor(all)
+	} else {
+		ex = benchls.RegexpExtractor{Regexp: regexp.MustCompile(flagInputMatch)}
+	}
+	xt := flagXTransform
+	if flagCateg != "" {
+		var err error
+		if ex, xt, err = categorical(ex, all, xt); err != nil {
+			return nil, err
+		}
+	}
+	if flagInteract > 0 {
+		xt = interactions(ex)
+	}
+	for i := range sets {
+		if serveMatch != nil || serveExclude != nil {
+			sets[i] = filterGroups(sets[i], ex, serveMatch, serveExclude)
+		}
+		if flagGroupBy == "" && flagGroup == "" && pkgsCollide(sets[i], ex) {
+			return nil, fmt.Errorf("%s has groups in more than one package, use -group-by=pkg", files[i])
+		}
+	}
+
+	varNames := ex.VarNames()
+	for _, name := range []string{"Y", "BytesPerOp", "TotalBytes"} {
+		if _, exists := varNames[name]; exists {
+			return nil, fmt.Errorf("`%s` is reserved and cannot be used as a named expression in vars", name)
+		}
+	}
+	for _, name := range []string{"P", "BytesPerOp", "TotalBytes"} {
+		varNames[name] = struct{}{}
+	}
+	up := &uploads{}
+	var err error
+	if up.xExprs, err = benchls.NewExpressions(xt, varNames, consts); err != nil {
+		return nil, err
+	}
+	varNames["Y"] = struct{}{}
+	if up.yExpr, err = benchls.NewExpression(flagYTransform, varNames, consts); err != nil {
+		return nil, err
+	}
+
+	for _, set := range sets {
+		samps := sampleGroups(set, ex, up.xExprs, up.yExpr)
+		fits := make(map[string]*benchls.Fit)
+		for g, samp := range samps {
+			if fits[g], _, _, err = fitSampleContext(ctx, samp); err != nil {
+				return nil, err
+			}
+		}
+		up.samps = append(up.samps, samps)
+		up.fits = append(up.fits, fits)
+	}
+	return up, nil
+}
+
+// uploadLabel returns the label of the upload stored in the named file, which
+// is its time and the label it was uploaded with, like
+// "2016-01-02T15:04:05Z commit".
+func uploadLabel(name string) string {
+	name = strings.TrimSuffix(name, ".txt")
+	stamp, label := name, ""
+	if i := strings.Index(name, "-"); i >= 0 {
+		stamp, label = name[:i], name[i+1:]
+	}
+	if t, err := time.Parse(uploadTime, stamp); err == nil {
+		stamp = t.Format("2006-01-02T15:04:05Z")
+	}
+	return strings.TrimSpace(stamp + " " + label)
+}
@@ -0,0 +1,94 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// serveUpload is a go test output with two groups.
+const serveUpload = `
+BenchmarkSort10-4   	 1000000	      1000 ns/op
+BenchmarkSort100-4  	  100000	     10000 ns/op
+BenchmarkSort1000-4 	   10000	    100000 ns/op
+BenchmarkFind10-4   	 1000000	        12 ns/op
+BenchmarkFind100-4  	  100000	       102 ns/op
+BenchmarkFind1000-4 	   10000	      1002 ns/op
+`
+
+func TestServe(t *testing.T) {
+	dir, err := ioutil.TempDir("", "benchls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	flagServeStore, flagHTML = dir, true
+	defer func() { flagServeStore, flagHTML, flagServeTimeout = "benchls-uploads", false, time.Minute }()
+
+	get := func(h http.HandlerFunc, method, target, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h(w, httptest.NewRequest(method, target, strings.NewReader(body)))
+		return w
+	}
+	if w := get(dashboard, "GET", "/", ""); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "There are no uploads yet") {
+		t.Errorf("expected an empty dashboard, got %d %s", w.Code, w.Body)
+	}
+	if w := get(upload, "POST", "/upload?label=v1.0", "PASS\n"); w.Code != http.StatusBadRequest {
+		t.Errorf("expected an upload without benchmarks to fail, got %d %s", w.Code, w.Body)
+	}
+	for _, label := range []string{"v1.0", "v1.1"} {
+		if w := get(upload, "POST", "/upload?label="+label, serveUpload); w.Code != http.StatusCreated {
+			t.Fatalf("%s: expected the upload to be stored, got %d %s", label, w.Code, w.Body)
+		}
+	}
+
+	w := get(dashboard, "GET", "/", "")
+	page := w.Body.String()
+	if w.Code != http.StatusOK || !strings.Contains(page, "<p>2 uploads in ") {
+		t.Fatalf("expected a dashboard of 2 uploads, got %d %s", w.Code, page)
+	}
+	// a row per upload, group and term
+	for _, label := range []string{"v1.0", "v1.1"} {
+		for _, g := range []string{"BenchmarkFind", "BenchmarkSort"} {
+			if n := strings.Count(page, label+"</td><td>"+g+"</td>"); n != 2 {
+				t.Errorf("expected 2 rows of %s in %s, got %d in %s", g, label, n, page)
+			}
+		}
+	}
+
+	w = get(metrics, "GET", "/metrics", "")
+	want := `benchls_coefficient{group="BenchmarkSort",response="NsPerOp",term="N"} 100`
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), want) {
+		t.Errorf("expected the gauges to contain %s, got %d %s", want, w.Code, w.Body)
+	}
+
+	// the fits are stopped by the -timeout
+	flagServeTimeout = time.Nanosecond
+	time.Sleep(time.Millisecond)
+	if w := get(dashboard, "GET", "/", ""); w.Code != http.StatusGatewayTimeout || !strings.Contains(w.Body.String(), "longer than -timeout") {
+		t.Errorf("expected the dashboard to time out, got %d %s", w.Code, w.Body)
+	}
+	if w := get(metrics, "GET", "/metrics", ""); w.Code != http.StatusGatewayTimeout {
+		t.Errorf("expected the gauges to time out, got %d %s", w.Code, w.Body)
+	}
+}
+
+func TestUploadLabel(t *testing.T) {
+	for name, want := range map[string]string{
+		"20160102T150405.000000000-v1.2.txt": "2016-01-02T15:04:05Z v1.2",
+		"20160102T150405.123456789.txt":      "2016-01-02T15:04:05Z",
+		"notatime-label.txt":                 "notatime label",
+	} {
+		if got := uploadLabel(name); got != want {
+			t.Errorf("%s: expected %q, got %q", name, want, got)
+		}
+	}
+}
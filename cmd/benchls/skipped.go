@@ -0,0 +1,82 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/jonlawlor/benchls"
+)
+
+// skip reasons, in the order they are checked
+const (
+	skipVars     = "do not match -vars"
+	skipFiltered = "are left out by -match or -exclude"
+	skipResponse = "do not report the -response"
+)
+
+// skipSummary counts the benchmark results of an input that are sampled, and
+// those that are skipped, by why.
+type skipSummary struct {
+	total, sampled int
+	skipped        map[string]int
+	examples       map[string][]string // the first few names skipped for each reason
+}
+
+// maxSkipExamples is how many names of skipped benchmarks are shown for each
+// reason.
+const maxSkipExamples = 3
+
+// summarizeSkips finds which of the benchmark results in benchSet are sampled
+// for the response flagYVar.
+func summarizeSkips(benchSet benchls.Set, ex benchls.Extractor, match, exclude *regexp.Regexp) skipSummary {
+	sum := skipSummary{skipped: make(map[string]int), examples: make(map[string][]string)}
+	names := make([]string, 0, len(benchSet))
+	for name := range benchSet {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		rs := benchSet[name]
+		sum.total += len(rs)
+		reason := ""
+		group, _, ok := ex.Extract(name)
+		switch {
+		case !ok:
+			reason = skipVars
+		case !selected(group, match, exclude):
+			reason = skipFiltered
+		}
+		for _, res := range rs {
+			why := reason
+			if _, ok := benchls.Response(res, flagYVar); why == "" && !ok {
+				why = skipResponse
+			}
+			if why == "" {
+				sum.sampled++
+				continue
+			}
+			sum.skipped[why]++
+			if seen := sum.examples[why]; len(seen) < maxSkipExamples && (len(seen) == 0 || seen[len(seen)-1] != name) {
+				sum.examples[why] = append(seen, name)
+			}
+		}
+	}
+	return sum
+}
+
+// log logs the summary of the input file name.
+func (sum skipSummary) log(name string) {
+	log.Printf("%s: sampled %d of %d benchmark results", name, sum.sampled, sum.total)
+	for _, why := range []string{skipVars, skipFiltered, skipResponse} {
+		if n := sum.skipped[why]; n > 0 {
+			log.Printf("%s: skipped %d that %s, like %s", name, n, why, strings.Join(sum.examples[why], ", "))
+		}
+	}
+}
@@ -0,0 +1,41 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/jonlawlor/benchls"
+)
+
+func TestSummarizeSkips(t *testing.T) {
+	set, err := benchls.ReadSet(strings.NewReader(filterInput))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ex := benchls.RegexpExtractor{Regexp: regexp.MustCompile(flagInputMatch)}
+	// the summary agrees with the groups that filterGroups keeps
+	for _, c := range filterCases {
+		match, exclude := compileFilter(c.match), compileFilter(c.exclude)
+		sum := summarizeSkips(set, ex, match, exclude)
+		kept := len(filterGroups(set, ex, match, exclude))
+		if sum.total != 5 || sum.sampled != kept || sum.skipped[skipVars] != 1 || sum.skipped[skipFiltered] != 4-kept {
+			t.Errorf("-match=%q -exclude=%q: expected 5 results, %d sampled, 1 without -vars and %d filtered, got %+v", c.match, c.exclude, kept, 4-kept, sum)
+		}
+		if names := sum.examples[skipVars]; len(names) != 1 || names[0] != "BenchmarkNoSize-4" {
+			t.Errorf("-match=%q -exclude=%q: expected BenchmarkNoSize-4 to be skipped for its -vars, got %q", c.match, c.exclude, names)
+		}
+	}
+
+	// the results without the -response are skipped after the others
+	flagYVar = "B/op"
+	defer func() { flagYVar = "NsPerOp" }()
+	sum := summarizeSkips(set, ex, regexp.MustCompile("Sort"), nil)
+	if sum.sampled != 0 || sum.skipped[skipResponse] != 2 || sum.skipped[skipFiltered] != 2 {
+		t.Errorf("expected 2 results without B/op and 2 filtered, got %+v", sum)
+	}
+}
@@ -0,0 +1,384 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// subcommand is a task with a flag set of its own, which is a subset of the
+// flags of the flat command line, possibly under shorter names.  The flags
+// that are not in its set are an error.
+type subcommand struct {
+	usage    string              // the arguments, after the options
+	doc      string              // what it does
+	flags    map[string]string   // the name of each flag in the flat command line
+	define   func()              // defines the flags that only it has
+	mode     string              // a flag that the subcommand sets
+	required []string            // flags of which at least one must be set
+	run      func(args []string) // runs it with the arguments after the options
+}
+
+// The flags of the flat command line that the modes share, by what they are
+// for.
+var (
+	// inputFlags read the inputs, find the groups and variables in them, and
+	// select and transform the response, which every mode does.
+	inputFlags = []string{
+		"input-format", "x-cols", "y-col", "vars", "auto-vars", "match", "exclude", "const",
+		"response", "ytransform", "yt", "confidence", "se", "solver", "format", "manifest", "watch", "verbose", "strict",
+	}
+	// sampleFlags group and aggregate the samples of the modes that fit them.
+	sampleFlags = []string{"group", "group-by", "agg", "response-stat"}
+	// termFlags are the explanatory terms of the modes that fit them.
+	termFlags = []string{"xtransform", "xt", "formula", "categorical", "interactions", "loglog", "semilogy"}
+	// fitFlags are how the modes that fit each group as the report does fit
+	// them.
+	fitFlags = []string{"fit", "weights", "varpower", "repvar"}
+)
+
+// modes are the flags that select a mode of the flat command line other than
+// the report, in the order that cmdFit checks them.
+var modes = []string{"powerlaw", "infer", "candidates", "compare", "series", "breakpoints", "matrix"}
+
+// modeFlags are the flags of each of the modes, besides the flag that selects
+// it.  The report has every flag that is not of a mode only, which are the
+// modes and -ref.
+var modeFlags = map[string][]string{
+	"powerlaw":    flagList(inputFlags, []string{"html"}),
+	"infer":       flagList(inputFlags, []string{"html"}),
+	"candidates":  flagList(inputFlags, []string{"html"}, sampleFlags),
+	"compare":     flagList(inputFlags, []string{"html"}, sampleFlags, termFlags, fitFlags, []string{"load-model"}),
+	"series":      flagList(inputFlags, []string{"html"}, sampleFlags, termFlags, fitFlags, []string{"model", "label"}),
+	"breakpoints": flagList(inputFlags, []string{"html"}, sampleFlags, termFlags),
+	"matrix":      flagList(inputFlags, []string{"html", "agg", "response-stat", "ref"}, termFlags),
+}
+
+// reportFlags returns the flags of the report.
+func reportFlags() []string {
+	var names []string
+	flag.VisitAll(func(f *flag.Flag) {
+		if f.Name != "ref" && modeFlags[f.Name] == nil {
+			names = append(names, f.Name)
+		}
+	})
+	return names
+}
+
+// flagList concatenates lists of flags.
+func flagList(lists ...[]string) []string {
+	var names []string
+	for _, l := range lists {
+		names = append(names, l...)
+	}
+	return names
+}
+
+// mode returns the flag that selects the mode of the command line, or "" for
+// the report.
+func mode() string {
+	used := usedFlags()
+	for _, m := range modes {
+		if used[m] {
+			return m
+		}
+	}
+	return ""
+}
+
+// checkMode returns an error if a flag of the flat command line is not a flag
+// of the mode that it selects, which keeps the modes and their flags apart.
+func checkMode() error {
+	m := mode()
+	valid := map[string]bool{m: true}
+	names := modeFlags[m]
+	if m == "" {
+		names = reportFlags()
+	}
+	for _, name := range names {
+		valid[name] = true
+	}
+	var err error
+	visit(func(f *flag.Flag) {
+		switch {
+		case err != nil || valid[f.Name] || f.Value.String() == f.DefValue:
+		case m == "":
+			// only -ref is not a flag of the report
+			err = fmt.Errorf("-%s needs -matrix", f.Name)
+		default:
+			err = fmt.Errorf("-%s cannot be used with -%s", f.Name, m)
+		}
+	})
+	return err
+}
+
+// reportInputs are the flags of the subcommands that fit the groups and
+// report them with their outputs.
+var reportInputs = flagList(inputFlags, sampleFlags, termFlags, fitFlags, []string{"model", "json", "pool", "xt-for"})
+
+// allFlags is a stand in for every flag of the flat command line except
+// -compare, and checkMode keeps the flags of the mode that they select.
+const allFlags = "*"
+
+var subcommands = map[string]subcommand{
+	"fit": {
+		usage: "bench.txt",
+		doc:   "fits the groups of benchmarks in bench.txt, like benchls without a subcommand",
+		flags: map[string]string{allFlags: allFlags},
+		run:   cmdFit,
+	},
+	"run": {
+		usage:  "[packages]",
+		doc:    "runs the benchmarks of the packages with go test, and fits them",
+		flags:  map[string]string{allFlags: allFlags, "bench": "bench", "count": "count"},
+		define: runFlags,
+		run:    cmdRun,
+	},
+	"compare": {
+		usage: "old.txt new.txt",
+		doc:   "fits the same model to both inputs, or to new.txt and the fits of -load-model, and reports the change in each coefficient",
+		flags: flatFlags(modeFlags["compare"], nil),
+		mode:  "compare",
+		run:   cmdCompare,
+	},
+	"predict": {
+		usage:    "bench.txt",
+		doc:      "fits the groups of benchmarks in bench.txt, or loads fits saved with -save-model, and predicts their responses",
+		flags:    flatFlags(reportInputs, map[string]string{"at": "predict", "load-model": "load-model", "crossover": "crossover", "back": "back", "smear": "smear", "widen": "widen"}),
+		required: []string{"at"},
+		run:      cmdReport,
+	},
+	"plot": {
+		usage:    "bench.txt",
+		doc:      "fits the groups of benchmarks in bench.txt and plots them",
+		flags:    flatFlags(reportInputs, map[string]string{"dir": "plot", "ext": "plotext", "log": "plotlog", "heatmap": "heatmap", "html-report": "html-report"}),
+		required: []string{"dir", "heatmap", "html-report"},
+		run:      cmdReport,
+	},
+	"serve": {
+		doc: "stores the benchmarks POSTed to /upload, and serves a dashboard of their fits over time at /",
+		// the uploads are fit like benchls -series, with terms that are set
+		// up for the variables of each request's uploads, and shown in HTML
+		flags: flatFlags(flagList(
+			[]string{"input-format", "x-cols", "y-col", "vars", "auto-vars", "match", "exclude", "const", "response", "ytransform", "yt", "confidence", "se", "solver"},
+			sampleFlags,
+			[]string{"xtransform", "xt", "formula", "categorical", "interactions", "fit", "varpower", "repvar"},
+		), map[string]string{"listen": "listen", "store": "store", "timeout": "timeout"}),
+		define: serveFlags,
+		run:    cmdServe,
+	},
+	"check": {
+		usage:    "bench.txt",
+		doc:      "fits the groups of benchmarks in bench.txt and fails with exit status 1 if a fit violates a threshold",
+		flags:    flatFlags(reportInputs, map[string]string{"check": "check", "check-file": "check-file"}),
+		required: []string{"check", "check-file"},
+		run:      cmdReport,
+	},
+}
+
+// flatFlags returns the flags of a subcommand, which are those of names under
+// their flat names, and those of renamed.
+func flatFlags(names []string, renamed map[string]string) map[string]string {
+	flags := make(map[string]string)
+	for _, n := range names {
+		flags[n] = n
+	}
+	for n, flat := range renamed {
+		flags[n] = flat
+	}
+	return flags
+}
+
+// cmdFit runs benchls fit, or benchls without a subcommand, in the mode that
+// the flags select.
+func cmdFit(args []string) {
+	j := prepare(args)
+	switch {
+	case flagPowerLaw:
+		j.powerLaw()
+	case flagInfer:
+		j.infer()
+	case flagCands != "":
+		j.candidates()
+	case flagCompare:
+		j.compare()
+	case flagSeries:
+		j.series()
+	case flagBreaks > 0:
+		j.breakpoints()
+	case flagMatrix:
+		j.matrix()
+	default:
+		j.report()
+	}
+}
+
+// cmdRun runs benchls run, which fits the output of the benchmarks it runs.
+func cmdRun(args []string) {
+	if flagSeries || flagLoadModel != "" || flagWatch {
+		log.Fatal("run cannot be used with -series, -load-model or -watch")
+	}
+	if err := runBenchmarks(args, goCommand); err != nil {
+		log.Fatal(err)
+	}
+	cmdFit([]string{runName})
+}
+
+// cmdCompare runs benchls compare.
+func cmdCompare(args []string) {
+	prepare(args).compare()
+}
+
+// cmdReport runs benchls predict, plot and check, which report the fits with
+// the outputs their flags add.
+func cmdReport(args []string) {
+	prepare(args).report()
+}
+
+// cmdServe runs benchls serve.
+func cmdServe(args []string) {
+	if len(args) != 0 {
+		log.Fatal("serve takes no input files, they are uploaded")
+	}
+	if flagFit == "nls" {
+		log.Fatal("serve cannot be used with -fit=nls")
+	}
+	if strings.Contains(flagYVar, ",") {
+		log.Fatal("serve needs a single -response")
+	}
+	if _, err := regexp.Compile(flagInputMatch); err != nil {
+		log.Fatal("invalid -vars: ", err)
+	}
+	_, serveMatch, serveExclude = checkFlags()
+	if flagConst != "" {
+		var err error
+		if consts, err = parseConsts(flagConst); err != nil {
+			log.Fatal(err)
+		}
+	}
+	flagHTML = true
+	serve()
+}
+
+// cmdline is the flag set that the command line was parsed with, the flat
+// flag.CommandLine or that of a subcommand, and flatNames the name in the
+// flat command line of each of its flags that is named differently.
+var (
+	cmdline   = flag.CommandLine
+	flatNames map[string]string
+)
+
+// visit calls fn for each flag that was set on the command line, by its
+// name in the flat command line.
+func visit(fn func(*flag.Flag)) {
+	cmdline.Visit(func(f *flag.Flag) {
+		if flat, ok := flatNames[f.Name]; ok {
+			f = flag.Lookup(flat)
+		}
+		fn(f)
+	})
+}
+
+// newFlagSet returns the flag set of the named subcommand.  Its flags share
+// the values of the flags of the flat command line that they stand for, so
+// that setting either sets both.
+func newFlagSet(name string) *flag.FlagSet {
+	sc := subcommands[name]
+	if sc.define != nil {
+		sc.define()
+	}
+	names := make(map[string]string)
+	for n, flat := range sc.flags {
+		if n != allFlags {
+			names[n] = flat
+			continue
+		}
+		flag.VisitAll(func(f *flag.Flag) {
+			if f.Name != "compare" {
+				names[f.Name] = f.Name
+			}
+		})
+	}
+
+	fs := flag.NewFlagSet("benchls "+name, flag.ExitOnError)
+	for n, flat := range names {
+		fl := flag.Lookup(flat)
+		fs.Var(fl.Value, n, fl.Usage)
+		fs.Lookup(n).DefValue = fl.DefValue
+	}
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "%s\n", strings.TrimSpace("usage: benchls "+name+" [options] "+sc.usage))
+		fmt.Fprintf(os.Stderr, "%s\n", sc.doc)
+		fmt.Fprintf(os.Stderr, "options:\n")
+		fs.PrintDefaults()
+		os.Exit(2)
+	}
+	return fs
+}
+
+// parseSubcommand parses the flags of the named subcommand, which become
+// those of the command line, and returns its arguments.
+func parseSubcommand(name string, args []string) []string {
+	sc := subcommands[name]
+	fs := newFlagSet(name)
+	fs.Parse(args)
+
+	if sc.mode != "" {
+		// the mode is not a flag of the subcommand, but is set as if it
+		// were, so that it is visited like the flat flag
+		fl := flag.Lookup(sc.mode)
+		fs.Var(fl.Value, sc.mode, fl.Usage)
+		fs.Set(sc.mode, "true")
+	}
+	flatNames = make(map[string]string)
+	for n, flat := range sc.flags {
+		if n != flat {
+			flatNames[n] = flat
+		}
+	}
+	cmdline = fs
+
+	if len(sc.required) > 0 {
+		set := false
+		fs.Visit(func(f *flag.Flag) {
+			for _, r := range sc.required {
+				set = set || f.Name == r
+			}
+		})
+		if !set {
+			log.Fatalf("benchls %s needs -%s", name, joinOr(sc.required))
+		}
+	}
+	return fs.Args()
+}
+
+// subcommandNames returns the names of the subcommands, in order.
+func subcommandNames() []string {
+	names := make([]string, 0, len(subcommands))
+	for name := range subcommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// joinOr joins names like "a, -b or -c".
+func joinOr(names []string) string {
+	s := names[0]
+	for i, n := range names[1:] {
+		if i == len(names)-2 {
+			s += " or -" + n
+		} else {
+			s += ", -" + n
+		}
+	}
+	return s
+}
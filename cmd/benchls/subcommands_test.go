@@ -0,0 +1,122 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"flag"
+	"strings"
+	"testing"
+)
+
+func TestNewFlagSet(t *testing.T) {
+	fs := newFlagSet("predict")
+	var buf bytes.Buffer
+	fs.SetOutput(&buf)
+	fs.PrintDefaults()
+	out := buf.String()
+	for _, want := range []string{"-at string\n", "-widen\n", "-confidence float\n", "(default 0.95)", `(default "text")`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected the defaults to include %q, got\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "(default false)") {
+		t.Errorf("expected no default for a false bool flag, got\n%s", out)
+	}
+	for _, name := range []string{"matrix", "html", "listen"} {
+		if fs.Lookup(name) != nil {
+			t.Errorf("expected only the flags of predict, got -%s", name)
+		}
+	}
+	if fs := newFlagSet("compare"); fs.Lookup("json") != nil || fs.Lookup("load-model") == nil {
+		t.Errorf("expected compare to have -load-model but not -json")
+	}
+}
+
+func TestCheckMode(t *testing.T) {
+	for _, c := range []struct {
+		args []string
+		err  string
+	}{
+		{[]string{"-json", "-xt=N, 1.0"}, ""},
+		{[]string{"-infer", "-vars=/N=%d"}, ""},
+		{[]string{"-matrix", "-ref=linux/amd64"}, ""},
+		{[]string{"-infer", "-json"}, "-json cannot be used with -infer"},
+		{[]string{"-compare", "-infer"}, "-compare cannot be used with -infer"},
+		{[]string{"-candidates=N, 1.0", "-xt=N"}, "-xt cannot be used with -candidates"},
+		{[]string{"-ref=linux/amd64"}, "-ref needs -matrix"},
+	} {
+		restore := parseFlags(t, c.args...)
+		err := checkMode()
+		restore()
+		switch {
+		case c.err == "" && err != nil:
+			t.Errorf("%q: unexpected error %v", c.args, err)
+		case c.err != "" && (err == nil || err.Error() != c.err):
+			t.Errorf("%q: expected error %q, got %v", c.args, c.err, err)
+		}
+	}
+}
+
+func TestParseSubcommand(t *testing.T) {
+	defer func() {
+		cmdline, flatNames = flag.CommandLine, nil
+		flagPredict, flagConfidence, flagCompare = "", 0.95, false
+	}()
+
+	args := parseSubcommand("predict", []string{"-at", "N=1e8", "-confidence", "0.9", "bench.txt"})
+	if len(args) != 1 || args[0] != "bench.txt" {
+		t.Errorf("expected the argument bench.txt, got %q", args)
+	}
+	if flagPredict != "N=1e8" || flagConfidence != 0.9 {
+		t.Errorf("expected -predict N=1e8 and -confidence 0.9, got %q and %g", flagPredict, flagConfidence)
+	}
+	var set []string
+	visit(func(f *flag.Flag) { set = append(set, f.Name) })
+	if strings.Join(set, " ") != "predict confidence" {
+		t.Errorf("expected -predict and -confidence to be visited, got %q", set)
+	}
+
+	parseSubcommand("compare", []string{"old.txt", "new.txt"})
+	set = set[:0]
+	visit(func(f *flag.Flag) { set = append(set, f.Name) })
+	if !flagCompare || strings.Join(set, " ") != "compare" {
+		t.Errorf("expected compare to set -compare, got %v and %q", flagCompare, set)
+	}
+}
+
+// parseFlags parses args with a flag set of all of the flat flags, which
+// becomes that of the command line until the returned function is called.
+func parseFlags(t *testing.T, args ...string) func() {
+	fs := flag.NewFlagSet("benchls", flag.ContinueOnError)
+	flag.VisitAll(func(f *flag.Flag) {
+		fs.Var(f.Value, f.Name, f.Usage)
+		fs.Lookup(f.Name).DefValue = f.DefValue
+	})
+	if err := fs.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+	cmdline = fs
+	return func() {
+		fs.Visit(func(f *flag.Flag) { f.Value.Set(f.DefValue) })
+		cmdline = flag.CommandLine
+	}
+}
+
+func TestUsedFlags(t *testing.T) {
+	defer parseFlags(t, "-fit=nls", "-breakpoints=0", "-json", "-xt=N, 1.0")()
+	used := usedFlags()
+	for _, name := range []string{"fit", "fit=nls", "json", "json=true"} {
+		if !used[name] {
+			t.Errorf("expected %s to be used", name)
+		}
+	}
+	// set to their defaults
+	for _, name := range []string{"breakpoints", "xt", "fit=ols", "html"} {
+		if used[name] {
+			t.Errorf("expected %s not to be used", name)
+		}
+	}
+}
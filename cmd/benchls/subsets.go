@@ -0,0 +1,55 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/jonlawlor/benchls"
+)
+
+// writeSubsets writes the subset of the -candidates that fits each group
+// best, along with the runner up and how much worse its BIC is.
+func writeSubsets(cands []benchls.Expression, yExpr benchls.Expression, subsets map[string][]benchls.Subset, man *manifest, seed int64, w io.Writer) {
+	groups := make([]string, 0, len(subsets))
+	for g := range subsets {
+		groups = append(groups, g)
+	}
+	sort.Strings(groups)
+
+	terms := func(sub benchls.Subset) string {
+		ts := make([]string, len(sub.Terms))
+		for i, j := range sub.Terms {
+			ts[i] = cands[j].String()
+		}
+		return strings.Join(ts, ", ")
+	}
+	table := []*row{newRow("group \\ "+yExpr.String()+" ~", "best", "R^2", "next best", "ΔBIC")}
+	for _, g := range groups {
+		ss := subsets[g]
+		if len(ss) == 0 {
+			table = append(table, newRow(g, "~", "~", "~", "~"))
+			continue
+		}
+		r := newRow(g, terms(ss[0]), fmt.Sprintf("%g", ss[0].Fit.Stats.RSquared))
+		if len(ss) > 1 {
+			r.add(terms(ss[1]))
+			r.add(fmt.Sprintf("%.1f", ss[1].Fit.Stats.BIC-ss[0].Fit.Stats.BIC))
+		} else {
+			r.add("~")
+			r.add("~")
+		}
+		table = append(table, r)
+	}
+
+	var buf bytes.Buffer
+	writeHeader(&buf, man, seed)
+	writeTable(&buf, table)
+	w.Write(buf.Bytes())
+}
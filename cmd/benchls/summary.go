@@ -0,0 +1,103 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/jonlawlor/benchls"
+)
+
+// summaries describes the fit of each group that could be fit in a sentence,
+// in the -sort order.  The first group, or the -baseline, is described by its
+// leading coefficient, like "Sort is 22.5 ns per N·log(N)", and the others
+// relative to it, like "StableSort is ~3.95× Sort per N·log(N)".  The terms
+// that are not significant are named after that.
+func summaries(terms []benchls.Expression, yExpr benchls.Expression, fits map[string]*benchls.Fit) []string {
+	groups := sortedGroups(fits, flagSort)
+	ref := flagBaseline
+	if ref == "" {
+		for _, g := range groups {
+			if fits[g] != nil {
+				ref = g
+				break
+			}
+		}
+	}
+	if fits[ref] == nil {
+		return nil
+	}
+	// the leading term is the first that is not constant
+	lead := -1
+	for i, x := range terms {
+		if !constantTerm(x) {
+			lead = i
+			break
+		}
+	}
+
+	var sums []string
+	for _, g := range groups {
+		fit := fits[g]
+		if fit == nil {
+			continue
+		}
+		var s string
+		switch {
+		case lead < 0:
+			s = fmt.Sprintf("%s is %s", prose(g), summaryNumber(fit.Model[0], yExpr))
+		case g == ref:
+			s = fmt.Sprintf("%s is %s per %s", prose(g), summaryNumber(fit.Model[lead], yExpr), eqTerm(terms[lead].String()))
+		default:
+			r := benchls.Ratios(fits[ref], fit, fitOpts)[lead]
+			s = fmt.Sprintf("%s is ~%s× %s per %s", prose(g), strconv.FormatFloat(r.Value, 'g', 3, 64), prose(ref), eqTerm(terms[lead].String()))
+			if math.Abs(r.Value-1) <= r.CI {
+				s += ", which is not significantly different"
+			}
+		}
+
+		var insig []string
+		for i, x := range terms {
+			if fit.Stats.P[i] >= 1-flagConfidence {
+				if constantTerm(x) {
+					insig = append(insig, "the intercept")
+				} else {
+					insig = append(insig, eqTerm(x.String()))
+				}
+			}
+		}
+		switch len(insig) {
+		case 0:
+		case 1:
+			s += "; " + insig[0] + " is not significant"
+		default:
+			s += "; " + strings.Join(insig[:len(insig)-1], ", ") + " and " + insig[len(insig)-1] + " are not significant"
+		}
+		sums = append(sums, s)
+	}
+	return sums
+}
+
+// prose returns a group name as it is written in a summary, without the
+// Benchmark prefix.
+func prose(g string) string {
+	if name := strings.TrimPrefix(g, "Benchmark"); name != "" {
+		return name
+	}
+	return g
+}
+
+// summaryNumber formats a coefficient to 3 digits with an SI prefix, and the
+// unit of the response if it is known, like 22.5 ns.
+func summaryNumber(b float64, yExpr benchls.Expression) string {
+	s := engineering(b, 3, true)
+	if yExpr.String() == "Y" {
+		s += " " + responseUnit(flagYVar)
+	}
+	return s
+}
@@ -0,0 +1,40 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jonlawlor/benchls"
+)
+
+func TestSummaries(t *testing.T) {
+	xExprs, yExpr, _, fits := testFits(t)
+	defer func() { flagBaseline, flagSort = "", "name" }()
+	check := func(name string, fits map[string]*benchls.Fit, want []string) {
+		if got := summaries(xExprs, yExpr, fits); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: expected\n%q\ngot\n%q", name, want, got)
+		}
+	}
+
+	// the first group is the reference, and the groups that cannot be fit
+	// are left out
+	check("name", fits, []string{"Fast is 3.00 ns per N", "Slow is ~2× Fast per N"})
+	flagSort = "coef"
+	check("coef", fits, []string{"Slow is 6.00 ns per N", "Fast is ~0.5× Slow per N"})
+	flagSort, flagBaseline = "name", "BenchmarkSlow"
+	check("baseline", fits, []string{"Fast is ~0.5× Slow per N", "Slow is 6.00 ns per N"})
+	flagBaseline = ""
+
+	// a copy of Fast is as fast, and its terms are not significant
+	same := *fits["BenchmarkFast"]
+	same.Stats.P = []float64{0.2, 0.3}
+	check("same", map[string]*benchls.Fit{"BenchmarkFast": fits["BenchmarkFast"], "BenchmarkSame": &same}, []string{
+		"Fast is 3.00 ns per N",
+		"Same is ~1× Fast per N, which is not significantly different; N and the intercept are not significant",
+	})
+	check("none", map[string]*benchls.Fit{"BenchmarkOne": nil}, nil)
+}
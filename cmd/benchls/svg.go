@@ -0,0 +1,194 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/jonlawlor/benchls"
+)
+
+// The HTML report draws its plots as inline SVG, so that each observation
+// has the name of its benchmark as a tooltip.
+
+// plot dimensions, in pixels
+const (
+	plotWidth, plotHeight                    = 640, 480
+	plotLeft, plotRight, plotTop, plotBottom = 80, 20, 40, 50
+	plotInnerWidth                           = plotWidth - plotLeft - plotRight
+	plotInnerHeight                          = plotHeight - plotTop - plotBottom
+)
+
+// axis maps data values on to pixels, linearly or by their logarithm.
+type axis struct {
+	lo, hi float64 // in data units, or their logarithms
+	log    bool
+	px     float64 // length in pixels
+}
+
+func newAxis(vs []float64, log bool, px float64) axis {
+	a := axis{lo: math.Inf(1), hi: math.Inf(-1), log: log, px: px}
+	for _, v := range vs {
+		if t, ok := a.transform(v); ok {
+			a.lo = math.Min(a.lo, t)
+			a.hi = math.Max(a.hi, t)
+		}
+	}
+	if a.hi <= a.lo {
+		a.lo, a.hi = a.lo-1, a.hi+1
+	}
+	return a
+}
+
+// transform returns false for values that cannot be drawn.
+func (a axis) transform(v float64) (float64, bool) {
+	if a.log {
+		if v <= 0 {
+			return 0, false
+		}
+		v = math.Log10(v)
+	}
+	return v, !math.IsNaN(v) && !math.IsInf(v, 0)
+}
+
+// pos returns the distance in pixels of v from the start of the axis.
+func (a axis) pos(v float64) (float64, bool) {
+	t, ok := a.transform(v)
+	return (t - a.lo) / (a.hi - a.lo) * a.px, ok
+}
+
+// ticks returns tick values: powers of ten on a log axis, and otherwise
+// multiples of 1, 2 or 5 times a power of ten.
+func (a axis) ticks() []float64 {
+	var ts []float64
+	if a.log {
+		for e := math.Ceil(a.lo); e <= a.hi; e++ {
+			ts = append(ts, math.Pow(10, e))
+		}
+		return ts
+	}
+	step := math.Pow(10, math.Floor(math.Log10((a.hi-a.lo)/5)))
+	for _, m := range []float64{1, 2, 5, 10} {
+		if (a.hi-a.lo)/(m*step) <= 6 {
+			step *= m
+			break
+		}
+	}
+	for v := math.Ceil(a.lo/step) * step; v <= a.hi; v += step {
+		ts = append(ts, v)
+	}
+	return ts
+}
+
+// pixels returns a function from data values to pixel coordinates in the
+// plot area, which also reports whether the point can be drawn.
+func pixels(xa, ya axis) func(x, y float64) (float64, float64, bool) {
+	return func(x, y float64) (float64, float64, bool) {
+		dx, okx := xa.pos(x)
+		dy, oky := ya.pos(y)
+		return plotLeft + dx, plotTop + plotInnerHeight - dy, okx && oky
+	}
+}
+
+// writeFrame opens an SVG plot, and draws its title, axes and labels.
+func writeFrame(w io.Writer, title, xLabel, yLabel string, xa, ya axis) {
+	px := pixels(xa, ya)
+	fmt.Fprintf(w, "<svg xmlns='http://www.w3.org/2000/svg' width='%d' height='%d' font-family='sans-serif' font-size='12'>\n", plotWidth, plotHeight)
+	fmt.Fprintf(w, "<rect width='100%%' height='100%%' fill='white'/>\n")
+	fmt.Fprintf(w, "<text x='%d' y='%d' text-anchor='middle' font-size='14'>%s</text>\n", plotWidth/2, plotTop/2, html.EscapeString(title))
+	fmt.Fprintf(w, "<rect x='%d' y='%d' width='%d' height='%d' fill='none' stroke='black'/>\n", plotLeft, plotTop, plotInnerWidth, plotInnerHeight)
+
+	for _, t := range xa.ticks() {
+		x, _, _ := px(t, math.NaN())
+		fmt.Fprintf(w, "<line x1='%.1f' y1='%d' x2='%.1f' y2='%d' stroke='black'/>", x, plotTop+plotInnerHeight, x, plotTop+plotInnerHeight+5)
+		fmt.Fprintf(w, "<text x='%.1f' y='%d' text-anchor='middle'>%g</text>\n", x, plotTop+plotInnerHeight+18, t)
+	}
+	for _, t := range ya.ticks() {
+		_, y, _ := px(math.NaN(), t)
+		fmt.Fprintf(w, "<line x1='%d' y1='%.1f' x2='%d' y2='%.1f' stroke='black'/>", plotLeft-5, y, plotLeft, y)
+		fmt.Fprintf(w, "<text x='%d' y='%.1f' text-anchor='end' dominant-baseline='middle'>%.3g</text>\n", plotLeft-8, y, t)
+	}
+	fmt.Fprintf(w, "<text x='%d' y='%d' text-anchor='middle'>%s</text>\n", plotLeft+plotInnerWidth/2, plotHeight-10, html.EscapeString(xLabel))
+	fmt.Fprintf(w, "<text transform='translate(15 %d) rotate(-90)' text-anchor='middle'>%s</text>\n", plotTop+plotInnerHeight/2, html.EscapeString(yLabel))
+}
+
+// residualSVG draws the residuals of m against the fitted values of s.
+func residualSVG(w io.Writer, group string, s benchls.Sample, m benchls.Model) {
+	res, _ := benchls.Standardized(m, s)
+	fitted := make([]float64, len(res))
+	for i, y := range s.Y {
+		fitted[i] = y - res[i]
+	}
+	xa := newAxis(fitted, false, plotInnerWidth)
+	ya := newAxis(append([]float64{0}, res...), false, plotInnerHeight)
+	px := pixels(xa, ya)
+	writeFrame(w, group+" residuals", "fitted", "residual", xa, ya)
+
+	x0, y0, _ := px(xa.lo, 0)
+	x1, _, _ := px(xa.hi, 0)
+	fmt.Fprintf(w, "<line x1='%.1f' y1='%.1f' x2='%.1f' y2='%.1f' stroke='gray' stroke-dasharray='4'/>\n", x0, y0, x1, y0)
+	for i, r := range res {
+		if x, y, ok := px(fitted[i], r); ok {
+			fmt.Fprintf(w, "<circle cx='%.1f' cy='%.1f' r='3' fill='firebrick'><title>%s</title></circle>\n", x, y, html.EscapeString(s.Names[i]))
+		}
+	}
+	fmt.Fprintf(w, "</svg>\n")
+}
+
+// plotSVG draws the observations of s and the curve of m against the input
+// variable v.
+func plotSVG(w io.Writer, group, v string, xExprs []benchls.Expression, yExpr benchls.Expression, s benchls.Sample, m benchls.Model, logScale bool) {
+	f := curve(v, xExprs, s, m)
+	lo, hi := s.Min[v], s.Max[v]
+	curveX := make([]float64, plotCurvePoints)
+	curveY := make([]float64, plotCurvePoints)
+	for i := range curveX {
+		t := float64(i) / (plotCurvePoints - 1)
+		if logScale && lo > 0 {
+			curveX[i] = lo * math.Pow(hi/lo, t)
+		} else {
+			curveX[i] = lo + (hi-lo)*t
+		}
+		curveY[i] = f(curveX[i])
+	}
+	obsX := make([]float64, len(s.Y))
+	for i := range s.Y {
+		obsX[i] = s.Vars[i][v]
+	}
+
+	xa := newAxis(append(obsX, curveX...), logScale, plotInnerWidth)
+	ya := newAxis(append(append([]float64(nil), s.Y...), curveY...), logScale, plotInnerHeight)
+	px := pixels(xa, ya)
+	writeFrame(w, group, v, yExpr.String(), xa, ya)
+
+	// the fitted curve is broken wherever it cannot be drawn
+	var seg []string
+	flush := func() {
+		if len(seg) > 1 {
+			fmt.Fprintf(w, "<polyline points='%s' fill='none' stroke='steelblue' stroke-width='2'/>\n", strings.Join(seg, " "))
+		}
+		seg = seg[:0]
+	}
+	for i := range curveX {
+		x, y, ok := px(curveX[i], curveY[i])
+		if !ok {
+			flush()
+			continue
+		}
+		seg = append(seg, fmt.Sprintf("%.1f,%.1f", x, y))
+	}
+	flush()
+
+	for i, y := range s.Y {
+		if x, y, ok := px(obsX[i], y); ok {
+			fmt.Fprintf(w, "<circle cx='%.1f' cy='%.1f' r='3' fill='firebrick'><title>%s</title></circle>\n", x, y, html.EscapeString(s.Names[i]))
+		}
+	}
+	fmt.Fprintf(w, "</svg>\n")
+}
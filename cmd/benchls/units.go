@@ -0,0 +1,63 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"strings"
+
+	"github.com/jonlawlor/benchls"
+)
+
+// responseUnits are the units of the benchmark fields, per op.
+var responseUnits = map[string]string{
+	"NsPerOp":           "ns",
+	"AllocedBytesPerOp": "B",
+	"AllocsPerOp":       "allocs",
+	"MBPerS":            "MB/s",
+	"BytesPerOp":        "B",
+}
+
+// responseUnit returns the unit of the response y, which is one of the
+// benchmark fields or the unit of a metric, like "cachemisses/op".
+func responseUnit(y string) string {
+	if u, ok := responseUnits[y]; ok {
+		return u
+	}
+	return strings.TrimSuffix(y, "/op")
+}
+
+// coefUnits returns the unit of the coefficient of each term, which is the
+// unit of the response divided by the term, like "ns/N", or just the unit of
+// the response for a constant term.  Units are only known for an
+// untransformed response, so it returns nil if there is a -ytransform.
+func coefUnits(xExprs []benchls.Expression, yExpr benchls.Expression) []string {
+	if yExpr.String() != "Y" {
+		return nil
+	}
+	y := responseUnit(flagYVar)
+	units := make([]string, len(xExprs))
+	for i, x := range xExprs {
+		switch t := x.String(); {
+		case constantTerm(x):
+			units[i] = y
+		case strings.ContainsAny(t, " +-*/"):
+			units[i] = y + "/(" + t + ")"
+		default:
+			units[i] = y + "/" + t
+		}
+	}
+	return units
+}
+
+// constantTerm reports whether x uses none of the input variables, like the
+// intercept 1.0.
+func constantTerm(x benchls.Expression) bool {
+	constant := true
+	_, err := x.GoSource(func(v string) string {
+		constant = false
+		return v
+	})
+	return err == nil && constant
+}
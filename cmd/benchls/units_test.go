@@ -0,0 +1,45 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jonlawlor/benchls"
+)
+
+func TestCoefUnits(t *testing.T) {
+	names := map[string]struct{}{"N": {}, "M": {}}
+	consts := map[string]float64{"K": 64}
+	xExprs, err := benchls.NewExpressions("N, N*math.Log2(N), math.Log(N), N*M, N + M, K, 1.0", names, consts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names["Y"] = struct{}{}
+	defer func() { flagYVar = "NsPerOp" }()
+	for _, c := range []struct {
+		yVar, yt string
+		want     []string
+	}{
+		// a monomial divides the unit of the response, other terms are in
+		// parentheses, and constant terms have the unit of the response
+		{"NsPerOp", "Y", []string{"ns/N", "ns/(N * math.Log2(N))", "ns/math.Log(N)", "ns/(N * M)", "ns/(N + M)", "ns", "ns"}},
+		{"AllocedBytesPerOp", "Y", []string{"B/N", "B/(N * math.Log2(N))", "B/math.Log(N)", "B/(N * M)", "B/(N + M)", "B", "B"}},
+		{"cachemisses/op", "Y", []string{"cachemisses/N", "cachemisses/(N * math.Log2(N))", "cachemisses/math.Log(N)", "cachemisses/(N * M)", "cachemisses/(N + M)", "cachemisses", "cachemisses"}},
+		// the units of a transformed response are unknown
+		{"NsPerOp", "math.Log(Y)", nil},
+	} {
+		yExpr, err := benchls.NewExpression(c.yt, names, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		flagYVar = c.yVar
+		got := coefUnits(xExprs, yExpr)
+		if strings.Join(got, ", ") != strings.Join(c.want, ", ") || (got == nil) != (c.want == nil) {
+			t.Errorf("%s of %s: expected %q, got %q", c.yt, c.yVar, c.want, got)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// watchInterval is how often -watch checks the input files for changes.
+const watchInterval = time.Second
+
+// watch runs benchls without -watch whenever the files matching the input
+// patterns change, until it is interrupted.  The patterns are expanded on
+// every run, so that a directory glob picks up new files.
+func watch(flags, patterns []string) {
+	poll := time.NewTicker(watchInterval)
+	defer poll.Stop()
+	state := func() ([]string, string) { return inputState(patterns) }
+	watchChanges(state, poll.C, func(files []string) {
+		if len(files) == 0 {
+			log.Printf("no input files match %q", patterns)
+			return
+		}
+		cmd := exec.Command(os.Args[0], append(flags, files...)...)
+		cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+		// the run reports its own errors, and the next change may fix them
+		cmd.Run()
+	})
+}
+
+// watchChanges calls run with the files that state returns, and then again
+// whenever their state has changed at a tick of poll, until poll is closed.
+func watchChanges(state func() ([]string, string), poll <-chan time.Time, run func(files []string)) {
+	files, last := state()
+	run(files)
+	for range poll {
+		files, s := state()
+		if s == last {
+			continue
+		}
+		last = s
+		fmt.Println()
+		log.Print("the input changed at ", time.Now().Format(time.Kitchen))
+		run(files)
+	}
+}
+
+// inputState returns the files matching the patterns, in order, and a
+// description of their names, sizes and modification times that changes
+// when any of them does.
+func inputState(patterns []string) ([]string, string) {
+	var files, state []string
+	for _, p := range patterns {
+		matches, err := filepath.Glob(p)
+		if err != nil {
+			log.Fatal(err)
+		}
+		sort.Strings(matches)
+		for _, name := range matches {
+			files = append(files, name)
+			if fi, err := os.Stat(name); err == nil {
+				state = append(state, fmt.Sprintf("%s %d %d", name, fi.Size(), fi.ModTime().UnixNano()))
+			}
+		}
+	}
+	return files, strings.Join(state, "\n")
+}
+
+// withoutWatch returns the command line flags, before the input files,
+// without -watch.
+func withoutWatch(args []string, inputs int) []string {
+	var flags []string
+	for _, a := range args[:len(args)-inputs] {
+		name := strings.TrimLeft(a, "-")
+		if strings.HasPrefix(a, "-") && (name == "watch" || strings.HasPrefix(name, "watch=")) {
+			continue
+		}
+		flags = append(flags, a)
+	}
+	return flags
+}
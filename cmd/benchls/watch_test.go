@@ -0,0 +1,101 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWatchChanges(t *testing.T) {
+	// the state of the inputs before the first run, and at each tick
+	states := []struct{ files, state string }{
+		{"a.txt", "1"},
+		{"a.txt", "1"},
+		{"a.txt", "2"},
+		{"a.txt b.txt", "3"},
+		{"a.txt b.txt", "3"},
+		{"", ""},
+	}
+	poll := make(chan time.Time, len(states)-1)
+	for range states[1:] {
+		poll <- time.Time{}
+	}
+	close(poll)
+
+	i := 0
+	state := func() ([]string, string) {
+		s := states[i]
+		i++
+		return strings.Fields(s.files), s.state
+	}
+	var runs []string
+	watchChanges(state, poll, func(files []string) {
+		runs = append(runs, strings.Join(files, " "))
+	})
+	if i != len(states) {
+		t.Errorf("expected the state to be read %d times, got %d", len(states), i)
+	}
+	// a run at first, and then one for each change
+	want := []string{"a.txt", "a.txt", "a.txt b.txt", ""}
+	if strings.Join(runs, ",") != strings.Join(want, ",") {
+		t.Errorf("expected the runs %q, got %q", want, runs)
+	}
+}
+
+func TestInputState(t *testing.T) {
+	dir, err := ioutil.TempDir("", "benchls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	write := func(name, s string) {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(s), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	patterns := []string{filepath.Join(dir, "b*.txt"), filepath.Join(dir, "a.txt")}
+
+	files, empty := inputState(patterns)
+	if len(files) != 0 {
+		t.Errorf("expected no files, got %q", files)
+	}
+	write("a.txt", "1")
+	write("b2.txt", "1")
+	write("b1.txt", "1")
+	write("c.txt", "1")
+	files, first := inputState(patterns)
+	// in the order of the patterns, and sorted within each
+	want := []string{filepath.Join(dir, "b1.txt"), filepath.Join(dir, "b2.txt"), filepath.Join(dir, "a.txt")}
+	if strings.Join(files, ",") != strings.Join(want, ",") {
+		t.Errorf("expected the files %q, got %q", want, files)
+	}
+	if first == empty {
+		t.Error("expected the state to change when files are created")
+	}
+	if _, s := inputState(patterns); s != first {
+		t.Error("expected the state not to change when the files do not")
+	}
+	write("c.txt", "12")
+	if _, s := inputState(patterns); s != first {
+		t.Error("expected the state not to change when a file that does not match does")
+	}
+	write("b2.txt", "12")
+	if _, s := inputState(patterns); s == first {
+		t.Error("expected the state to change when a file does")
+	}
+}
+
+func TestWithoutWatch(t *testing.T) {
+	args := []string{"-watch", "-xt", "N, 1.0", "-watch=true", "--watch", "-watchful", "a.txt", "-watch"}
+	got := withoutWatch(args, 2)
+	if want := []string{"-xt", "N, 1.0", "-watchful"}; strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
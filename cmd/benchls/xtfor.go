@@ -0,0 +1,91 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/jonlawlor/benchls"
+)
+
+// xtOverride is a -xt-for override of -xtransform for the groups whose names
+// match a regexp, like "BenchmarkSort.*=N*math.Log(N), 1.0".
+type xtOverride struct {
+	src        string
+	group      *regexp.Regexp
+	xtransform string
+}
+
+// xtOverrides is the repeatable -xt-for flag.  The first override that
+// matches a group applies to it.
+type xtOverrides []xtOverride
+
+func (xs *xtOverrides) String() string {
+	srcs := make([]string, len(*xs))
+	for i, x := range *xs {
+		srcs[i] = x.src
+	}
+	return strings.Join(srcs, "; ")
+}
+
+func (xs *xtOverrides) Set(src string) error {
+	kv := strings.SplitN(src, "=", 2)
+	if len(kv) != 2 || strings.TrimSpace(kv[1]) == "" {
+		return fmt.Errorf("invalid override %q, want something like \"BenchmarkSort.*=N*math.Log(N), 1.0\"", src)
+	}
+	re, err := regexp.Compile(strings.TrimSpace(kv[0]))
+	if err != nil {
+		return fmt.Errorf("invalid override %q: %v", src, err)
+	}
+	*xs = append(*xs, xtOverride{src: strings.TrimSpace(src), group: re, xtransform: kv[1]})
+	return nil
+}
+
+// part is the benchmarks of the groups that are fit with the same terms.
+type part struct {
+	xExprs []benchls.Expression
+	set    benchls.Set
+}
+
+// split divides benchSet into the groups of each override, in order, and
+// the rest, which are fit with xExprs.  The parts without benchmarks are left
+// out, unless there are none at all.
+func (xs xtOverrides) split(benchSet benchls.Set, ex benchls.Extractor, xExprs []benchls.Expression, varNames map[string]struct{}) ([]part, error) {
+	parts := make([]part, len(xs)+1)
+	for i, x := range xs {
+		exprs, err := benchls.NewExpressions(x.xtransform, varNames, consts)
+		if err != nil {
+			return nil, fmt.Errorf("-xt-for %q: %v", x.src, err)
+		}
+		parts[i] = part{exprs, make(benchls.Set)}
+	}
+	parts[len(xs)] = part{xExprs, make(benchls.Set)}
+
+	for name, bs := range benchSet {
+		i := len(xs)
+		if group, _, ok := ex.Extract(name); ok {
+			for j, x := range xs {
+				if x.group.MatchString(group) {
+					i = j
+					break
+				}
+			}
+		}
+		parts[i].set[name] = bs
+	}
+
+	var nonEmpty []part
+	for _, p := range parts {
+		if len(p.set) > 0 {
+			nonEmpty = append(nonEmpty, p)
+		}
+	}
+	if len(nonEmpty) == 0 {
+		return parts[len(xs):], nil
+	}
+	return nonEmpty, nil
+}
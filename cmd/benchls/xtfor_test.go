@@ -0,0 +1,73 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/jonlawlor/benchls"
+	"golang.org/x/perf/benchfmt"
+)
+
+func TestXTOverrides(t *testing.T) {
+	var xs xtOverrides
+	for _, src := range []string{"BenchmarkSort.*=N*math.Log(N), 1.0", "Benchmark.*Map=1.0"} {
+		if err := xs.Set(src); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, src := range []string{"BenchmarkSort", "BenchmarkSort=", "Benchmark(=N"} {
+		var bad xtOverrides
+		if err := bad.Set(src); err == nil {
+			t.Errorf("%s: expected an error", src)
+		}
+	}
+
+	benchSet := make(benchls.Set)
+	for _, name := range []string{"BenchmarkSort10-4", "BenchmarkSortStable10-4", "BenchmarkHashMap10-4", "BenchmarkSum10-4", "BenchmarkSum100-4"} {
+		benchSet[name] = []*benchfmt.Result{{Iters: 1, Values: []benchfmt.Value{{Value: 1, Unit: "ns/op"}}}}
+	}
+	ex := benchls.RegexpExtractor{Regexp: regexp.MustCompile(`(?P<N>\d+)-\d+$`)}
+	varNames := ex.VarNames()
+	xExprs, err := benchls.NewExpressions("N, 1.0", varNames, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts, err := xs.split(benchSet, ex, xExprs, varNames)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the first override that matches applies
+	for i, want := range []struct {
+		terms string
+		n     int
+	}{
+		{"N * math.Log(N), 1.0", 2},
+		{"1.0", 1},
+		{"N, 1.0", 2},
+	} {
+		if i >= len(parts) {
+			t.Fatalf("expected 3 parts, got %d", len(parts))
+		}
+		var terms string
+		for j, x := range parts[i].xExprs {
+			if j > 0 {
+				terms += ", "
+			}
+			terms += x.String()
+		}
+		if terms != want.terms || len(parts[i].set) != want.n {
+			t.Errorf("part %d: expected %d benchmarks with %s, got %d with %s", i, want.n, want.terms, len(parts[i].set), terms)
+		}
+	}
+
+	// an override of a variable that is not found is an error
+	var bad xtOverrides
+	bad.Set("BenchmarkSort=M, 1.0")
+	if _, err := bad.split(benchSet, ex, xExprs, varNames); err == nil {
+		t.Error("expected an error for an unknown variable")
+	}
+}
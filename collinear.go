@@ -0,0 +1,140 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package benchls
+
+import "math"
+
+// collinearTol is how small, relative to its length, the part of a term that
+// is independent of the earlier terms can be before the term is collinear.
+const collinearTol = 1e-9
+
+// Collinear returns the indexes of the explanatory terms of s that are,
+// to within rounding, linear combinations of the terms before them, like the
+// 2*N in "N, 2*N, 1.0".  The model cannot be estimated unless it is empty.
+// An empty sample has no terms, and so none that are collinear.
+func Collinear(s Sample) []int {
+	if len(s.Y) == 0 {
+		return nil
+	}
+	n, k := len(s.Y), len(s.X)/len(s.Y)
+
+	// modified Gram-Schmidt on the columns, keeping only the independent ones
+	var basis [][]float64
+	var collinear []int
+	for j := 0; j < k; j++ {
+		col := make([]float64, n)
+		norm := 0.0
+		for i := range col {
+			col[i] = s.X[i*k+j]
+			norm += col[i] * col[i]
+		}
+		norm = math.Sqrt(norm)
+		for _, q := range basis {
+			dot := 0.0
+			for i := range col {
+				dot += q[i] * col[i]
+			}
+			for i := range col {
+				col[i] -= dot * q[i]
+			}
+		}
+		rest := 0.0
+		for _, c := range col {
+			rest += c * c
+		}
+		rest = math.Sqrt(rest)
+		if rest <= collinearTol*norm || math.IsNaN(rest) {
+			collinear = append(collinear, j)
+			continue
+		}
+		for i := range col {
+			col[i] /= rest
+		}
+		basis = append(basis, col)
+	}
+	return collinear
+}
+
+// VIF returns the variance inflation factor of each explanatory term of s,
+// which is how much larger the variance of its parameter is than it would be
+// if the term were orthogonal to the others.  They are uncentered, so they
+// also measure collinearity with a constant term.  Large values, above 10
+// or so, mean the terms are nearly collinear and their parameters poorly
+// determined.  VIF returns nil if some terms are collinear.
+//
+// The factors are the squared norms of the rows of the inverse of R, in the
+// QR factorization of the terms scaled to unit length, which stays accurate
+// when the terms are too ill-conditioned to invert X^T*X, like N^2 and N
+// over a wide range of N.
+func VIF(s Sample) []float64 {
+	if len(s.Y) == 0 {
+		return nil
+	}
+	n, k := len(s.Y), len(s.X)/len(s.Y)
+
+	// modified Gram-Schmidt, with R upper triangular
+	q := make([][]float64, k)
+	r := make([][]float64, k)
+	for j := 0; j < k; j++ {
+		r[j] = make([]float64, k)
+		col := make([]float64, n)
+		norm := 0.0
+		for i := range col {
+			col[i] = s.X[i*k+j]
+			norm += col[i] * col[i]
+		}
+		norm = math.Sqrt(norm)
+		if !(norm > 0) || math.IsInf(norm, 0) {
+			return nil
+		}
+		for i := range col {
+			col[i] /= norm
+		}
+		for i := 0; i < j; i++ {
+			dot := 0.0
+			for l := range col {
+				dot += q[i][l] * col[l]
+			}
+			r[i][j] = dot
+			for l := range col {
+				col[l] -= dot * q[i][l]
+			}
+		}
+		rest := 0.0
+		for _, c := range col {
+			rest += c * c
+		}
+		rest = math.Sqrt(rest)
+		if rest <= collinearTol || math.IsNaN(rest) {
+			return nil
+		}
+		r[j][j] = rest
+		for l := range col {
+			col[l] /= rest
+		}
+		q[j] = col
+	}
+
+	// back substitution for the inverse of R, which is upper triangular too
+	inv := make([][]float64, k)
+	for j := k - 1; j >= 0; j-- {
+		inv[j] = make([]float64, k)
+		inv[j][j] = 1 / r[j][j]
+		for c := j + 1; c < k; c++ {
+			sum := 0.0
+			for m := j + 1; m <= c; m++ {
+				sum += r[j][m] * inv[m][c]
+			}
+			inv[j][c] = -sum / r[j][j]
+		}
+	}
+	vifs := make([]float64, k)
+	for j, row := range inv {
+		for _, v := range row {
+			vifs[j] += v * v
+		}
+	}
+	return vifs
+}
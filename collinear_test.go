@@ -0,0 +1,67 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package benchls
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCollinear(t *testing.T) {
+	var s Sample
+	for n := 1.0; n <= 10; n++ {
+		s.X = append(s.X, n, 2*n, 1.0, n*n)
+		s.Y = append(s.Y, 3*n+1)
+	}
+	if got := Collinear(s); len(got) != 1 || got[0] != 1 {
+		t.Errorf("expected term 1 to be collinear, got %v", got)
+	}
+	if m := Estimate(s, Options{}); m != nil {
+		t.Errorf("expected no estimate of a collinear model, got %v", m)
+	}
+	if vifs := VIF(s); vifs != nil {
+		t.Errorf("expected no VIFs of a collinear model, got %v", vifs)
+	}
+}
+
+func TestVIF(t *testing.T) {
+	// orthogonal terms are not inflated
+	s := Sample{X: []float64{1, 1, 1, -1, -1, 1, -1, -1}, Y: []float64{1, 2, 3, 4}}
+	for j, v := range VIF(s) {
+		if math.Abs(v-1) > 1e-12 {
+			t.Errorf("term %d: expected VIF 1, got %g", j, v)
+		}
+	}
+}
+
+func TestVIFIllConditioned(t *testing.T) {
+	// N^2 and N over six decades, which are too ill-conditioned to invert
+	// X^T*X, but not collinear
+	var s Sample
+	for e := 0.0; e <= 6; e += 0.5 {
+		n := math.Pow(10, e)
+		s.X = append(s.X, n*n, n, 1.0)
+		s.Y = append(s.Y, n*n+n+1)
+	}
+	vifs := VIF(s)
+	if len(vifs) != 3 {
+		t.Fatalf("expected 3 VIFs, got %v", vifs)
+	}
+	for j, v := range vifs {
+		if !(v >= 1) || math.IsInf(v, 0) {
+			t.Errorf("term %d: expected a finite VIF of at least 1, got %g", j, v)
+		}
+	}
+
+	// against the inverse of X^T*X of well conditioned terms, x and 1
+	s = Sample{X: []float64{1, 1, 2, 1, 3, 1, 4, 1}, Y: []float64{1, 2, 3, 4}}
+	// X^T*X is [[30 10] [10 4]], with the inverse [[4 -10] [-10 30]]/20
+	want := []float64{4.0 / 20 * 30, 30.0 / 20 * 4}
+	for j, v := range VIF(s) {
+		if math.Abs(v-want[j]) > 1e-12 {
+			t.Errorf("term %d: expected VIF %g, got %g", j, want[j], v)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package benchls
+
+import "math"
+
+// Delta is the change in one parameter between two fits.
+type Delta struct {
+	Before, After float64
+	CI            float64 // confidence interval half-width of After - Before
+}
+
+// Diff returns After - Before.
+func (d Delta) Diff() float64 {
+	return d.After - d.Before
+}
+
+// Significant reports whether the confidence interval of the change excludes
+// zero.
+func (d Delta) Significant() bool {
+	return math.Abs(d.Diff()) > d.CI
+}
+
+// Compare returns the change in each parameter from the before fit to the
+// after fit, which must have the same explanatory terms.  The standard error
+// of each change combines the standard errors of both fits, with the
+// Welch-Satterthwaite degrees of freedom, and its interval is at the
+// Confidence of opts.
+func Compare(before, after *Fit, opts Options) []Delta {
+	ds := make([]Delta, len(before.Model))
+	for i := range ds {
+		v1 := before.Stats.SE[i] * before.Stats.SE[i]
+		v2 := after.Stats.SE[i] * after.Stats.SE[i]
+		ds[i] = Delta{
+			Before: before.Model[i],
+			After:  after.Model[i],
+			CI:     conf(opts.level(), math.Sqrt(v1+v2), welch(v1, before.Stats.DOF, v2, after.Stats.DOF)),
+		}
+	}
+	return ds
+}
+
+// Ratio is a parameter of a fit relative to the same parameter of a baseline
+// fit.
+type Ratio struct {
+	Value float64
+	CI    float64 // confidence interval half-width of Value
+}
+
+// Ratios returns each parameter of fit divided by the same parameter of base,
+// which must have the same terms.  The standard error of each ratio is
+// propagated to first order from the relative standard errors of both fits,
+// with the Welch-Satterthwaite degrees of freedom, and its interval is at the
+// Confidence of opts.
+func Ratios(base, fit *Fit, opts Options) []Ratio {
+	rs := make([]Ratio, len(fit.Model))
+	for i := range rs {
+		r := fit.Model[i] / base.Model[i]
+		v1 := fit.Stats.SE[i] / fit.Model[i]
+		v1 *= v1
+		v2 := base.Stats.SE[i] / base.Model[i]
+		v2 *= v2
+		rs[i] = Ratio{
+			Value: r,
+			CI:    conf(opts.level(), math.Abs(r)*math.Sqrt(v1+v2), welch(v1, fit.Stats.DOF, v2, base.Stats.DOF)),
+		}
+	}
+	return rs
+}
+
+// welch returns the Welch-Satterthwaite degrees of freedom of the sum of two
+// variances with the given degrees of freedom.
+func welch(v1 float64, dof1 int, v2 float64, dof2 int) int {
+	if !(v1+v2 > 0) {
+		return dof1 + dof2
+	}
+	w := (v1 + v2) * (v1 + v2) / (v1*v1/float64(dof1) + v2*v2/float64(dof2))
+	return int(math.Max(math.Floor(w), 1))
+}
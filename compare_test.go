@@ -0,0 +1,58 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package benchls
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCompare(t *testing.T) {
+	line := func(slope, phase float64) *Fit {
+		var s Sample
+		for n := 1.0; n <= 20; n++ {
+			s.X = append(s.X, n, 1.0)
+			s.Y = append(s.Y, slope*n+10+0.5*math.Sin(n+phase))
+		}
+		return NewFit(s, Options{})
+	}
+	before := line(2, 0)
+
+	ds := Compare(before, line(2, 1), Options{})
+	if ds[0].Significant() || ds[1].Significant() {
+		t.Errorf("expected no significant change from noise, got %+v", ds)
+	}
+
+	ds = Compare(before, line(2.5, 1), Options{})
+	if !ds[0].Significant() {
+		t.Errorf("expected a significant change in slope, got %+v", ds[0])
+	}
+	if math.Abs(ds[0].Diff()-0.5) > 0.05 {
+		t.Errorf("expected a slope change near 0.5, got %g", ds[0].Diff())
+	}
+}
+
+func TestRatios(t *testing.T) {
+	line := func(slope, phase float64) *Fit {
+		var s Sample
+		for n := 1.0; n <= 20; n++ {
+			s.X = append(s.X, n, 1.0)
+			s.Y = append(s.Y, slope*n+10+0.5*math.Sin(n+phase))
+		}
+		return NewFit(s, Options{})
+	}
+	base, fit := line(2, 0), line(8, 1)
+
+	rs := Ratios(base, fit, Options{})
+	if math.Abs(rs[0].Value-4) > 0.05 {
+		t.Errorf("expected a slope ratio near 4, got %g", rs[0].Value)
+	}
+	if !(rs[0].CI > 0) || math.Abs(rs[0].Value-4) > rs[0].CI {
+		t.Errorf("expected the interval of the slope ratio to cover 4, got %+v", rs[0])
+	}
+	if r := Ratios(base, base, Options{})[0]; r.Value != 1 {
+		t.Errorf("expected a ratio of 1 to itself, got %+v", r)
+	}
+}
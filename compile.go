@@ -0,0 +1,216 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package benchls
+
+import (
+	"errors"
+	"go/ast"
+	"go/token"
+	"math"
+	"strconv"
+)
+
+// evalFunc evaluates a compiled expression.
+type evalFunc func(vars map[string]float64) float64
+
+// mathFuncs are the math functions of one argument that expressions can call.
+var mathFuncs = map[string]func(float64) float64{
+	"Abs": math.Abs, "Acos": math.Acos, "Acosh": math.Acosh, "Asin": math.Asin,
+	"Asinh": math.Asinh, "Atan": math.Atan, "Atanh": math.Atanh, "Cbrt": math.Cbrt,
+	"Ceil": math.Ceil, "Cos": math.Cos, "Cosh": math.Cosh, "Erf": math.Erf,
+	"Erfc": math.Erfc, "Exp": math.Exp, "Exp2": math.Exp2, "Expm1": math.Expm1,
+	"Floor": math.Floor, "Gamma": math.Gamma, "J0": math.J0, "J1": math.J1,
+	"Log": math.Log, "Log10": math.Log10, "Log1p": math.Log1p, "Log2": math.Log2,
+	"Logb": math.Logb, "Sin": math.Sin, "Sinh": math.Sinh, "Sqrt": math.Sqrt,
+	"Tan": math.Tan, "Tanh": math.Tanh, "Trunc": math.Trunc, "Y0": math.Y0,
+	"Y1": math.Y1,
+}
+
+// mathFuncs2 are the math functions of two arguments that expressions can
+// call.
+var mathFuncs2 = map[string]func(float64, float64) float64{
+	"Atan2": math.Atan2, "Copysign": math.Copysign, "Dim": math.Dim,
+	"Hypot": math.Hypot, "Max": math.Max, "Min": math.Min, "Mod": math.Mod,
+	"Nextafter": math.Nextafter, "Pow": math.Pow, "Remainder": math.Remainder,
+}
+
+// compile turns a rewritten expression into a closure, so that evaluating it
+// does not walk the tree or allocate.  Subexpressions without variables are
+// evaluated once, here.
+func compile(n ast.Expr) (evalFunc, error) {
+	f, c, err := compileConst(n)
+	if err != nil {
+		return nil, err
+	}
+	if f == nil {
+		return func(map[string]float64) float64 { return c }, nil
+	}
+	return f, nil
+}
+
+// compileConst compiles n, or returns a nil evalFunc and the value of n if it
+// is constant.
+func compileConst(n ast.Expr) (evalFunc, float64, error) {
+	switch n := n.(type) {
+	case *ast.BasicLit:
+		switch n.Kind {
+		case token.FLOAT:
+			v, err := strconv.ParseFloat(n.Value, 64)
+			return nil, v, err
+		case token.INT:
+			i, err := strconv.ParseInt(n.Value, 0, 64)
+			return nil, float64(i), err
+		}
+	case *ast.Ident:
+		name := n.Name
+		return func(vars map[string]float64) float64 { return vars[name] }, 0, nil
+	case *ast.ParenExpr:
+		return compileConst(n.X)
+	case *ast.UnaryExpr:
+		x, c, err := compileConst(n.X)
+		if err != nil {
+			return nil, 0, err
+		}
+		switch n.Op {
+		case token.ADD:
+			return x, c, nil
+		case token.SUB:
+			if x == nil {
+				return nil, -c, nil
+			}
+			return func(vars map[string]float64) float64 { return -x(vars) }, 0, nil
+		}
+	case *ast.BinaryExpr:
+		return compileBinary(n)
+	case *ast.CallExpr:
+		return compileCall(n)
+	}
+	return nil, 0, errors.New("cannot compile " + format(n))
+}
+
+// compileBinary compiles an arithmetic operation.
+func compileBinary(n *ast.BinaryExpr) (evalFunc, float64, error) {
+	x, a, err := compileConst(n.X)
+	if err != nil {
+		return nil, 0, err
+	}
+	y, b, err := compileConst(n.Y)
+	if err != nil {
+		return nil, 0, err
+	}
+	var op func(a, b float64) float64
+	switch n.Op {
+	case token.ADD:
+		op = func(a, b float64) float64 { return a + b }
+	case token.SUB:
+		op = func(a, b float64) float64 { return a - b }
+	case token.MUL:
+		op = func(a, b float64) float64 { return a * b }
+	case token.QUO:
+		op = func(a, b float64) float64 { return a / b }
+	case token.REM:
+		op = math.Mod
+	default:
+		return nil, 0, errors.New("cannot compile " + format(n))
+	}
+	switch {
+	case x == nil && y == nil:
+		return nil, op(a, b), nil
+	case x == nil:
+		return func(vars map[string]float64) float64 { return op(a, y(vars)) }, 0, nil
+	case y == nil:
+		return func(vars map[string]float64) float64 { return op(x(vars), b) }, 0, nil
+	}
+	// the common operators are spelled out, so that they are not a second call
+	switch n.Op {
+	case token.ADD:
+		return func(vars map[string]float64) float64 { return x(vars) + y(vars) }, 0, nil
+	case token.MUL:
+		return func(vars map[string]float64) float64 { return x(vars) * y(vars) }, 0, nil
+	}
+	return func(vars map[string]float64) float64 { return op(x(vars), y(vars)) }, 0, nil
+}
+
+// compileCall compiles a call of a math function.
+func compileCall(n *ast.CallExpr) (evalFunc, float64, error) {
+	name := mathName(n.Fun)
+	args := make([]evalFunc, len(n.Args))
+	consts := make([]float64, len(n.Args))
+	constant := true
+	for i, arg := range n.Args {
+		var err error
+		if args[i], consts[i], err = compileConst(arg); err != nil {
+			return nil, 0, err
+		}
+		constant = constant && args[i] == nil
+	}
+	// arg returns the i'th argument as a closure
+	arg := func(i int) evalFunc {
+		if args[i] != nil {
+			return args[i]
+		}
+		c := consts[i]
+		return func(map[string]float64) float64 { return c }
+	}
+
+	if f, ok := mathFuncs[name]; ok && len(args) == 1 {
+		if constant {
+			return nil, f(consts[0]), nil
+		}
+		x := args[0]
+		return func(vars map[string]float64) float64 { return f(x(vars)) }, 0, nil
+	}
+	if f, ok := mathFuncs2[name]; ok && len(args) == 2 {
+		if constant {
+			return nil, f(consts[0], consts[1]), nil
+		}
+		x, y := arg(0), arg(1)
+		return func(vars map[string]float64) float64 { return f(x(vars), y(vars)) }, 0, nil
+	}
+	if err := arity(n); err != nil {
+		return nil, 0, err
+	}
+	return nil, 0, errors.New("cannot compile " + format(n))
+}
+
+// arity returns an error naming the number of arguments that a math function
+// takes if call has another number of them, or nil.
+func arity(call *ast.CallExpr) error {
+	name := mathName(call.Fun)
+	_, one := mathFuncs[name]
+	_, two := mathFuncs2[name]
+	switch {
+	case one && len(call.Args) != 1:
+		return errors.New("math." + name + " takes one argument: " + format(call))
+	case two && len(call.Args) != 2:
+		return errors.New("math." + name + " takes two arguments: " + format(call))
+	}
+	return nil
+}
+
+// checkArity returns the arity error of the first call in n with the wrong
+// number of arguments, which parsefloat would only report as unsupported.
+func checkArity(n ast.Expr) error {
+	var err error
+	ast.Inspect(n, func(n ast.Node) bool {
+		if call, ok := n.(*ast.CallExpr); ok && err == nil {
+			err = arity(call)
+		}
+		return err == nil
+	})
+	return err
+}
+
+// mathName returns the name of the selector math.Name, or "" if n is not one.
+func mathName(n ast.Expr) string {
+	sel, ok := n.(*ast.SelectorExpr)
+	if !ok {
+		return ""
+	}
+	if pkg, ok := sel.X.(*ast.Ident); !ok || pkg.Name != "math" {
+		return ""
+	}
+	return sel.Sel.Name
+}
@@ -0,0 +1,67 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package benchls
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestCompile(t *testing.T) {
+	names := map[string]struct{}{"N": struct{}{}, "M": struct{}{}}
+	vars := map[string]float64{"N": 1000, "M": 3}
+	for _, test := range []struct {
+		src  string
+		want float64
+	}{
+		{"N * math.Log(N) + 2*M", 1000*math.Log(1000) + 6},
+		{"-N / 4 - M", -253},
+		{"N % 7", 6},
+		{"0x10 * M", 48},
+		{"math.Pow(2, 10) + math.Max(M, N)", 2024},
+		{"math.Sqrt(16)", 4},
+		{"M > 2 ? math.Frexp(N)[1] : 0", 10},
+	} {
+		e, err := NewExpression(test.src, names, nil)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", test.src, err)
+			continue
+		}
+		if got := e.Eval(vars); math.Abs(got-test.want) > 1e-12*math.Abs(test.want) {
+			t.Errorf("%s: expected %g, got %g", test.src, test.want, got)
+		}
+	}
+
+	for src, want := range map[string]string{
+		"math.Pow(N)":     "math.Pow takes two arguments: math.Pow(N)",
+		"math.Log(N, M)":  "math.Log takes one argument: math.Log(N, M)",
+		"math.Nope(N, M)": "math.Nope",
+	} {
+		if _, err := NewExpression(src, names, nil); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: expected an error containing %q, got %v", src, want, err)
+		}
+	}
+
+	e, err := NewExpression("N * math.Log(N) + M * N + 1", names, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if allocs := testing.AllocsPerRun(100, func() { e.Eval(vars) }); allocs != 0 {
+		t.Errorf("expected no allocations, got %v", allocs)
+	}
+}
+
+func BenchmarkEval(b *testing.B) {
+	names := map[string]struct{}{"N": struct{}{}, "M": struct{}{}}
+	e, err := NewExpression("N * math.Log(N) + M * N + 1", names, nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+	vars := map[string]float64{"N": 1000, "M": 3}
+	for i := 0; i < b.N; i++ {
+		e.Eval(vars)
+	}
+}
@@ -0,0 +1,199 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package benchls
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/perf/benchfmt"
+)
+
+// A Set is the benchmark results of go test output, keyed by the name of the
+// benchmark, like BenchmarkSort/n=1000-4, in the order they were read.  Each
+// result has every measurement of its line, including the custom metrics
+// reported by b.ReportMetric, and the configuration lines in effect where it
+// was read, like goos, goarch, cpu and pkg.
+type Set map[string][]*benchfmt.Result
+
+// ReadSet reads the benchmark results of go test output, in the format of
+// golang.org/x/perf/benchfmt.  Configuration lines, like
+//
+//	cpu: Intel(R) Core(TM) i7-8650U CPU @ 1.90GHz
+//
+// apply to the results after them.  Output without a pkg line has the package
+// in the footer of each test binary's run, like
+//
+//	ok  	sort	1.234s
+//
+// which is the pkg of the results since the previous footer.  Lines that are
+// not results or configuration are skipped.
+func ReadSet(r io.Reader) (Set, error) {
+	input, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// the line numbers of the footers, and their packages
+	var footerLines []int
+	var footerPkgs []string
+	scan := bufio.NewScanner(bytes.NewReader(input))
+	for line := 1; scan.Scan(); line++ {
+		if m := footer.FindStringSubmatch(scan.Text()); m != nil {
+			footerLines = append(footerLines, line)
+			footerPkgs = append(footerPkgs, m[1])
+		}
+	}
+	if err := scan.Err(); err != nil {
+		return nil, err
+	}
+
+	set := make(Set)
+	br := benchfmt.NewReader(bytes.NewReader(input), "")
+	for br.Scan() {
+		res, ok := br.Result().(*benchfmt.Result)
+		if !ok {
+			// a malformed result, or unit metadata
+			continue
+		}
+		res = res.Clone()
+		if res.GetConfig("pkg") == "" {
+			_, line := res.Pos()
+			if i := sort.SearchInts(footerLines, line); i < len(footerLines) {
+				res.SetConfig("pkg", footerPkgs[i])
+			}
+		}
+		name := "Benchmark" + res.Name.String()
+		set[name] = append(set[name], res)
+	}
+	if err := br.Err(); err != nil {
+		return nil, err
+	}
+	return set, nil
+}
+
+// footer is the last line of a test binary's run, with its package.
+var footer = regexp.MustCompile(`^(?:ok|FAIL)\s+(\S+)\s`)
+
+var procsSuffix = regexp.MustCompile(`-(\d+)$`)
+
+// procs returns the GOMAXPROCS suffix of a benchmark name, if there is one.
+func procs(name string) (float64, bool) {
+	m := procsSuffix.FindStringSubmatch(name)
+	if m == nil {
+		return 0, false
+	}
+	p, err := strconv.ParseFloat(m[1], 64)
+	return p, err == nil
+}
+
+// configKeys are the configuration lines that distinguish one machine
+// configuration from another.
+var configKeys = []string{"goos", "goarch", "cpu"}
+
+// ConfigKey identifies the machine configuration of a result, like
+// "linux/amd64/Intel Xeon".
+func ConfigKey(res *benchfmt.Result) string {
+	var parts []string
+	for _, k := range configKeys {
+		if v := res.GetConfig(k); v != "" {
+			parts = append(parts, v)
+		}
+	}
+	if len(parts) == 0 {
+		return "unknown"
+	}
+	return strings.Join(parts, "/")
+}
+
+// SplitConfigs partitions the benchmarks by their machine configuration.  It
+// returns the sorted configuration keys along with the benchmarks for each.
+func SplitConfigs(benchSet Set) ([]string, map[string]Set) {
+	return split(benchSet, func(_ string, res *benchfmt.Result) string {
+		return ConfigKey(res)
+	})
+}
+
+// GroupKey identifies the values of the named configuration lines of a
+// result, like "goos=linux pkg=sort".  Missing lines have empty values.
+func GroupKey(res *benchfmt.Result, keys []string) string {
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = k + "=" + res.GetConfig(k)
+	}
+	return strings.Join(parts, " ")
+}
+
+// SplitBy partitions the benchmarks by the values of the named configuration
+// lines.  The key P is the GOMAXPROCS suffix of the benchmark name.  It
+// returns the sorted group keys along with the benchmarks for each.
+func SplitBy(benchSet Set, keys []string) ([]string, map[string]Set) {
+	return split(benchSet, func(name string, res *benchfmt.Result) string {
+		if contains(keys, "P") {
+			res = res.Clone()
+			res.SetConfig("P", strconv.FormatFloat(gomaxprocs(name), 'g', -1, 64))
+		}
+		return GroupKey(res, keys)
+	})
+}
+
+// gomaxprocs returns the GOMAXPROCS suffix of a benchmark name, which go test
+// leaves off when it is 1.
+func gomaxprocs(name string) float64 {
+	p, ok := procs(name)
+	if !ok {
+		return 1
+	}
+	return p
+}
+
+// templateKey matches the {key} placeholders of a group template.
+var templateKey = regexp.MustCompile(`\{([^{}]*)\}`)
+
+// SplitTemplate partitions the benchmarks by a group template, like
+// "{name}/{goos}", in which each {key} is the value of that configuration
+// line, or of P, the GOMAXPROCS suffix.  Missing lines have empty values.
+// {name} is left in place for the group name that the benchmarks are sampled
+// into.  It returns the sorted partially expanded templates along with the
+// benchmarks for each.
+func SplitTemplate(benchSet Set, template string) ([]string, map[string]Set) {
+	return split(benchSet, func(name string, res *benchfmt.Result) string {
+		return templateKey.ReplaceAllStringFunc(template, func(m string) string {
+			switch k := m[1 : len(m)-1]; k {
+			case "name":
+				return m
+			case "P":
+				return strconv.FormatFloat(gomaxprocs(name), 'g', -1, 64)
+			default:
+				return res.GetConfig(k)
+			}
+		})
+	})
+}
+
+func split(benchSet Set, keyOf func(string, *benchfmt.Result) string) ([]string, map[string]Set) {
+	sets := make(map[string]Set)
+	for name, rs := range benchSet {
+		for _, res := range rs {
+			key := keyOf(name, res)
+			if sets[key] == nil {
+				sets[key] = make(Set)
+			}
+			sets[key][name] = append(sets[key][name], res)
+		}
+	}
+	keys := make([]string, 0, len(sets))
+	for k := range sets {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys, sets
+}
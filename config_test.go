@@ -0,0 +1,149 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package benchls
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSplitBy(t *testing.T) {
+	s := `
+goos: linux
+pkg: sort
+BenchmarkSort10-4   	 1000000	      1008 ns/op
+BenchmarkSort100-4  	  200000	      8224 ns/op
+pkg: container/list
+BenchmarkSort10-4   	 1000000	      2016 ns/op
+`
+	benchSet, err := ReadSet(strings.NewReader(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys, sets := SplitBy(benchSet, []string{"pkg", "goarch"})
+	want := []string{"pkg=container/list goarch=", "pkg=sort goarch="}
+	if strings.Join(keys, ",") != strings.Join(want, ",") {
+		t.Fatalf("expected keys %q, got %q", want, keys)
+	}
+	if n := len(sets[want[1]]["BenchmarkSort10-4"]); n != 1 {
+		t.Errorf("expected 1 BenchmarkSort10-4 in sort, got %d", n)
+	}
+	if n := len(sets[want[1]]); n != 2 {
+		t.Errorf("expected 2 benchmarks in sort, got %d", n)
+	}
+}
+
+func TestSplitTemplate(t *testing.T) {
+	s := `
+goos: linux
+pkg: sort
+BenchmarkSort10-4   	 1000000	      1008 ns/op
+pkg: container/list
+BenchmarkSort10-8   	 1000000	      2016 ns/op
+`
+	benchSet, err := ReadSet(strings.NewReader(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys, sets := SplitTemplate(benchSet, "{name}/{goos}/{pkg}-{P}{goarch}")
+	want := []string{"{name}/linux/container/list-8", "{name}/linux/sort-4"}
+	if strings.Join(keys, ",") != strings.Join(want, ",") {
+		t.Fatalf("expected keys %q, got %q", want, keys)
+	}
+	if n := len(sets[want[0]]["BenchmarkSort10-8"]); n != 1 {
+		t.Errorf("expected 1 BenchmarkSort10-8 in container/list, got %d", n)
+	}
+}
+
+func TestSplitByProcs(t *testing.T) {
+	s := `
+pkg: sort
+BenchmarkSort10-4   	 1000000	      1008 ns/op
+BenchmarkSort10-8   	 1000000	       604 ns/op
+BenchmarkSort10     	 1000000	      2016 ns/op
+`
+	benchSet, err := ReadSet(strings.NewReader(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys, sets := SplitBy(benchSet, []string{"P"})
+	want := []string{"P=1", "P=4", "P=8"}
+	if strings.Join(keys, ",") != strings.Join(want, ",") {
+		t.Fatalf("expected keys %q, got %q", want, keys)
+	}
+	if n := len(sets["P=8"]["BenchmarkSort10-8"]); n != 1 {
+		t.Errorf("expected 1 BenchmarkSort10-8 in P=8, got %d", n)
+	}
+}
+
+func TestReadSetFooter(t *testing.T) {
+	s := `
+BenchmarkSort10-4   	 1000000	      1008 ns/op
+PASS
+ok  	sort	1.234s
+BenchmarkSort10-4   	 1000000	      3024 ns/op
+FAIL	strings	0.1s
+pkg: container/list
+BenchmarkSort10-4   	 1000000	      2016 ns/op
+ok  	container/list	0.5s
+`
+	benchSet, err := ReadSet(strings.NewReader(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rs := benchSet["BenchmarkSort10-4"]
+	want := []string{"sort", "strings", "container/list"}
+	if len(rs) != len(want) {
+		t.Fatalf("expected %d results, got %d", len(want), len(rs))
+	}
+	for i, pkg := range want {
+		if got := rs[i].GetConfig("pkg"); got != pkg {
+			t.Errorf("result %d: expected pkg %s, got %q", i, pkg, got)
+		}
+	}
+}
+
+func TestReadSet(t *testing.T) {
+	s := `
+goos: linux
+goarch: amd64
+BenchmarkSort/n=10/algo=quick-4  	 1000000	      1008 ns/op	      64 B/op	       2 allocs/op
+BenchmarkSort/n=10/algo=quick-4  	 1000000	      1010 ns/op	      64 B/op	       2 allocs/op
+goarch: arm64
+BenchmarkSort/n=100/algo=quick-4 	  200000	      8224 ns/op	  12.16 MB/s	       3 swaps/op
+BenchmarkSort this line is not a result
+PASS
+`
+	benchSet, err := ReadSet(strings.NewReader(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(benchSet) != 2 || len(benchSet["BenchmarkSort/n=10/algo=quick-4"]) != 2 {
+		t.Fatalf("expected 2 names, with 2 results of n=10, got %v", benchSet)
+	}
+	res := benchSet["BenchmarkSort/n=100/algo=quick-4"][0]
+	if res.Iters != 200000 || res.GetConfig("goos") != "linux" || res.GetConfig("goarch") != "arm64" {
+		t.Errorf("expected 200000 iterations on linux/arm64, got %d on %s/%s", res.Iters, res.GetConfig("goos"), res.GetConfig("goarch"))
+	}
+	for _, test := range []struct {
+		yVar string
+		want float64
+		ok   bool
+	}{
+		{"NsPerOp", 8224, true},
+		{"MBPerS", 12.16, true},
+		{"BytesPerOp", 100, true},
+		{"swaps/op", 3, true},
+		{"AllocsPerOp", 0, false},
+		{"cachemisses/op", 0, false},
+	} {
+		if got, ok := Response(res, test.yVar); ok != test.ok || got != test.want {
+			t.Errorf("%s: expected %g, %v, got %g, %v", test.yVar, test.want, test.ok, got, ok)
+		}
+	}
+	if got := Units(benchSet); strings.Join(got, " ") != "B/op MB/s allocs/op ns/op swaps/op" {
+		t.Errorf("expected the units as written, got %q", got)
+	}
+}
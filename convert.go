@@ -0,0 +1,85 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package benchls
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// The converters of other benchmark harnesses' results write go test -bench
+// output, so that everything that reads benchmarks reads theirs too.  Their
+// parameters become key=value elements of sub-benchmark names, which
+// -auto-vars finds, and the name ends in a GOMAXPROCS like suffix, which the
+// default -vars expects.
+
+// measurement is a value and its unit, like 1234 ns/op.
+type measurement struct {
+	value float64
+	unit  string
+}
+
+// benchName writes a benchmark name like "Benchmarksort/size=1000-1" from the
+// name of the benchmark, its parameters in order and the number of threads it
+// ran on.  Spaces, which would end the name, are replaced with underscores.
+func benchName(name string, params [][2]string, threads int) string {
+	var b bytes.Buffer
+	b.WriteString("Benchmark")
+	b.WriteString(name)
+	for _, p := range params {
+		fmt.Fprintf(&b, "/%s=%s", p[0], p[1])
+	}
+	if threads < 1 {
+		threads = 1
+	}
+	fmt.Fprintf(&b, "-%d", threads)
+	return strings.Join(strings.Fields(b.String()), "_")
+}
+
+// writeLine writes a benchmark line of n iterations with the measurements.
+// Allocations are rounded, since go test reports whole bytes and allocs.
+// Measurements that are not finite are left out.
+func writeLine(w *bytes.Buffer, name string, n int, ms ...measurement) {
+	fmt.Fprintf(w, "%s\t%d", name, n)
+	for _, m := range ms {
+		if math.IsNaN(m.value) || math.IsInf(m.value, 0) {
+			continue
+		}
+		v := m.value
+		if m.unit == "B/op" || m.unit == "allocs/op" {
+			v = math.Floor(v + 0.5)
+		}
+		fmt.Fprintf(w, "\t%s %s", strconv.FormatFloat(v, 'g', -1, 64), m.unit)
+	}
+	w.WriteString("\n")
+}
+
+// timeUnits are the nanoseconds in each unit of time.
+var timeUnits = map[string]float64{
+	"ns":  1,
+	"us":  1e3,
+	"µs":  1e3,
+	"ms":  1e6,
+	"s":   1e9,
+	"min": 60e9,
+}
+
+// nsPerOp converts a time per op, like 12 "us/op", or a throughput, like 3
+// "ops/ms", to nanoseconds per op.
+func nsPerOp(v float64, unit string) (float64, error) {
+	if i := strings.Index(unit, "/"); i > 0 {
+		num, den := unit[:i], unit[i+1:]
+		if ns, ok := timeUnits[num]; ok && den == "op" {
+			return v * ns, nil
+		}
+		if ns, ok := timeUnits[den]; ok && num == "ops" {
+			return ns / v, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown unit %q", unit)
+}
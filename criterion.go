@@ -0,0 +1,162 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package benchls
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// criterionColumns are the columns of criterion's raw.csv.
+var criterionColumns = []string{"group", "function", "value", "throughput_num", "throughput_type", "sample_measured_value", "unit", "iteration_count"}
+
+// Criterion converts the raw.csv samples of criterion.rs, possibly several
+// concatenated, to go test -bench output.  Each sample is a replicate, named
+// like BenchmarkSort/quick/1024-1 for the function quick of the group Sort
+// with the value 1024, so that the default -vars finds N.  Throughputs in
+// bytes are MB/s, and in elements the metric elements/s.
+func Criterion(r io.Reader) ([]byte, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	records, err := cr.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("criterion: %v", err)
+	}
+	var out bytes.Buffer
+	for _, rec := range records {
+		if len(rec) != len(criterionColumns) || rec[0] == "group" {
+			continue // the header of each file
+		}
+		var elems []string
+		for _, e := range rec[:3] {
+			if e != "" {
+				elems = append(elems, e)
+			}
+		}
+		measured, err := strconv.ParseFloat(rec[5], 64)
+		if err != nil {
+			return nil, fmt.Errorf("criterion: %s: %v", strings.Join(elems, "/"), err)
+		}
+		iters, err := strconv.ParseFloat(rec[7], 64)
+		if err != nil || iters <= 0 {
+			return nil, fmt.Errorf("criterion: %s: invalid iteration count %q", strings.Join(elems, "/"), rec[7])
+		}
+		ns, err := nsPerOp(measured/iters, rec[6]+"/op")
+		if err != nil {
+			return nil, fmt.Errorf("criterion: %s: %v", strings.Join(elems, "/"), err)
+		}
+		ms := []measurement{{ns, "ns/op"}}
+		if num, err := strconv.ParseFloat(rec[3], 64); err == nil && ns > 0 {
+			switch rec[4] {
+			case "bytes", "bytes_decimal":
+				ms = append(ms, measurement{num / ns * 1e3, "MB/s"})
+			case "elements":
+				ms = append(ms, measurement{num / ns * 1e9, "elements/s"})
+			}
+		}
+		// n is the iterations of the sample, which go test reports too
+		writeLine(&out, benchName(strings.Join(elems, "/"), nil, 1), int(iters), ms...)
+	}
+	return out.Bytes(), nil
+}
+
+// CriterionDir reads the results of criterion.rs in dir, like
+// target/criterion, as raw.csv samples for Criterion.  It uses the raw.csv of
+// each benchmark if there is one, or else the slope, or mean, point estimate
+// of its estimates.json as a single sample, named by its benchmark.json.
+func CriterionDir(dir string) ([]byte, error) {
+	var out bytes.Buffer
+	w := csv.NewWriter(&out)
+	w.Write(criterionColumns)
+	err := filepath.Walk(dir, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !fi.IsDir() || filepath.Base(path) != "new" {
+			return nil
+		}
+		if raw, err := ioutil.ReadFile(filepath.Join(path, "raw.csv")); err == nil {
+			w.Flush()
+			out.Write(raw)
+			return filepath.SkipDir
+		}
+		rec, err := criterionEstimate(dir, path)
+		if err != nil {
+			return err
+		}
+		if rec != nil {
+			w.Write(rec)
+		}
+		return filepath.SkipDir
+	})
+	w.Flush()
+	if err != nil {
+		return nil, fmt.Errorf("criterion: %v", err)
+	}
+	return out.Bytes(), w.Error()
+}
+
+// criterionEstimate returns the point estimate in the estimates.json in dir,
+// under root, as a raw.csv record, or nil if there is none.
+func criterionEstimate(root, dir string) ([]string, error) {
+	est, err := ioutil.ReadFile(filepath.Join(dir, "estimates.json"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var estimates map[string]*struct {
+		PointEstimate float64 `json:"point_estimate"`
+	}
+	if err := json.Unmarshal(est, &estimates); err != nil {
+		return nil, fmt.Errorf("%s: %v", filepath.Join(dir, "estimates.json"), err)
+	}
+	e := estimates["slope"]
+	if e == nil {
+		e = estimates["mean"]
+	}
+	if e == nil {
+		return nil, fmt.Errorf("%s has no slope or mean", filepath.Join(dir, "estimates.json"))
+	}
+
+	var bench struct {
+		GroupID    string                     `json:"group_id"`
+		FunctionID *string                    `json:"function_id"`
+		ValueStr   *string                    `json:"value_str"`
+		Throughput map[string]json.RawMessage `json:"throughput"`
+	}
+	if b, err := ioutil.ReadFile(filepath.Join(dir, "benchmark.json")); err == nil {
+		if err := json.Unmarshal(b, &bench); err != nil {
+			return nil, fmt.Errorf("%s: %v", filepath.Join(dir, "benchmark.json"), err)
+		}
+	} else {
+		// the directory of the benchmark is named after it
+		rel, err := filepath.Rel(root, filepath.Dir(dir))
+		if err != nil {
+			return nil, err
+		}
+		bench.GroupID = filepath.ToSlash(rel)
+	}
+	rec := []string{bench.GroupID, "", "", "", "", strconv.FormatFloat(e.PointEstimate, 'g', -1, 64), "ns", "1"}
+	if bench.FunctionID != nil {
+		rec[1] = *bench.FunctionID
+	}
+	if bench.ValueStr != nil {
+		rec[2] = *bench.ValueStr
+	}
+	for kind, num := range bench.Throughput {
+		rec[3], rec[4] = string(num), strings.ToLower(strings.Replace(kind, "Decimal", "_decimal", 1))
+	}
+	return rec, nil
+}
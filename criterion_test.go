@@ -0,0 +1,72 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package benchls
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCriterion(t *testing.T) {
+	input := `group,function,value,throughput_num,throughput_type,sample_measured_value,unit,iteration_count
+Sort,quick,1024,4096,bytes,20480,ns,10
+Sort,quick,1024,4096,bytes,40960,ns,20
+group,function,value,throughput_num,throughput_type,sample_measured_value,unit,iteration_count
+fib,,,,,1500,ns,3
+`
+	want := "BenchmarkSort/quick/1024-1\t10\t2048 ns/op\t2000 MB/s\n" +
+		"BenchmarkSort/quick/1024-1\t20\t2048 ns/op\t2000 MB/s\n" +
+		"Benchmarkfib-1\t3\t500 ns/op\n"
+	got, err := Criterion(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if string(got) != want {
+		t.Errorf("expected\n%s\ngot\n%s", want, got)
+	}
+}
+
+func TestCriterionDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "criterion")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	files := map[string]string{
+		"Sort/quick/1024/new/raw.csv": "group,function,value,throughput_num,throughput_type,sample_measured_value,unit,iteration_count\n" +
+			"Sort,quick,1024,,,1000,ns,1\n",
+		"Sort/quick/1024/new/estimates.json":  `{"mean": {"point_estimate": 999}}`,
+		"Sort/quick/2048/new/estimates.json":  `{"mean": {"point_estimate": 2100}, "slope": {"point_estimate": 2000}}`,
+		"Sort/quick/2048/new/benchmark.json":  `{"group_id": "Sort", "function_id": "quick", "value_str": "2048", "throughput": {"Elements": 2048}}`,
+		"Sort/quick/2048/base/estimates.json": `{"mean": {"point_estimate": 1}}`,
+		"fib/new/estimates.json":              `{"mean": {"point_estimate": 7}}`,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	raw, err := CriterionDir(dir)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	got, err := Criterion(strings.NewReader(string(raw)))
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	want := "BenchmarkSort/quick/1024-1\t1\t1000 ns/op\n" +
+		"BenchmarkSort/quick/2048-1\t1\t2000 ns/op\t1.024e+09 elements/s\n" +
+		"Benchmarkfib-1\t1\t7 ns/op\n"
+	if string(got) != want {
+		t.Errorf("expected\n%s\ngot\n%s", want, got)
+	}
+}
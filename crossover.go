@@ -0,0 +1,67 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package benchls
+
+import "math"
+
+// Crossover is where the responses predicted by two models are equal.
+type Crossover struct {
+	At     float64 // value of the input variable
+	Y      float64 // predicted response
+	ALower bool    // whether the first model predicts the lower response below At
+}
+
+// crossoverSteps is how finely the range is scanned for crossovers.
+const crossoverSteps = 1000
+
+// Crossovers finds where models a and b of the terms xExprs predict the same
+// response, as the input variable v ranges from lo to hi.  The range is
+// scanned in even steps, which are logarithmic if lo is positive, and each
+// crossover is refined by bisection.  Crossovers closer together than a step
+// may be missed.
+func Crossovers(xExprs []Expression, a, b Model, v string, lo, hi float64) []Crossover {
+	eval := func(m Model, at float64) float64 {
+		vars := map[string]float64{"P": 1, v: at}
+		y := 0.0
+		for i, xExpr := range xExprs {
+			y += m[i] * xExpr.Eval(vars)
+		}
+		return y
+	}
+	diff := func(at float64) float64 { return eval(a, at) - eval(b, at) }
+	step := func(i int) float64 {
+		if lo > 0 {
+			return lo * math.Pow(hi/lo, float64(i)/crossoverSteps)
+		}
+		return lo + (hi-lo)*float64(i)/crossoverSteps
+	}
+
+	var cs []Crossover
+	x0, d0 := lo, diff(lo)
+	for i := 1; i <= crossoverSteps; i++ {
+		x1 := step(i)
+		d1 := diff(x1)
+		if math.IsNaN(d0) || math.IsNaN(d1) || (d0 < 0) == (d1 < 0) {
+			x0, d0 = x1, d1
+			continue
+		}
+		l, r := x0, x1
+		for j := 0; j < 100 && l < r; j++ {
+			mid := l + (r-l)/2
+			if mid == l || mid == r {
+				break
+			}
+			if (diff(mid) < 0) == (d0 < 0) {
+				l = mid
+			} else {
+				r = mid
+			}
+		}
+		at := l + (r-l)/2
+		cs = append(cs, Crossover{At: at, Y: eval(a, at), ALower: d0 < 0})
+		x0, d0 = x1, d1
+	}
+	return cs
+}
@@ -0,0 +1,36 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package benchls
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCrossovers(t *testing.T) {
+	xExprs, err := NewExpressions("N, 1.0", map[string]struct{}{"N": {}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 2N + 100 is more than 3N below N = 100, and less above it
+	cs := Crossovers(xExprs, Model{2, 100}, Model{3, 0}, "N", 1, 1e4)
+	if len(cs) != 1 {
+		t.Fatalf("expected one crossover, got %v", cs)
+	}
+	if c := cs[0]; math.Abs(c.At-100) > 1e-9 || math.Abs(c.Y-300) > 1e-6 || c.ALower {
+		t.Errorf("expected a crossover at 100 of 300 with b lower below it, got %+v", c)
+	}
+
+	if cs := Crossovers(xExprs, Model{2, 0}, Model{3, 0}, "N", 1, 1e4); len(cs) != 0 {
+		t.Errorf("expected no crossovers, got %v", cs)
+	}
+
+	// a linear scan through 0
+	cs = Crossovers(xExprs, Model{1, 0}, Model{-1, 0}, "N", -10, 10)
+	if len(cs) != 1 || math.Abs(cs[0].At) > 1e-9 || !cs[0].ALower {
+		t.Errorf("expected a crossover at 0 with a lower below it, got %v", cs)
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package benchls
+
+import (
+	"context"
+	"math"
+)
+
+// CrossValidation is the leave-one-out cross validated error of a least
+// squares fit, which, unlike R^2, grows when a model with few observations
+// fits them by chance.
+type CrossValidation struct {
+	PRESS float64 // predicted residual sum of squares
+	RMSE  float64 // root mean square of the predicted residuals
+	Rel   float64 // RMSE relative to the root mean square of the response
+}
+
+// LeaveOneOut returns the leave-one-out cross validated error of m, the least
+// squares fit of s, without refitting: the residual of each observation when
+// it is left out is its residual divided by 1 - its leverage.  The errors are
+// infinite if an observation has a leverage of 1, so that no fit without it
+// could predict it.
+func LeaveOneOut(m Model, s Sample) CrossValidation {
+	res, _ := Standardized(m, s)
+	press, yss := 0.0, 0.0
+	for i, h := range leverages(s) {
+		e := res[i] / (1 - h)
+		if h >= 1 {
+			e = math.Inf(1)
+		}
+		press += e * e
+		yss += s.Y[i] * s.Y[i]
+	}
+	return CrossValidation{
+		PRESS: press,
+		RMSE:  math.Sqrt(press / float64(len(s.Y))),
+		Rel:   math.Sqrt(press / yss),
+	}
+}
+
+// LeaveOneOutRefit returns the leave-one-out cross validated error of fit,
+// by refitting s without each observation in turn.  Unlike LeaveOneOut, it
+// is exact for fits whose weights depend on the data, like RobustContext.
+// The error of an observation is infinite if s cannot be fit without it.  It
+// stops refitting and returns ctx.Err() once ctx is done.
+func LeaveOneOutRefit(ctx context.Context, s Sample, fit func(context.Context, Sample) (Model, error)) (CrossValidation, error) {
+	if len(s.Y) == 0 {
+		return CrossValidation{}, nil
+	}
+	k := len(s.X) / len(s.Y)
+	press, yss := 0.0, 0.0
+	for i, y := range s.Y {
+		if err := ctx.Err(); err != nil {
+			return CrossValidation{}, err
+		}
+		out := Sample{
+			X: append(append([]float64(nil), s.X[:i*k]...), s.X[(i+1)*k:]...),
+			Y: append(append([]float64(nil), s.Y[:i]...), s.Y[i+1:]...),
+		}
+		e := math.Inf(1)
+		m, err := fit(ctx, out)
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return CrossValidation{}, ctxErr
+		}
+		if err == nil && m != nil {
+			e = y
+			for j, x := range s.X[i*k : (i+1)*k] {
+				e -= m[j] * x
+			}
+		}
+		press += e * e
+		yss += y * y
+	}
+	return CrossValidation{
+		PRESS: press,
+		RMSE:  math.Sqrt(press / float64(len(s.Y))),
+		Rel:   math.Sqrt(press / yss),
+	}, nil
+}
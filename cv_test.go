@@ -0,0 +1,80 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package benchls
+
+import (
+	"context"
+	"math"
+	"testing"
+)
+
+func TestLeaveOneOut(t *testing.T) {
+	var s Sample
+	for n := 1.0; n <= 8; n++ {
+		s.X = append(s.X, n, 1.0)
+		s.Y = append(s.Y, 2*n+1+0.1*math.Sin(n))
+	}
+	cv := LeaveOneOut(Estimate(s, Options{}), s)
+
+	// refit without each observation
+	press := 0.0
+	for i := range s.Y {
+		var out Sample
+		for j := range s.Y {
+			if j != i {
+				out.X = append(out.X, s.X[2*j:2*j+2]...)
+				out.Y = append(out.Y, s.Y[j])
+			}
+		}
+		m := Estimate(out, Options{})
+		e := s.Y[i] - m[0]*s.X[2*i] - m[1]
+		press += e * e
+	}
+	if math.Abs(cv.PRESS-press) > 1e-9*press {
+		t.Errorf("expected PRESS %g, got %g", press, cv.PRESS)
+	}
+	if want := math.Sqrt(press / 8); math.Abs(cv.RMSE-want) > 1e-9*want {
+		t.Errorf("expected RMSE %g, got %g", want, cv.RMSE)
+	}
+
+	// a line through two points predicts neither without the other
+	two := Sample{X: []float64{1, 1, 2, 1}, Y: []float64{1, 2}}
+	if cv := LeaveOneOut(Estimate(two, Options{}), two); !math.IsInf(cv.PRESS, 1) {
+		t.Errorf("expected an infinite PRESS, got %g", cv.PRESS)
+	}
+}
+
+func TestLeaveOneOutRefit(t *testing.T) {
+	var s Sample
+	for n := 1.0; n <= 8; n++ {
+		s.X = append(s.X, n, 1.0)
+		s.Y = append(s.Y, 2*n+1+0.1*math.Sin(n))
+	}
+	solve := func(ctx context.Context, s Sample) (Model, error) {
+		return Solve(s, Options{})
+	}
+
+	// least squares has the same errors either way
+	want := LeaveOneOut(Estimate(s, Options{}), s)
+	got, err := LeaveOneOutRefit(context.Background(), s, solve)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(got.PRESS-want.PRESS) > 1e-9*want.PRESS || math.Abs(got.Rel-want.Rel) > 1e-9*want.Rel {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+
+	// a line through two points cannot be fit to one
+	two := Sample{X: []float64{1, 1, 2, 1}, Y: []float64{1, 2}}
+	if cv, err := LeaveOneOutRefit(context.Background(), two, solve); err != nil || !math.IsInf(cv.PRESS, 1) {
+		t.Errorf("expected an infinite PRESS, got %g, %v", cv.PRESS, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := LeaveOneOutRefit(ctx, s, solve); err != context.Canceled {
+		t.Errorf("expected the refits to be canceled, got %v", err)
+	}
+}
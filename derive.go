@@ -0,0 +1,95 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package benchls
+
+import (
+	"math"
+	"strconv"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// CoefNames returns the names that quantities derived from the k coefficients
+// of a model use for them, b0, b1 and so on.
+func CoefNames(k int) map[string]struct{} {
+	names := make(map[string]struct{}, k)
+	for i := 0; i < k; i++ {
+		names["b"+strconv.Itoa(i)] = struct{}{}
+	}
+	return names
+}
+
+// Derived is a quantity derived from the coefficients of a fit, like the
+// ratio of two of them.
+type Derived struct {
+	Value float64
+	SE    float64 // standard error, by the delta method
+	CI    float64 // confidence interval half-width, at the Confidence of the Options
+}
+
+// Derive evaluates expr, in the coefficients b0, b1 and so on of m, which was
+// fit to s, and propagates their covariance to it to first order by the delta
+// method: its variance is g' cov g, where g is the gradient of expr, found by
+// central differences.  The covariance is that of the standard errors of
+// NewStats, and the interval is at the Confidence of opts.  The standard
+// error and interval are NaN if s has no residual degrees of freedom, or if
+// its terms are collinear.
+func Derive(expr Expression, m Model, s Sample, opts Options) Derived {
+	k := len(m)
+	vars := make(map[string]float64, k)
+	for i, b := range m {
+		vars["b"+strconv.Itoa(i)] = b
+	}
+	d := Derived{Value: expr.Eval(vars)}
+
+	g := make([]float64, k)
+	for i, b := range m {
+		name := "b" + strconv.Itoa(i)
+		h := math.Cbrt(epsilon) * math.Max(math.Abs(b), 1)
+		vars[name] = b + h
+		hi := expr.Eval(vars)
+		vars[name] = b - h
+		lo := expr.Eval(vars)
+		vars[name] = b
+		g[i] = (hi - lo) / (2 * h)
+	}
+
+	dof := len(s.Y) - k
+	cov := covariance(m, s, opts)
+	if dof < 1 || cov == nil {
+		d.SE, d.CI = math.NaN(), math.NaN()
+		return d
+	}
+	gv := mat64.NewVector(k, g)
+	d.SE = math.Sqrt(mat64.Inner(gv, cov, gv))
+	d.CI = conf(opts.level(), d.SE, dof)
+	return d
+}
+
+// epsilon is the machine epsilon of float64.
+const epsilon = 2.220446049250313e-16
+
+// covariance returns the covariance of the coefficients of m, which was fit to
+// s: mse (X'X)^-1, or with opts.HC1, the sandwich.  It is nil if X'X cannot
+// be inverted.
+func covariance(m Model, s Sample, opts Options) *mat64.Dense {
+	k := len(m)
+	X := mat64.NewDense(len(s.Y), k, s.X)
+	XTX := mat64.NewDense(k, k, nil)
+	XTX.Mul(X.T(), X)
+	if err := XTX.Inverse(XTX); err != nil {
+		return nil
+	}
+	if opts.HC1 {
+		return sandwich(m, s, XTX)
+	}
+	res, _ := Standardized(m, s)
+	RSS := 0.0
+	for _, e := range res {
+		RSS += e * e
+	}
+	XTX.Scale(RSS/float64(len(s.Y)-k), XTX)
+	return XTX
+}
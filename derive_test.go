@@ -0,0 +1,52 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package benchls
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDerive(t *testing.T) {
+	var s Sample
+	for n := 1.0; n <= 10; n++ {
+		s.X = append(s.X, n, 1.0)
+		s.Y = append(s.Y, 4*n+20+math.Sin(n))
+	}
+	fit := NewFit(s, Options{})
+	vars := CoefNames(2)
+
+	// a coefficient itself has its own standard error
+	b1, err := NewExpression("2 * b1", vars, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := Derive(b1, fit.Model, s, Options{})
+	if math.Abs(d.Value-2*fit.Model[1]) > 1e-12 || math.Abs(d.CI-2*fit.Stats.CI[1]) > 1e-6*d.CI {
+		t.Errorf("expected %g±%g, got %g±%g", 2*fit.Model[1], 2*fit.Stats.CI[1], d.Value, d.CI)
+	}
+
+	// the ratio has a relative variance that is the sum of the relative
+	// variances and covariance of its terms
+	ratio, err := NewExpression("b1 / b0", vars, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d = Derive(ratio, fit.Model, s, Options{})
+	cov := covariance(fit.Model, s, Options{})
+	b0, b1v := fit.Model[0], fit.Model[1]
+	r := b1v / b0
+	want := math.Abs(r) * math.Sqrt(cov.At(0, 0)/(b0*b0)+cov.At(1, 1)/(b1v*b1v)-2*cov.At(0, 1)/(b0*b1v))
+	if math.Abs(d.Value-r) > 1e-12 || math.Abs(d.SE-want) > 1e-6*want {
+		t.Errorf("expected %g with se %g, got %g with se %g", r, want, d.Value, d.SE)
+	}
+
+	// the second term is twice the first, so X'X is singular
+	s = Sample{X: []float64{1, 2, 2, 4, 3, 6, 4, 8}, Y: []float64{1, 2, 3, 4}}
+	d = Derive(b1, Model{1, 0}, s, Options{})
+	if d.Value != 0 || !math.IsNaN(d.SE) || !math.IsNaN(d.CI) {
+		t.Errorf("expected 0 with no interval for collinear terms, got %g with se %g and interval %g", d.Value, d.SE, d.CI)
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package benchls
+
+import "math"
+
+// tCDF is the cumulative distribution function of Student's t distribution.
+func tCDF(t float64, dof int) float64 {
+	nu := float64(dof)
+	p := 0.5 * betaInc(nu/2, 0.5, nu/(nu+t*t))
+	if t > 0 {
+		return 1 - p
+	}
+	return p
+}
+
+// tTwoSided is the probability of a t statistic at least as extreme as t.
+func tTwoSided(t float64, dof int) float64 {
+	nu := float64(dof)
+	return betaInc(nu/2, 0.5, nu/(nu+t*t))
+}
+
+// fSurvival is the probability that an F distributed variable with d1 and d2
+// degrees of freedom exceeds f.
+func fSurvival(f float64, d1, d2 int) float64 {
+	if f <= 0 {
+		return 1
+	}
+	a, b := float64(d1), float64(d2)
+	return betaInc(b/2, a/2, b/(b+a*f))
+}
+
+// betaInc is the regularized incomplete beta function I_x(a, b).
+func betaInc(a, b, x float64) float64 {
+	switch {
+	case x <= 0:
+		return 0
+	case x >= 1:
+		return 1
+	}
+	la, _ := math.Lgamma(a)
+	lb, _ := math.Lgamma(b)
+	lab, _ := math.Lgamma(a + b)
+	front := math.Exp(lab - la - lb + a*math.Log(x) + b*math.Log(1-x))
+	// the continued fraction converges quickly below the mean
+	if x < (a+1)/(a+b+2) {
+		return front * betaCF(a, b, x) / a
+	}
+	return 1 - front*betaCF(b, a, 1-x)/b
+}
+
+// betaCF evaluates the continued fraction of the incomplete beta function by
+// the modified Lentz method.
+func betaCF(a, b, x float64) float64 {
+	const (
+		maxIter = 300
+		eps     = 1e-15
+		tiny    = 1e-300
+	)
+	c, d := 1.0, 1-(a+b)*x/(a+1)
+	if math.Abs(d) < tiny {
+		d = tiny
+	}
+	d = 1 / d
+	h := d
+	for m := 1; m <= maxIter; m++ {
+		fm := float64(m)
+		// even step
+		num := fm * (b - fm) * x / ((a + 2*fm - 1) * (a + 2*fm))
+		d = 1 + num*d
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = 1 + num/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		h *= d * c
+		// odd step
+		num = -(a + fm) * (a + b + fm) * x / ((a + 2*fm) * (a + 2*fm + 1))
+		d = 1 + num*d
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = 1 + num/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		del := d * c
+		h *= del
+		if math.Abs(del-1) < eps {
+			break
+		}
+	}
+	return h
+}
@@ -0,0 +1,40 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package benchls
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDistributions(t *testing.T) {
+	// critical values from the NIST t distribution tables, at
+	// http://www.itl.nist.gov/div898/handbook/eda/section3/eda3672.htm
+	for dof, c := range map[int]float64{1: 12.706, 5: 2.571, 10: 2.228, 30: 2.042} {
+		if p := tCDF(c, dof); math.Abs(p-0.975) > 1e-4 {
+			t.Errorf("t CDF of %g with %d dof: expected 0.975, got %g", c, dof, p)
+		}
+		if p := tTwoSided(-c, dof); math.Abs(p-0.05) > 2e-4 {
+			t.Errorf("two sided p of %g with %d dof: expected 0.05, got %g", -c, dof, p)
+		}
+	}
+	for _, test := range []struct {
+		level float64
+		dof   int
+		want  float64
+	}{
+		{0.95, 10, 2.228},
+		{0.99, 10, 3.169},
+		{0.90, 30, 1.697},
+	} {
+		if got := conf(test.level, 1, test.dof); math.Abs(got-test.want) > 1e-3 {
+			t.Errorf("%g interval with %d dof: expected %g, got %g", test.level, test.dof, test.want, got)
+		}
+	}
+	// 95th percentile of F(5, 10) is 3.326
+	if p := fSurvival(3.326, 5, 10); math.Abs(p-0.05) > 1e-4 {
+		t.Errorf("F survival: expected 0.05, got %g", p)
+	}
+}
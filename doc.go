@@ -0,0 +1,31 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package benchls computes least squares fits on groups of parameterized
+// benchmarks.
+//
+// ReadSet reads the benchmark results of go test output into a Set.  They are
+// grouped, and their input variables found, by an Extractor.  SampleGroup evaluates the explanatory and response Expressions
+// for each benchmark to produce a Sample per group, which NewFit fits:
+//
+//	benchSet, err := benchls.ReadSet(r)
+//	...
+//	ex := benchls.RegexpExtractor{Regexp: regexp.MustCompile(`/?(?P<N>\d+)-\d+$`)}
+//	names := ex.VarNames()
+//	xExprs, err := benchls.NewExpressions("N * math.Log(N), 1.0", names, nil)
+//	...
+//	names["Y"] = struct{}{}
+//	yExpr, err := benchls.NewExpression("Y", names, nil)
+//	...
+//	for group, s := range benchls.SampleGroup(benchSet, ex, xExprs, yExpr, "NsPerOp") {
+//		if fit := benchls.NewFit(s, benchls.Options{}); fit != nil {
+//			fmt.Println(group, fit.Model, fit.Stats.RSquared)
+//		}
+//	}
+//
+// An Online fit is updated as each observation arrives, without refitting the
+// observations before it.
+//
+// The benchls command, in cmd/benchls, reports the fits as a table.
+package benchls
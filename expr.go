@@ -0,0 +1,532 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package benchls
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/scanner"
+	"go/token"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jonlawlor/parsefloat"
+)
+
+// Expression is a parsefloat expression extended with constructs that
+// parsefloat cannot evaluate by itself.  Each such construct is replaced by an
+// auxiliary variable, which is computed before the rewritten expression is
+// evaluated.  parsefloat checks the rewritten expression, which is then
+// compiled to a closure.
+type Expression struct {
+	src    string // the Expression as written, after formatting
+	fn     evalFunc
+	aux    []auxVar
+	vars   map[string]struct{} // the named variables it was parsed with
+	consts map[string]float64  // and the constants
+}
+
+// ErrInvalidExpression and ErrUnknownVariable are the causes of the
+// ExprErrors of expressions that cannot be parsed.
+var (
+	ErrInvalidExpression = errors.New("invalid expression")
+	ErrUnknownVariable   = errors.New("unknown variable")
+)
+
+// ExprError records why an expression could not be parsed.
+type ExprError struct {
+	Expr string // the expression as written
+	Err  error  // ErrInvalidExpression or ErrUnknownVariable
+	Msg  string // what is wrong with it
+}
+
+func (e *ExprError) Error() string {
+	return e.Expr + ": " + e.Msg
+}
+
+// Unwrap returns the cause of the error, Err.
+func (e *ExprError) Unwrap() error {
+	return e.Err
+}
+
+// exprError returns err as an *ExprError in src.  Errors that are not
+// ExprErrors already are ErrInvalidExpression.
+func exprError(src string, err error) error {
+	e, ok := err.(*ExprError)
+	if !ok {
+		e = &ExprError{Err: ErrInvalidExpression, Msg: err.Error()}
+	}
+	if e.Expr == "" {
+		e.Expr = src
+	}
+	return e
+}
+
+// auxVar is a value substituted into a rewritten expression.
+type auxVar struct {
+	name string
+	eval func(vars map[string]float64) float64
+}
+
+// String returns the expression as it was written.
+func (e Expression) String() string {
+	return e.src
+}
+
+// Eval evaluates the expression, adding any auxiliary variables to vars.
+func (e Expression) Eval(vars map[string]float64) float64 {
+	for _, a := range e.aux {
+		vars[a.name] = a.eval(vars)
+	}
+	return e.fn(vars)
+}
+
+// multiFuncs are the math functions with more than one return value.  By
+// default the first return value is used; the others can be selected by
+// indexing the call, as in math.Frexp(N)[1].
+var multiFuncs = map[string]func(float64) []float64{
+	"Frexp": func(x float64) []float64 {
+		frac, exp := math.Frexp(x)
+		return []float64{frac, float64(exp)}
+	},
+	"Lgamma": func(x float64) []float64 {
+		lgamma, sign := math.Lgamma(x)
+		return []float64{lgamma, float64(sign)}
+	},
+	"Modf": func(x float64) []float64 {
+		i, frac := math.Modf(x)
+		return []float64{i, frac}
+	},
+	"Sincos": func(x float64) []float64 {
+		sin, cos := math.Sincos(x)
+		return []float64{sin, cos}
+	},
+}
+
+// shorthands are identifiers which expand to common basis terms of a named
+// variable, so that with a variable N, NlogN means N * math.Log(N).  A named
+// variable always takes precedence over a shorthand with the same name.
+var shorthands = []struct {
+	name, expansion string
+}{
+	{"%[1]slog%[1]s", "%[1]s * math.Log(%[1]s)"},
+	{"log%s", "math.Log(%s)"},
+	{"log2%s", "math.Log2(%s)"},
+	{"sqrt%s", "math.Sqrt(%s)"},
+	{"%s2", "%[1]s * %[1]s"},
+	{"%s3", "%[1]s * %[1]s * %[1]s"},
+}
+
+// constant returns the value of name as a literal, if it is a constant.
+func (rw *rewriter) constant(name string) (ast.Expr, bool) {
+	if _, ok := rw.vars[name]; ok {
+		return nil, false
+	}
+	v, ok := rw.consts[name]
+	if !ok {
+		return nil, false
+	}
+	n, err := parser.ParseExpr("(" + strconv.FormatFloat(v, 'g', -1, 64) + ")")
+	if err != nil {
+		return nil, false
+	}
+	return n, true
+}
+
+// shorthand returns the expansion of name, if it is a shorthand.
+func (rw *rewriter) shorthand(name string) (ast.Expr, bool) {
+	if _, ok := rw.vars[name]; ok {
+		return nil, false
+	}
+	for v := range rw.vars {
+		if v == "" {
+			continue
+		}
+		for _, sh := range shorthands {
+			if fmt.Sprintf(sh.name, v) == name {
+				n, err := parser.ParseExpr(fmt.Sprintf(sh.expansion, v))
+				if err != nil {
+					// v is not an identifier, so it has no shorthands
+					break
+				}
+				return &ast.ParenExpr{X: n}, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// Interactions returns the explanatory terms of a model with every product
+// of up to order distinct variables, highest order first, and an intercept.
+// For variables M and N and order 2, that is "M * N, M, N, 1.0".
+func Interactions(vars []string, order int) string {
+	vars = append([]string(nil), vars...)
+	sort.Strings(vars)
+	var terms []string
+	for k := order; k > 0; k-- {
+		// each combination of k variables, in lexical order
+		var combine func(start int, chosen []string)
+		combine = func(start int, chosen []string) {
+			if len(chosen) == k {
+				terms = append(terms, strings.Join(chosen, " * "))
+				return
+			}
+			for i := start; i < len(vars); i++ {
+				combine(i+1, append(chosen, vars[i]))
+			}
+		}
+		combine(0, nil)
+	}
+	return strings.Join(append(terms, "1.0"), ", ")
+}
+
+// NewExpression parses a single expression in the named variables and
+// constants.  Constants are named values, like a page or cache line size, that
+// are substituted into the expression as it is parsed, and consts may be nil.
+// Named variables take precedence over constants.  The error, if any, is an
+// *ExprError.
+func NewExpression(src string, vars map[string]struct{}, consts map[string]float64) (Expression, error) {
+	rewritten, err := operators(src)
+	if err != nil {
+		return Expression{}, exprError(src, err)
+	}
+	n, err := parser.ParseExpr(rewritten)
+	if err != nil {
+		return Expression{}, exprError(src, err)
+	}
+	e, err := (&rewriter{vars: vars, consts: consts}).parse(n)
+	if err != nil {
+		return Expression{}, exprError(src, err)
+	}
+	return e, nil
+}
+
+// NewExpressions parses a comma separated list of expressions in the named
+// variables and constants, as NewExpression does.  The error, if any, is an
+// *ExprError.
+func NewExpressions(src string, vars map[string]struct{}, consts map[string]float64) ([]Expression, error) {
+	rewritten, err := operators(src)
+	if err != nil {
+		return nil, exprError(src, err)
+	}
+	n, err := parser.ParseExpr("float64{" + rewritten + "}")
+	if err != nil {
+		return nil, exprError(src, err)
+	}
+	lit, ok := n.(*ast.CompositeLit)
+	if !ok {
+		return nil, exprError(src, errors.New("invalid Expression list: "+src))
+	}
+	rw := &rewriter{vars: vars, consts: consts}
+	exprs := make([]Expression, len(lit.Elts))
+	for i, elt := range lit.Elts {
+		if exprs[i], err = rw.parse(elt); err != nil {
+			return nil, exprError(src, err)
+		}
+	}
+	return exprs, nil
+}
+
+// operators rewrites the operators in src that Go does not have.
+func operators(src string) (string, error) {
+	src, err := ternaries(src)
+	if err != nil {
+		return "", err
+	}
+	return powers(src)
+}
+
+// tok is a token of an expression, and where it is in the source.
+type tok struct {
+	tok      token.Token
+	off, end int
+}
+
+// powers rewrites the exponent operators in src, a^b and a**b, as
+// math.Pow(a, b).  They bind more tightly than any other operator, including
+// unary minus, so -N^2 is -(N^2), and they group to the right, so 2^3^2 is
+// 2^9.
+func powers(src string) (string, error) {
+	for {
+		toks := scan(src)
+		op := -1
+		for i, t := range toks {
+			if t.tok == token.XOR {
+				op = i
+			}
+		}
+		if op < 0 {
+			return src, nil
+		}
+		// the rightmost operator comes first, so that they group to the right
+		lo, ok := leftOperand(toks, op)
+		if !ok {
+			return "", fmt.Errorf("missing base of exponent in %s", src)
+		}
+		hi, ok := rightOperand(toks, op)
+		if !ok {
+			return "", fmt.Errorf("missing exponent in %s", src)
+		}
+		src = src[:toks[lo].off] + "math.Pow(" + src[toks[lo].off:toks[op-1].end] + ", " +
+			src[toks[op+1].off:toks[hi].end] + ")" + src[toks[hi].end:]
+	}
+}
+
+// scan tokenizes src, with ** as a single XOR token.
+func scan(src string) []tok {
+	var s scanner.Scanner
+	fset := token.NewFileSet()
+	file := fset.AddFile("", fset.Base(), len(src))
+	s.Init(file, []byte(src), nil, 0)
+	var toks []tok
+	for {
+		pos, t, lit := s.Scan()
+		if t == token.EOF {
+			return toks
+		}
+		if t == token.SEMICOLON && lit == "\n" {
+			continue // inserted automatically
+		}
+		off := file.Offset(pos)
+		end := off + len(t.String())
+		if lit != "" {
+			end = off + len(lit)
+		}
+		if n := len(toks); t == token.MUL && n > 0 && toks[n-1].tok == token.MUL && toks[n-1].end == off {
+			toks[n-1] = tok{token.XOR, toks[n-1].off, end}
+			continue
+		}
+		toks = append(toks, tok{t, off, end})
+	}
+}
+
+// leftOperand returns the index of the first token of the operand before
+// toks[i]: a literal, or a variable, call or parenthesized expression,
+// possibly indexed.
+func leftOperand(toks []tok, i int) (int, bool) {
+	j := i - 1
+	for j >= 0 {
+		switch toks[j].tok {
+		case token.INT, token.FLOAT:
+			return j, true
+		case token.IDENT:
+			// include package selectors, like math.Log
+			for j >= 2 && toks[j-1].tok == token.PERIOD && toks[j-2].tok == token.IDENT {
+				j -= 2
+			}
+			return j, true
+		case token.RPAREN, token.RBRACK:
+			if j = match(toks, j, -1); j < 0 {
+				return 0, false
+			}
+			// a call or index includes what is called or indexed
+			if j > 0 {
+				switch toks[j-1].tok {
+				case token.IDENT, token.RPAREN, token.RBRACK:
+					j--
+					continue
+				}
+			}
+			return j, true
+		default:
+			return 0, false
+		}
+	}
+	return 0, false
+}
+
+// rightOperand returns the index of the last token of the operand after
+// toks[i], which may have a sign.
+func rightOperand(toks []tok, i int) (int, bool) {
+	j := i + 1
+	for j < len(toks) && (toks[j].tok == token.SUB || toks[j].tok == token.ADD) {
+		j++
+	}
+	if j >= len(toks) {
+		return 0, false
+	}
+	switch toks[j].tok {
+	case token.INT, token.FLOAT:
+	case token.IDENT:
+		for j+2 < len(toks) && toks[j+1].tok == token.PERIOD && toks[j+2].tok == token.IDENT {
+			j += 2
+		}
+	case token.LPAREN:
+		if j = match(toks, j, 1); j < 0 {
+			return 0, false
+		}
+	default:
+		return 0, false
+	}
+	// calls and indexes
+	for j+1 < len(toks) && (toks[j+1].tok == token.LPAREN || toks[j+1].tok == token.LBRACK) {
+		if j = match(toks, j+1, 1); j < 0 {
+			return 0, false
+		}
+	}
+	return j, true
+}
+
+// match returns the index of the bracket that matches toks[i], searching in
+// direction dir, or -1 if there is none.
+func match(toks []tok, i, dir int) int {
+	depth := 0
+	for ; i >= 0 && i < len(toks); i += dir {
+		switch toks[i].tok {
+		case token.LPAREN, token.LBRACK, token.LBRACE:
+			depth += dir
+		case token.RPAREN, token.RBRACK, token.RBRACE:
+			depth -= dir
+		}
+		if depth == 0 {
+			return i
+		}
+	}
+	return -1
+}
+
+// rewriter replaces unsupported constructs with auxiliary variables.  The
+// same rewriter is used for all of the expressions in a list so that the
+// auxiliary variable names are unique.
+type rewriter struct {
+	vars   map[string]struct{}
+	consts map[string]float64
+	n      int
+}
+
+func (rw *rewriter) parse(n ast.Expr) (Expression, error) {
+	e := Expression{src: unternary(format(n)), vars: rw.vars, consts: rw.consts}
+	n, err := rw.rewrite(n, &e.aux)
+	if err != nil {
+		return Expression{}, err
+	}
+	vars := make(map[string]struct{}, len(rw.vars)+len(e.aux))
+	for v := range rw.vars {
+		vars[v] = struct{}{}
+	}
+	for _, a := range e.aux {
+		vars[a.name] = struct{}{}
+	}
+	if err = checkArity(n); err != nil {
+		return Expression{}, err
+	}
+	if _, err = parsefloat.New(format(n), vars); err != nil {
+		return Expression{}, err
+	}
+	if e.fn, err = compile(n); err != nil {
+		return Expression{}, err
+	}
+	return e, nil
+}
+
+// newAux returns an identifier for a new auxiliary variable.
+func (rw *rewriter) newAux(aux *[]auxVar, eval func(map[string]float64) float64) *ast.Ident {
+	name := fmt.Sprintf("_aux%d", rw.n)
+	rw.n++
+	*aux = append(*aux, auxVar{name: name, eval: eval})
+	return ast.NewIdent(name)
+}
+
+// rewrite walks n and returns it with every unsupported construct replaced.
+// The auxiliary variables are appended to aux in evaluation order.
+func (rw *rewriter) rewrite(n ast.Expr, aux *[]auxVar) (ast.Expr, error) {
+	var err error
+	switch n := n.(type) {
+	case *ast.Ident:
+		if c, ok := rw.constant(n.Name); ok {
+			return c, nil
+		}
+		if sh, ok := rw.shorthand(n.Name); ok {
+			return sh, nil
+		}
+		if _, ok := rw.vars[n.Name]; !ok {
+			return nil, &ExprError{Err: ErrUnknownVariable, Msg: "unknown variable " + n.Name}
+		}
+	case *ast.IndexExpr:
+		if call, ok := n.X.(*ast.CallExpr); ok && multiFunc(call) != "" {
+			lit, ok := n.Index.(*ast.BasicLit)
+			if !ok || lit.Kind != token.INT {
+				return nil, errors.New("result index must be an integer literal: " + format(n))
+			}
+			i, err := strconv.Atoi(lit.Value)
+			if err != nil {
+				return nil, &ExprError{Err: ErrInvalidExpression, Msg: "invalid result index: " + format(n)}
+			}
+			return rw.multi(call, i, aux)
+		}
+	case *ast.CallExpr:
+		if multiFunc(n) != "" {
+			return rw.multi(n, 0, aux)
+		}
+		if id, ok := n.Fun.(*ast.Ident); ok && id.Name == ifFunc {
+			return rw.ifCall(n, aux)
+		}
+		for i, arg := range n.Args {
+			if n.Args[i], err = rw.rewrite(arg, aux); err != nil {
+				return nil, err
+			}
+		}
+	case *ast.ParenExpr:
+		n.X, err = rw.rewrite(n.X, aux)
+	case *ast.UnaryExpr:
+		n.X, err = rw.rewrite(n.X, aux)
+	case *ast.BinaryExpr:
+		if n.X, err = rw.rewrite(n.X, aux); err != nil {
+			return nil, err
+		}
+		n.Y, err = rw.rewrite(n.Y, aux)
+	}
+	return n, err
+}
+
+// multi replaces a call of a multiple return math function with an auxiliary
+// variable holding its i'th result.
+func (rw *rewriter) multi(call *ast.CallExpr, i int, aux *[]auxVar) (ast.Expr, error) {
+	name := multiFunc(call)
+	if len(call.Args) != 1 {
+		return nil, errors.New("math." + name + " takes one argument: " + format(call))
+	}
+	f := multiFuncs[name]
+	if n := len(f(0)); i < 0 || i >= n {
+		return nil, fmt.Errorf("math.%s has %d results, cannot select result %d", name, n, i)
+	}
+	arg, err := rw.parse(call.Args[0])
+	if err != nil {
+		return nil, err
+	}
+	*aux = append(*aux, arg.aux...)
+	return rw.newAux(aux, func(vars map[string]float64) float64 {
+		return f(arg.fn(vars))[i]
+	}), nil
+}
+
+// multiFunc returns the name of the multiple return math function called by
+// call, or "" if it is some other function.
+func multiFunc(call *ast.CallExpr) string {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return ""
+	}
+	if pkg, ok := sel.X.(*ast.Ident); !ok || pkg.Name != "math" {
+		return ""
+	}
+	if _, ok := multiFuncs[sel.Sel.Name]; !ok {
+		return ""
+	}
+	return sel.Sel.Name
+}
+
+// format prints an expression the way gofmt would.
+func format(n ast.Expr) string {
+	var buf bytes.Buffer
+	printer.Fprint(&buf, token.NewFileSet(), n)
+	return buf.String()
+}
@@ -0,0 +1,113 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package benchls
+
+import (
+	"math"
+	"testing"
+)
+
+func TestExpression(t *testing.T) {
+	names := map[string]struct{}{"N": struct{}{}}
+	vars := map[string]float64{"N": 2.5}
+	for _, test := range []struct {
+		src  string
+		want float64
+	}{
+		{"N * 2", 5},
+		{"math.Modf(N)", 2},
+		{"math.Modf(N)[1]", 0.5},
+		{"math.Lgamma(N + 1)", math.Log(math.Gamma(3.5))},
+		{"math.Frexp(N)[1] + math.Log(N)", 2 + math.Log(2.5)},
+		{"math.Frexp(math.Modf(N * 2)[0])[0]", 0.625},
+		{"NlogN", 2.5 * math.Log(2.5)},
+		{"logN + log2N", math.Log(2.5) + math.Log2(2.5)},
+		{"sqrtN * N2 - N3", math.Sqrt(2.5)*2.5*2.5 - 2.5*2.5*2.5},
+		{"N^3", 2.5 * 2.5 * 2.5},
+		{"2*N**2 + 1", 2*2.5*2.5 + 1},
+		{"-N^2", -2.5 * 2.5},
+		{"2^3^2", 512},
+		{"2^-N", math.Pow(2, -2.5)},
+		{"(N + 1)^2 / math.Log(N)^2", 3.5 * 3.5 / (math.Log(2.5) * math.Log(2.5))},
+		{"math.Frexp(N)[1]^N", math.Pow(2, 2.5)},
+		{"N < 1024 ? N : N*math.Log(N)", 2.5},
+		{"N > 2 && N != 3 ? 1 : 2", 1},
+		{"!(N >= 2) || N == 0 ? 1 : N < 1 ? 2 : 3", 3},
+		{"2 * (N <= 2.5 ? N^2 : 0) + 1", 2*2.5*2.5 + 1},
+		{"math.Max(N > 1 ? N : 1, math.Modf(N)[1])", 2.5},
+	} {
+		e, err := NewExpression(test.src, names, nil)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", test.src, err)
+			continue
+		}
+		if got := e.Eval(vars); math.Abs(got-test.want) > 1e-12 {
+			t.Errorf("%s: expected %g, got %g", test.src, test.want, got)
+		}
+	}
+
+	for _, src := range []string{"math.Modf(N)[2]", "math.Frexp(N)[N]", "math.Frexp(N)[99999999999999999999]", "math.Lgamma(N, N)", "^N", "N^", "N^*2", "N ? 1", "N > 1 ? : 2", "N ? 1 : 2", "N > (1 ? 2 : 3"} {
+		_, err := NewExpression(src, names, nil)
+		if e, ok := err.(*ExprError); !ok || e.Err != ErrInvalidExpression || e.Expr != src {
+			t.Errorf("%s: expected an invalid expression, got %v", src, err)
+		}
+	}
+	for _, src := range []string{"M", "N > M ? 1 : 2", "math.Modf(M)[1]"} {
+		_, err := NewExpressions(src+", 1.0", names, nil)
+		if e, ok := err.(*ExprError); !ok || e.Err != ErrUnknownVariable {
+			t.Errorf("%s: expected an unknown variable, got %v", src, err)
+		}
+	}
+}
+
+func TestTernary(t *testing.T) {
+	for _, test := range []struct {
+		src, want string
+	}{
+		{"N < 1024 ? N : N*math.Log(N)", "N < 1024 ? N : N*math.Log(N)"},
+		{"1 + (N < 2 ? 1 : N > 4 ? 2 : 3)", "1 + (N < 2 ? 1 : N > 4 ? 2 : 3)"},
+		{"2*(N < 2 ? 1 : 2)^2", "2 * math.Pow((N < 2 ? 1 : 2), 2)"},
+	} {
+		e, err := NewExpression(test.src, map[string]struct{}{"N": {}}, nil)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", test.src, err)
+			continue
+		}
+		if got := e.String(); got != test.want {
+			t.Errorf("%s: expected %s, got %s", test.src, test.want, got)
+		}
+	}
+}
+
+func TestConstants(t *testing.T) {
+	consts := map[string]float64{"B": 4096, "C": -64}
+	e, err := NewExpression("N/B + C", map[string]struct{}{"N": {}}, consts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := e.Eval(map[string]float64{"N": 8192}), 2.0-64; got != want {
+		t.Errorf("expected %g, got %g", want, got)
+	}
+	if got := e.String(); got != "N/B + C" {
+		t.Errorf("expected the constants to be shown by name, got %s", got)
+	}
+}
+
+func TestInteractions(t *testing.T) {
+	for _, test := range []struct {
+		vars  []string
+		order int
+		want  string
+	}{
+		{[]string{"N", "M"}, 2, "M * N, M, N, 1.0"},
+		{[]string{"N", "M"}, 1, "M, N, 1.0"},
+		{[]string{"K", "M", "N"}, 2, "K * M, K * N, M * N, K, M, N, 1.0"},
+		{[]string{"K", "M", "N"}, 5, "K * M * N, K * M, K * N, M * N, K, M, N, 1.0"},
+	} {
+		if got := Interactions(test.vars, test.order); got != test.want {
+			t.Errorf("%v order %d: expected %q, got %q", test.vars, test.order, test.want, got)
+		}
+	}
+}
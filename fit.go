@@ -2,108 +2,182 @@
 // Use of this source code is governed by a BSD-style
 // license that can be found in the LICENSE file.
 
-package main
+package benchls
 
 import (
-	"log"
+	"context"
+	"errors"
+	"fmt"
 	"math"
-	"regexp"
-	"strconv"
+	"sort"
 	"strings"
 
 	"github.com/gonum/blas"
 	"github.com/gonum/blas/blas64"
 	"github.com/gonum/lapack/lapack64"
 	"github.com/gonum/matrix/mat64"
-	"github.com/jonlawlor/parsefloat"
-	"golang.org/x/tools/benchmark/parse"
 )
 
-type samp struct {
-	x []float64 // explanatory
-	y []float64 // response
+// Sample is the data for one group of benchmarks.  Each observation has a
+// row of explanatory terms in X, in row major order, and a response in Y.
+type Sample struct {
+	X     []float64            // explanatory
+	Y     []float64            // response
+	Names []string             // benchmark name of each observation
+	Vars  []map[string]float64 // input variables of each observation
+	CPUs  []string             // distinct cpu configurations the sample was measured on
+	Pkgs  []string             // distinct packages of the benchmarks
+
+	// observed range of each named input variable
+	Min, Max map[string]float64
 }
 
-// sampleGroup finds the samples in the benchmark.  The resulting samp x and y will
-// not be in a stable order.
-func sampleGroup(benchSet parse.Set, inre *regexp.Regexp, xExprs []parsefloat.Expression, yExpr parsefloat.Expression, yVar string) map[string]samp {
-	samps := make(map[string]samp)
-Bench:
-	for name, bs := range benchSet {
+// Responses are the benchmark fields that can be used as a response.
+var Responses = []string{"NsPerOp", "AllocedBytesPerOp", "AllocsPerOp", "MBPerS", "BytesPerOp"}
+
+// SampleGroup finds the samples in the benchmarks, by group.  The explanatory
+// terms are xExprs and the response is yExpr, evaluated with the variables
+// found by ex and with Y set to the Response yVar.  Results without that
+// response are left out.  The observations are ordered by benchmark name, so
+// that seeded subsampling is reproducible.
+func SampleGroup(benchSet Set, ex Extractor, xExprs []Expression, yExpr Expression, yVar string) map[string]Sample {
+	names := make([]string, 0, len(benchSet))
+	for name := range benchSet {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	samps := make(map[string]Sample)
+	for _, name := range names {
+		rs := benchSet[name]
 		// determine if we can find input variables to construct x and y
-		input := inre.FindStringSubmatch(name)
-		if input == nil {
+		groupName, vars, ok := ex.Extract(name)
+		if !ok {
 			continue
 		}
-		// create the group name from whatever didn't match
-		groupName := strings.TrimRight(name, input[0])
 
-		// convert input string matches into a variable map
-		vars := make(map[string]float64)
-		for i, varname := range inre.SubexpNames() {
-			if i == 0 {
+		s := samps[groupName]
+		if s.Min == nil {
+			s.Min = make(map[string]float64)
+			s.Max = make(map[string]float64)
+		}
+		for varname, v := range vars {
+			if varname == "" {
 				continue
 			}
-			val, err := strconv.ParseFloat(input[i], 64)
-			if err != nil {
-				log.Println("non numeric string in \"" + name + "\": " + input[i] + ", skipping.")
-				continue Bench
+			if lo, ok := s.Min[varname]; !ok || v < lo {
+				s.Min[varname] = v
+			}
+			if hi, ok := s.Max[varname]; !ok || v > hi {
+				s.Max[varname] = v
 			}
-			vars[varname] = val
 		}
 
-		// eval x
-		x := make([]float64, len(xExprs))
-		for i, xExpr := range xExprs {
-			x[i] = xExpr.Eval(vars)
+		// the GOMAXPROCS suffix is available as P, unless P is captured
+		if _, exists := vars["P"]; !exists {
+			vars["P"] = 1 // go test omits the suffix when GOMAXPROCS is 1
+			if p, ok := procs(name); ok {
+				vars["P"] = p
+			}
 		}
 
-		s := samps[groupName]
-		for _, b := range bs {
-			// add "Y" to the vars
-			switch yVar {
-			case "NsPerOp":
-				vars["Y"] = b.NsPerOp
-			case "AllocedBytesPerOp":
-				vars["Y"] = float64(b.AllocedBytesPerOp)
-			case "AllocsPerOp":
-				vars["Y"] = float64(b.AllocsPerOp)
-			case "MBPerS":
-				vars["Y"] = b.MBPerS
-			default:
-				panic("unknown YVar: " + yVar)
+		for _, res := range rs {
+			resp, ok := Response(res, yVar)
+			if !ok {
+				continue
+			}
+			if cpu := res.GetConfig("cpu"); cpu != "" && !contains(s.CPUs, cpu) {
+				s.CPUs = append(s.CPUs, cpu)
+			}
+			if pkg := res.GetConfig("pkg"); pkg != "" && !contains(s.Pkgs, pkg) {
+				s.Pkgs = append(s.Pkgs, pkg)
+			}
+
+			// the bytes processed per op are implied by b.SetBytes' MB/s
+			vars["BytesPerOp"] = bytesPerOp(res)
+			vars["TotalBytes"] = vars["BytesPerOp"] * float64(res.Iters)
+
+			// keep the inputs before the expressions add to them
+			inputs := make(map[string]float64, len(vars))
+			for k, v := range vars {
+				inputs[k] = v
 			}
 
+			// eval x
+			x := make([]float64, len(xExprs))
+			for i, xExpr := range xExprs {
+				x[i] = xExpr.Eval(vars)
+			}
+
+			// add "Y" to the vars
+			vars["Y"] = resp
+
 			// eval y
 			y := yExpr.Eval(vars)
-			s.x = append(s.x, x...)
-			s.y = append(s.y, y)
+			s.X = append(s.X, x...)
+			s.Y = append(s.Y, y)
+			s.Names = append(s.Names, name)
+			s.Vars = append(s.Vars, inputs)
 		}
 		samps[groupName] = s
 	}
 	return samps
 }
 
-// model contains the model parameters
-type model []float64
+func contains(ss []string, s string) bool {
+	for _, t := range ss {
+		if t == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Model contains the model parameters, one per explanatory term.
+type Model []float64
+
+// ErrSingularFit is the error of a least squares problem without a unique
+// solution, because some of the terms are Collinear or the solver failed.
+var ErrSingularFit = errors.New("singular fit")
+
+// Estimate estimates the parameters via least squares.  Returns nil if it could
+// not converge, or if some of the terms are Collinear.
+func Estimate(s Sample, opts Options) Model {
+	m, _ := Solve(s, opts)
+	return m
+}
 
-// estimate parameters via least squares.  Returns nil if it could not converge.
-func estimate(s samp) model {
+// Solve estimates the parameters via least squares, with LAPACK or, if
+// opts.Native is set, in Go.  It returns ErrSingularFit if s has fewer observations than
+// terms, if it could not converge, or if some of the terms are Collinear.
+func Solve(s Sample, opts Options) (Model, error) {
+	if len(s.Y) == 0 || len(s.Y) < len(s.X)/len(s.Y) {
+		return nil, ErrSingularFit
+	}
+	if len(Collinear(s)) > 0 {
+		return nil, ErrSingularFit
+	}
+	if opts.Native {
+		if m := qrSolve(s); m != nil {
+			return m, nil
+		}
+		return nil, ErrSingularFit
+	}
 	y := blas64.General{
-		Rows:   len(s.y),
+		Rows:   len(s.Y),
 		Cols:   1,
 		Stride: 1,
-		Data:   make([]float64, len(s.y)),
+		Data:   make([]float64, len(s.Y)),
 	}
-	copy(y.Data, s.y)
+	copy(y.Data, s.Y)
 
 	x := blas64.General{
-		Rows:   len(s.y),
-		Cols:   len(s.x) / len(s.y),
-		Stride: len(s.x) / len(s.y),
-		Data:   make([]float64, len(s.x)),
+		Rows:   len(s.Y),
+		Cols:   len(s.X) / len(s.Y),
+		Stride: len(s.X) / len(s.Y),
+		Data:   make([]float64, len(s.X)),
 	}
-	copy(x.Data, s.x)
+	copy(x.Data, s.X)
 
 	// find optimal work size
 	work := make([]float64, 1)
@@ -113,37 +187,523 @@ func estimate(s samp) model {
 	ok := lapack64.Gels(blas.NoTrans, x, y, work, len(work))
 
 	if !ok {
-		return nil
+		return nil, ErrSingularFit
 	}
-	return y.Data[:x.Cols]
+	return y.Data[:x.Cols], nil
 }
 
-// calculate R squared
-func stats(m model, s samp) (r2 float64, cint []float64) {
+// Huber loss tuning: residuals beyond huberK robust standard deviations are
+// downweighted, which keeps 95% efficiency when the errors are normal.
+const (
+	huberK      = 1.345
+	robustIters = 50
+	robustTol   = 1e-8
+)
+
+// Robust estimates the parameters by iteratively reweighted least squares
+// with a Huber loss, so that a few outlying observations do not dominate the
+// fit.  The scale of the residuals is estimated from their median absolute
+// deviation.  It returns the model and the weighted sample of the final
+// iteration.  It returns ErrSingularFit, and a nil model, if any of the fits
+// fail.
+func Robust(s Sample, opts Options) (Model, Sample, error) {
+	return RobustContext(context.Background(), s, opts)
+}
+
+// RobustContext is Robust, but stops reweighting and returns ctx.Err(), and a
+// nil model, once ctx is done.
+func RobustContext(ctx context.Context, s Sample, opts Options) (Model, Sample, error) {
+	m, err := Solve(s, opts)
+	if err != nil {
+		return nil, s, err
+	}
+	stride := len(s.X) / len(s.Y)
+	ws := s
+	for iter := 0; iter < robustIters && m != nil; iter++ {
+		if err := ctx.Err(); err != nil {
+			return nil, ws, err
+		}
+		res := make([]float64, len(s.Y))
+		for i, y := range s.Y {
+			res[i] = y
+			for j, x := range s.X[i*stride : (i+1)*stride] {
+				res[i] -= m[j] * x
+			}
+		}
+		scale := mad(res) / 0.6745
+		if scale == 0 {
+			break
+		}
+		w := make([]float64, len(s.Y))
+		for i, r := range res {
+			w[i] = 1
+			if u := math.Abs(r) / scale; u > huberK {
+				w[i] = huberK / u
+			}
+		}
+		ws = Weighted(s, w)
+		prev := m
+		if m, err = Solve(ws, opts); err != nil {
+			return nil, ws, err
+		}
+		converged := true
+		for j := range m {
+			if math.Abs(m[j]-prev[j]) > robustTol*math.Max(math.Abs(prev[j]), 1) {
+				converged = false
+			}
+		}
+		if converged {
+			break
+		}
+	}
+	return m, ws, nil
+}
+
+// mad returns the median absolute deviation from the median of xs.
+func mad(xs []float64) float64 {
+	med := median(xs)
+	dev := make([]float64, len(xs))
+	for i, x := range xs {
+		dev[i] = math.Abs(x - med)
+	}
+	return median(dev)
+}
+
+func median(xs []float64) float64 {
+	s := append([]float64(nil), xs...)
+	sort.Float64s(s)
+	n := len(s)
+	if n == 0 {
+		return 0
+	}
+	if n%2 == 1 {
+		return s[n/2]
+	}
+	return (s[n/2-1] + s[n/2]) / 2
+}
+
+// Stats describes how well a model fits a sample.
+type Stats struct {
+	RSquared float64   // uncentered coefficient of determination
+	CI       []float64 // confidence interval half-width of each parameter, at the Confidence of the Options
+	SE       []float64 // standard error of each parameter
+	DOF      int       // residual degrees of freedom
+
+	T []float64 // t statistic of each parameter
+	P []float64 // two sided p-value of each parameter being zero
+
+	// F statistic and p-value for the regression as a whole, against the
+	// model with no terms, which is what RSquared compares against too
+	F, FP float64
+
+	// goodness of fit penalized by the number of terms, for comparing models
+	AdjRSquared float64 // RSquared adjusted for the degrees of freedom
+	AIC, BIC    float64 // Akaike and Bayesian information criteria, lower is better
+}
+
+// Fit is a model estimated from a sample, along with its goodness of fit.
+type Fit struct {
+	Model Model
+	Stats Stats
+}
+
+// Underdetermined returns an error if s has too few observations to estimate
+// a model with k terms along with its confidence intervals, which takes at
+// least one more observation than there are terms.
+func Underdetermined(s Sample, k int) error {
+	if len(s.Y) <= k {
+		return fmt.Errorf("has %d points but the model needs %d", len(s.Y), k+1)
+	}
+	return nil
+}
+
+// NewFit estimates a model for s.  Returns nil if it could not converge, or
+// if s is Underdetermined.
+func NewFit(s Sample, opts Options) *Fit {
+	fit, _ := NewFitContext(context.Background(), s, opts)
+	return fit
+}
+
+// NewFitContext is NewFit, but returns ctx.Err() instead of a fit once ctx is
+// done.
+func NewFitContext(ctx context.Context, s Sample, opts Options) (*Fit, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if len(s.Y) == 0 || Underdetermined(s, len(s.X)/len(s.Y)) != nil {
+		return nil, nil
+	}
+	m := Estimate(s, opts)
+	if m == nil {
+		return nil, nil
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return &Fit{Model: m, Stats: NewStats(m, s, opts)}, nil
+}
+
+// NewStats calculates R squared and the confidence intervals of the model, at
+// the Confidence of opts.
+func NewStats(m Model, s Sample, opts Options) Stats {
 	RSS := 0.0
 	YSS := 0.0
 
 	// also consumed degrees of freedom
-	stride := len(s.x) / len(s.y)
-	for i, y := range s.y {
+	stride := len(s.X) / len(s.Y)
+	for i, y := range s.Y {
 		YSS += y * y
 		yHat := 0.0
-		for j, x := range s.x[i*stride : (i+1)*stride] {
+		for j, x := range s.X[i*stride : (i+1)*stride] {
 			yHat += m[j] * x
 		}
 		RSS += (yHat - y) * (yHat - y)
 	}
-	r2 = 1.0 - RSS/YSS
 
-	mse := RSS / float64(len(s.y)-stride)
-	X := mat64.NewDense(len(s.y), stride, s.x)
+	X := mat64.NewDense(len(s.Y), stride, s.X)
 	XTX := mat64.NewDense(stride, stride, make([]float64, stride*stride))
 	XTX.Mul(X.T(), X)
 	XTX.Inverse(XTX)
-	cint = make([]float64, stride)
+	var cov *mat64.Dense
+	if opts.HC1 {
+		cov = sandwich(m, s, XTX)
+	}
+	return newStats(m, RSS, YSS, XTX, cov, len(s.Y), opts)
+}
+
+// newStats calculates the Stats of m from its residual sum of squares RSS,
+// the sum of squares of the response YSS, (X'X)^-1 and the number of
+// observations n.  The standard errors are from cov, the covariance of the
+// parameters, unless it is nil.
+func newStats(m Model, RSS, YSS float64, XTXInv, cov *mat64.Dense, n int, opts Options) Stats {
+	stride := len(m)
+	r2 := 1.0 - RSS/YSS
+	mse := RSS / float64(n-stride)
+	dof := n - stride
+	st := Stats{
+		RSquared: r2,
+		CI:       make([]float64, stride),
+		SE:       make([]float64, stride),
+		DOF:      dof,
+		T:        make([]float64, stride),
+		P:        make([]float64, stride),
+	}
 	for i := 0; i < stride; i++ {
-		cint[i] = conf95(math.Sqrt(XTX.At(i, i)*mse), len(s.y)-stride)
+		if cov != nil {
+			st.SE[i] = math.Sqrt(cov.At(i, i))
+		} else {
+			st.SE[i] = math.Sqrt(XTXInv.At(i, i) * mse)
+		}
+		st.CI[i] = conf(opts.level(), st.SE[i], dof)
+		st.T[i] = m[i] / st.SE[i]
+		st.P[i] = tTwoSided(st.T[i], dof)
+	}
+	st.F = (YSS - RSS) / float64(stride) / mse
+	st.FP = fSurvival(st.F, stride, dof)
+
+	nf, k := float64(n), float64(stride)
+	st.AdjRSquared = 1 - (1-r2)*nf/float64(dof)
+	st.AIC = nf*math.Log(RSS/nf) + 2*k
+	st.BIC = nf*math.Log(RSS/nf) + k*math.Log(nf)
+	return st
+}
+
+// Online is a least squares fit that is updated as each observation is added,
+// by recursive least squares: a rank-1 update of the coefficients and of
+// (X'X)^-1, which takes O(k^2) time for k terms instead of refitting every
+// observation.  Until the observations determine the k coefficients, they are
+// fit all at once.  The zero value has no observations, and the default
+// Options.
+type Online struct {
+	Options Options // of the fit
+
+	s   Sample    // the observations so far
+	m   Model     // nil until the observations determine it
+	inv []float64 // (X'X)^-1, k by k in row major order
+
+	rss, yss float64 // residual and response sums of squares
+}
+
+// Add adds the observation with the explanatory terms x and the response y,
+// named name, and updates the fit.  Every observation must have the same
+// number of terms.
+func (o *Online) Add(name string, x []float64, y float64) {
+	o.s.X = append(o.s.X, x...)
+	o.s.Y = append(o.s.Y, y)
+	o.s.Names = append(o.s.Names, name)
+	o.yss += y * y
+	k := len(x)
+	if o.m == nil {
+		if len(o.s.Y) < k {
+			return
+		}
+		m := Estimate(o.s, o.Options)
+		if m == nil {
+			return
+		}
+		X := mat64.NewDense(len(o.s.Y), k, o.s.X)
+		var XTX mat64.Dense
+		XTX.Mul(X.T(), X)
+		if err := XTX.Inverse(&XTX); err != nil {
+			return
+		}
+		o.m = append(Model(nil), m...)
+		o.inv = append([]float64(nil), XTX.RawMatrix().Data...)
+		o.rss = 0
+		for i, y := range o.s.Y {
+			e := y
+			for j, xj := range o.s.X[i*k : (i+1)*k] {
+				e -= o.m[j] * xj
+			}
+			o.rss += e * e
+		}
+		return
+	}
+
+	// Px = (X'X)^-1 x, and the prior residual is scaled by 1 + x'Px
+	Px := make([]float64, k)
+	for a := 0; a < k; a++ {
+		for b, xb := range x {
+			Px[a] += o.inv[a*k+b] * xb
+		}
+	}
+	denom, e := 1.0, y
+	for j, xj := range x {
+		denom += xj * Px[j]
+		e -= o.m[j] * xj
+	}
+	for a := 0; a < k; a++ {
+		o.m[a] += Px[a] * e / denom
+		for b := 0; b < k; b++ {
+			o.inv[a*k+b] -= Px[a] * Px[b] / denom
+		}
+	}
+	o.rss += e * e / denom
+}
+
+// Sample returns the observations added so far.
+func (o *Online) Sample() Sample {
+	return o.s
+}
+
+// Fit returns the current fit, or nil if the observations do not determine
+// it, or are Underdetermined.  With Options.HC1, the standard errors take
+// every observation, in O(n k^2) time.
+func (o *Online) Fit() *Fit {
+	if o.m == nil || Underdetermined(o.s, len(o.m)) != nil {
+		return nil
+	}
+	m := append(Model(nil), o.m...)
+	if o.Options.HC1 {
+		return &Fit{Model: m, Stats: NewStats(m, o.s, o.Options)}
+	}
+	k := len(m)
+	inv := mat64.NewDense(k, k, append([]float64(nil), o.inv...))
+	return &Fit{Model: m, Stats: newStats(m, o.rss, o.yss, inv, nil, len(o.s.Y), o.Options)}
+}
+
+// sandwich returns the HC1 covariance of the parameters,
+// n/(n-k) (X'X)^-1 X' diag(e^2) X (X'X)^-1, given XTXInv = (X'X)^-1.
+func sandwich(m Model, s Sample, XTXInv *mat64.Dense) *mat64.Dense {
+	n, k := len(s.Y), len(s.X)/len(s.Y)
+	res, _ := Standardized(m, s)
+	meat := mat64.NewDense(k, k, nil)
+	for i, e := range res {
+		x := s.X[i*k : (i+1)*k]
+		for a := 0; a < k; a++ {
+			for b := 0; b < k; b++ {
+				meat.Set(a, b, meat.At(a, b)+e*e*x[a]*x[b])
+			}
+		}
+	}
+	half := mat64.NewDense(k, k, nil)
+	half.Mul(XTXInv, meat)
+	cov := mat64.NewDense(k, k, nil)
+	cov.Mul(half, XTXInv)
+	cov.Scale(float64(n)/float64(n-k), cov)
+	return cov
+}
+
+// Predict returns the response predicted by m, which was fit to s, at the
+// explanatory terms x, and the half-width of its prediction interval at the
+// Confidence of opts.  The interval is NaN if s has no residual degrees of
+// freedom, or if its terms are collinear.
+func Predict(m Model, s Sample, x []float64, opts Options) (y, pi float64) {
+	for j, xj := range x {
+		y += m[j] * xj
+	}
+	if len(s.Y) == 0 {
+		return y, math.NaN()
 	}
 
-	return
+	stride := len(s.X) / len(s.Y)
+	dof := len(s.Y) - stride
+	if dof < 1 {
+		return y, math.NaN()
+	}
+	RSS := 0.0
+	for i, yi := range s.Y {
+		yHat := 0.0
+		for j, xj := range s.X[i*stride : (i+1)*stride] {
+			yHat += m[j] * xj
+		}
+		RSS += (yi - yHat) * (yi - yHat)
+	}
+	mse := RSS / float64(dof)
+
+	// the variance of a new observation is mse * (1 + x' (X'X)^-1 x)
+	X := mat64.NewDense(len(s.Y), stride, s.X)
+	XTX := mat64.NewDense(stride, stride, make([]float64, stride*stride))
+	XTX.Mul(X.T(), X)
+	if err := XTX.Inverse(XTX); err != nil {
+		return y, math.NaN()
+	}
+	x0 := mat64.NewVector(stride, x)
+	return y, conf(opts.level(), math.Sqrt(mse*(1+mat64.Inner(x0, XTX, x0))), dof)
+}
+
+// leverageTol is how close to 1 a leverage can be before the residual of its
+// observation is, to within rounding, always 0.
+const leverageTol = 1e-9
+
+// Standardized returns the residuals of the fit along with the standardized
+// residuals, which are the residuals divided by their estimated standard
+// deviation.  Standardized residuals are NaN if they cannot be estimated,
+// which includes those of observations with a leverage of 1, that the fit
+// passes through whatever their response.  Both are nil if s is empty.
+func Standardized(m Model, s Sample) (res, std []float64) {
+	if len(s.Y) == 0 {
+		return nil, nil
+	}
+	stride := len(s.X) / len(s.Y)
+	res = make([]float64, len(s.Y))
+	RSS := 0.0
+	for i, y := range s.Y {
+		yHat := 0.0
+		for j, x := range s.X[i*stride : (i+1)*stride] {
+			yHat += m[j] * x
+		}
+		res[i] = y - yHat
+		RSS += res[i] * res[i]
+	}
+
+	std = make([]float64, len(s.Y))
+	dof := len(s.Y) - stride
+	if dof < 1 {
+		for i := range std {
+			std[i] = math.NaN()
+		}
+		return res, std
+	}
+	mse := RSS / float64(dof)
+
+	// the variance of each residual is mse * (1 - h) where h is the leverage
+	for i, h := range leverages(s) {
+		if !(1-h > leverageTol) {
+			std[i] = math.NaN()
+			continue
+		}
+		std[i] = res[i] / math.Sqrt(mse*(1-h))
+	}
+	return res, std
+}
+
+// leverages returns the diagonal of the hat matrix X (X'X)^-1 X'.  The
+// leverages are NaN if X'X cannot be inverted.
+func leverages(s Sample) []float64 {
+	stride := len(s.X) / len(s.Y)
+	X := mat64.NewDense(len(s.Y), stride, s.X)
+	XTX := mat64.NewDense(stride, stride, make([]float64, stride*stride))
+	XTX.Mul(X.T(), X)
+	hs := make([]float64, len(s.Y))
+	if err := XTX.Inverse(XTX); err != nil {
+		for i := range hs {
+			hs[i] = math.NaN()
+		}
+		return hs
+	}
+	for i := range s.Y {
+		xi := mat64.NewVector(stride, s.X[i*stride:(i+1)*stride])
+		hs[i] = mat64.Inner(xi, XTX, xi)
+	}
+	return hs
+}
+
+// Worst returns the name of the observation with the largest standardized
+// residual, and that residual.  Observations whose standardized residual
+// cannot be estimated are skipped, and name is "" if none of them can be.
+func Worst(m Model, s Sample) (name string, z float64) {
+	_, std := Standardized(m, s)
+	for i, r := range std {
+		if !math.IsNaN(r) && math.Abs(r) > math.Abs(z) {
+			name, z = s.Names[i], r
+		}
+	}
+	return name, z
+}
+
+// Extrapolation describes how far the input variables in vars lie outside of
+// the range observed in s, as in "N=1e+09 is 100x above the observed
+// maximum 1e+07".  It returns "" if none of them do.
+func Extrapolation(s Sample, vars map[string]float64) string {
+	names := make([]string, 0, len(s.Min))
+	for name := range s.Min {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var notes []string
+	for _, name := range names {
+		v, ok := vars[name]
+		if !ok {
+			continue
+		}
+		lo, hi := s.Min[name], s.Max[name]
+		switch {
+		case v > hi && hi > 0:
+			notes = append(notes, fmt.Sprintf("%s=%g is %.3gx above the observed maximum %g", name, v, v/hi, hi))
+		case v > hi:
+			notes = append(notes, fmt.Sprintf("%s=%g is %g above the observed maximum %g", name, v, v-hi, hi))
+		case v < lo && v > 0:
+			notes = append(notes, fmt.Sprintf("%s=%g is %.3gx below the observed minimum %g", name, v, lo/v, lo))
+		case v < lo:
+			notes = append(notes, fmt.Sprintf("%s=%g is %g below the observed minimum %g", name, v, lo-v, lo))
+		}
+	}
+	return strings.Join(notes, "; ")
+}
+
+// Widening returns how many times farther out than the range observed in s
+// the input variables in vars lie, by which the interval of an extrapolated
+// prediction can be widened.  It is the largest of v/max and min/v, as in the
+// notes of Extrapolation, or, where those ratios are not positive, 1 plus the
+// distance outside the range over its width.  It is 1 if none of them lie
+// outside.
+func Widening(s Sample, vars map[string]float64) float64 {
+	w := 1.0
+	for name, lo := range s.Min {
+		v, ok := vars[name]
+		if !ok {
+			continue
+		}
+		hi := s.Max[name]
+		f := 1.0
+		switch {
+		case v > hi && hi > 0:
+			f = v / hi
+		case v < lo && v > 0:
+			f = lo / v
+		case v > hi && hi > lo:
+			f = 1 + (v-hi)/(hi-lo)
+		case v < lo && hi > lo:
+			f = 1 + (lo-v)/(hi-lo)
+		case v < lo || v > hi:
+			// a single observed value gives no scale to compare to
+			f = math.Inf(1)
+		}
+		if f > w {
+			w = f
+		}
+	}
+	return w
 }
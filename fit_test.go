@@ -2,16 +2,16 @@
 // Use of this source code is governed by a BSD-style
 // license that can be found in the LICENSE file.
 
-package main
+package benchls
 
 import (
+	"context"
 	"math"
+	"reflect"
 	"regexp"
+	"strconv"
 	"strings"
 	"testing"
-
-	"github.com/jonlawlor/parsefloat"
-	"golang.org/x/tools/benchmark/parse"
 )
 
 func TestFit(t *testing.T) {
@@ -28,36 +28,305 @@ ok  	github.com/jonlawlor/benchls	149.108s
 `
 	yVar := "NsPerOp"
 	r := strings.NewReader(s)
-	benchSet, err := parse.ParseSet(r)
+	benchSet, err := ReadSet(r)
 	if err != nil {
 		panic(err)
 	}
 	inre := regexp.MustCompile(`(?P<N>\d+)-\d+$`)
-	names := parsefloat.NamedVars(inre)
+	ex := RegexpExtractor{inre}
+	names := ex.VarNames()
 
 	// Sort isn't O(n) obviously, but this was easy to verify.
 	xtrans := "N, 1.0"
 	ytrans := "Y"
 	wantFit := []float64{428.2534163147418, -1.4343020792698523e+07}
 
-	xExprs, err := parsefloat.NewSlice("float64{"+xtrans+"}", names)
+	xExprs, err := NewExpressions(xtrans, names, nil)
 	if err != nil {
 		panic(err)
 	}
 	names["Y"] = struct{}{}
-	yExpr, err := parsefloat.New(ytrans, names)
+	yExpr, err := NewExpression(ytrans, names, nil)
 	if err != nil {
 		panic(err)
 	}
 
-	samps := sampleGroup(benchSet, inre, xExprs, yExpr, yVar)
-	fit := estimate(samps["BenchmarkSort"])
+	samps := SampleGroup(benchSet, ex, xExprs, yExpr, yVar)
+	fit := Estimate(samps["BenchmarkSort"], Options{})
 	for i, f := range fit {
 		if math.Abs(wantFit[i]-f) > 1e-6 {
 			t.Errorf("expected fit[%d] = %f, got %f", i, wantFit[i], f)
 		}
 	}
-	if r2, _ := stats(fit, samps["BenchmarkSort"]); r2 < .999 || r2 > 1.0 {
+	st := NewStats(fit, samps["BenchmarkSort"], Options{})
+	if r2 := st.RSquared; r2 < .999 || r2 > 1.0 {
 		t.Errorf("expected r2 approximately %f, got %f", .999, r2)
 	}
+	// the slope is clearly significant, and the intercept is not
+	if st.P[0] > 0.001 || st.P[1] < 0.05 {
+		t.Errorf("expected p-values of about 0 and above 0.05, got %v", st.P)
+	}
+	if st.FP > 0.001 {
+		t.Errorf("expected a significant regression, got F p-value %g", st.FP)
+	}
+	if !(st.AdjRSquared < st.RSquared) {
+		t.Errorf("expected adjusted R^2 below %g, got %g", st.RSquared, st.AdjRSquared)
+	}
+	// with 2 terms and 7 observations, BIC - AIC = 2 * (log(7) - 2)
+	if d := st.BIC - st.AIC; math.Abs(d-2*(math.Log(7)-2)) > 1e-9 {
+		t.Errorf("expected BIC - AIC = %g, got %g", 2*(math.Log(7)-2), d)
+	}
+}
+
+func TestWorst(t *testing.T) {
+	var s Sample
+	for n := 1.0; n <= 10; n++ {
+		y := 2*n + 1 + 0.01*math.Sin(n) // small, smooth noise
+		if n == 4 {
+			y += 5
+		}
+		s.X = append(s.X, n, 1.0)
+		s.Y = append(s.Y, y)
+		s.Names = append(s.Names, "BenchmarkFoo"+strconv.Itoa(int(n)))
+	}
+	m := Estimate(s, Options{})
+	name, z := Worst(m, s)
+	if name != "BenchmarkFoo4" {
+		t.Errorf("expected worst point BenchmarkFoo4, got %s", name)
+	}
+	if z < 2 {
+		t.Errorf("expected a large standardized residual, got %g", z)
+	}
+}
+
+func TestWorstLeverage(t *testing.T) {
+	// the last term is 1 only for BenchmarkFoo6, so the fit passes through it
+	// and its leverage is 1, whatever its response
+	var s Sample
+	for n := 1.0; n <= 6; n++ {
+		y := 2*n + 1 + 0.01*math.Sin(n)
+		d := 0.0
+		if n == 6 {
+			y += 100
+			d = 1
+		}
+		s.X = append(s.X, n, 1.0, d)
+		s.Y = append(s.Y, y)
+		s.Names = append(s.Names, "BenchmarkFoo"+strconv.Itoa(int(n)))
+	}
+	m := Estimate(s, Options{})
+	if _, std := Standardized(m, s); !math.IsNaN(std[5]) {
+		t.Errorf("expected no standardized residual at a leverage of 1, got %g", std[5])
+	}
+	if name, _ := Worst(m, s); name == "BenchmarkFoo6" {
+		t.Errorf("expected the worst point not to be the one with a leverage of 1")
+	}
+}
+
+func TestRobust(t *testing.T) {
+	var s Sample
+	for n := 1.0; n <= 20; n++ {
+		y := 2*n + 1 + 0.01*math.Sin(n)
+		if n == 18 {
+			y *= 3 // a GC pause
+		}
+		s.X = append(s.X, n, 1.0)
+		s.Y = append(s.Y, y)
+	}
+	if m := Estimate(s, Options{}); math.Abs(m[0]-2) < 0.1 {
+		t.Fatalf("expected the outlier to skew least squares, got slope %g", m[0])
+	}
+	m, _, err := Robust(s, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(m[0]-2) > 0.01 || math.Abs(m[1]-1) > 0.1 {
+		t.Errorf("expected a fit near [2 1], got %v", m)
+	}
+}
+
+func TestPredict(t *testing.T) {
+	var s Sample
+	for n := 1.0; n <= 10; n++ {
+		s.X = append(s.X, n, 1.0)
+		s.Y = append(s.Y, 2*n+1+0.1*math.Sin(n))
+	}
+	m := Estimate(s, Options{})
+	y, near := Predict(m, s, []float64{5, 1}, Options{})
+	if math.Abs(y-11) > 0.2 {
+		t.Errorf("expected a prediction near 11, got %g", y)
+	}
+	_, far := Predict(m, s, []float64{100, 1}, Options{})
+	if !(near > 0 && far > near) {
+		t.Errorf("expected the interval to widen away from the data, got %g and %g", near, far)
+	}
+
+	// the second term is twice the first, so X'X is singular
+	s = Sample{X: []float64{1, 2, 2, 4, 3, 6, 4, 8}, Y: []float64{1, 2, 3, 4}}
+	if _, pi := Predict(Model{1, 0}, s, []float64{5, 10}, Options{}); !math.IsNaN(pi) {
+		t.Errorf("expected no interval for collinear terms, got %g", pi)
+	}
+}
+
+func TestExtrapolation(t *testing.T) {
+	s := Sample{
+		Min: map[string]float64{"N": 10},
+		Max: map[string]float64{"N": 1e7},
+	}
+	for _, test := range []struct {
+		n    float64
+		want string
+	}{
+		{1000, ""},
+		{1e9, "N=1e+09 is 100x above the observed maximum 1e+07"},
+		{2, "N=2 is 5x below the observed minimum 10"},
+		{-5, "N=-5 is 15 below the observed minimum 10"},
+	} {
+		if got := Extrapolation(s, map[string]float64{"N": test.n}); got != test.want {
+			t.Errorf("N=%g: expected %q, got %q", test.n, test.want, got)
+		}
+	}
+}
+
+func TestWidening(t *testing.T) {
+	s := Sample{
+		Min: map[string]float64{"M": -10, "N": 10},
+		Max: map[string]float64{"M": 10, "N": 1e7},
+	}
+	for _, test := range []struct {
+		m, n float64
+		want float64
+	}{
+		{0, 1000, 1},
+		{0, 1e9, 100},
+		{0, 2, 5},
+		{50, 1000, 5},
+		{-50, 1000, 3},
+		{50, 1e9, 100},
+	} {
+		if got := Widening(s, map[string]float64{"M": test.m, "N": test.n}); math.Abs(got-test.want) > 1e-12 {
+			t.Errorf("M=%g N=%g: expected %g, got %g", test.m, test.n, test.want, got)
+		}
+	}
+}
+
+func TestHC1(t *testing.T) {
+	// for the mean alone, the HC1 variance is sum(e^2) / (n (n-1))
+	s := Sample{X: []float64{1, 1, 1, 1}, Y: []float64{1, 2, 3, 6}}
+	fit := NewFit(s, Options{HC1: true})
+	if want := math.Sqrt(14.0 / 12); math.Abs(fit.Stats.SE[0]-want) > 1e-12 {
+		t.Errorf("expected standard error %g, got %g", want, fit.Stats.SE[0])
+	}
+}
+
+func TestUnderdetermined(t *testing.T) {
+	s := Sample{X: []float64{10, 1, 100, 1}, Y: []float64{50, 500}}
+	if err := Underdetermined(s, 2); err == nil || err.Error() != "has 2 points but the model needs 3" {
+		t.Errorf("expected an error about 2 points, got %v", err)
+	}
+	if fit := NewFit(s, Options{}); fit != nil {
+		t.Errorf("expected no fit, got %v", fit)
+	}
+
+	// the library functions fail without panicking on too few points
+	for _, s := range []Sample{{X: []float64{10, 1}, Y: []float64{50}}, {}} {
+		if _, err := Solve(s, Options{}); err != ErrSingularFit {
+			t.Errorf("%v: expected Solve to fail with %v, got %v", s.Y, ErrSingularFit, err)
+		}
+		if _, _, err := Robust(s, Options{}); err != ErrSingularFit {
+			t.Errorf("%v: expected Robust to fail with %v, got %v", s.Y, ErrSingularFit, err)
+		}
+		if _, _, _, err := VarPower(s, Options{}); err != ErrSingularFit {
+			t.Errorf("%v: expected VarPower to fail with %v, got %v", s.Y, ErrSingularFit, err)
+		}
+		if m := Estimate(s, Options{}); m != nil {
+			t.Errorf("%v: expected no model, got %v", s.Y, m)
+		}
+	}
+	if cols := Collinear(Sample{}); cols != nil {
+		t.Errorf("expected no collinear terms in an empty sample, got %v", cols)
+	}
+	if res, std := Standardized(Model{1}, Sample{}); res != nil || std != nil {
+		t.Errorf("expected no residuals of an empty sample, got %v and %v", res, std)
+	}
+
+	// an exact fit has no confidence intervals
+	m, err := Solve(s, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	st := NewStats(m, s, Options{})
+	if st.DOF != 0 || !math.IsNaN(st.CI[0]) {
+		t.Errorf("expected a NaN interval without degrees of freedom, got %v with %d", st.CI, st.DOF)
+	}
+}
+
+func TestOnline(t *testing.T) {
+	var o Online
+	var s Sample
+	for i, n := range []float64{10, 100, 1000, 10000, 100000, 1000000} {
+		x := []float64{n * math.Log(n), n, 1}
+		y := 3*n*math.Log(n) + 2*n + 100 + float64(i%3)*50
+		o.Add(strconv.Itoa(int(n)), x, y)
+		s.X = append(s.X, x...)
+		s.Y = append(s.Y, y)
+		s.Names = append(s.Names, strconv.Itoa(int(n)))
+
+		got, want := o.Fit(), NewFit(s, Options{})
+		if (got == nil) != (want == nil) {
+			t.Fatalf("%d observations: expected fit %v, got %v", i+1, want, got)
+		}
+		if got == nil {
+			continue
+		}
+		for j := range want.Model {
+			if math.Abs(got.Model[j]-want.Model[j]) > 1e-6*math.Abs(want.Model[j]) {
+				t.Errorf("%d observations: expected coefficient %d to be %g, got %g", i+1, j, want.Model[j], got.Model[j])
+			}
+			if math.Abs(got.Stats.SE[j]-want.Stats.SE[j]) > 1e-4*want.Stats.SE[j] {
+				t.Errorf("%d observations: expected standard error %d to be %g, got %g", i+1, j, want.Stats.SE[j], got.Stats.SE[j])
+			}
+		}
+		if math.Abs(got.Stats.RSquared-want.Stats.RSquared) > 1e-9 || got.Stats.DOF != want.Stats.DOF {
+			t.Errorf("%d observations: expected R^2 %g with %d dof, got %g with %d", i+1,
+				want.Stats.RSquared, want.Stats.DOF, got.Stats.RSquared, got.Stats.DOF)
+		}
+	}
+	if len(o.Sample().Y) != 6 {
+		t.Errorf("expected 6 observations, got %d", len(o.Sample().Y))
+	}
+}
+
+func TestNewFitContext(t *testing.T) {
+	var s Sample
+	for n := 1.0; n <= 10; n++ {
+		s.X = append(s.X, n, 1.0)
+		s.Y = append(s.Y, 2*n+1+0.1*math.Sin(n))
+	}
+	fit, err := NewFitContext(context.Background(), s, Options{})
+	if err != nil || fit == nil {
+		t.Fatalf("expected a fit, got %v, %v", fit, err)
+	}
+	if want := NewFit(s, Options{}); !reflect.DeepEqual(fit, want) {
+		t.Errorf("expected %v, got %v", want, fit)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if fit, err := NewFitContext(ctx, s, Options{}); fit != nil || err != context.Canceled {
+		t.Errorf("expected a canceled fit, got %v, %v", fit, err)
+	}
+}
+
+func TestRobustContext(t *testing.T) {
+	var s Sample
+	for n := 1.0; n <= 20; n++ {
+		s.X = append(s.X, n, 1.0)
+		s.Y = append(s.Y, 2*n+1+0.1*math.Sin(n))
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if m, _, err := RobustContext(ctx, s, Options{}); m != nil || err != context.Canceled {
+		t.Errorf("expected a canceled fit, got %v, %v", m, err)
+	}
 }
@@ -0,0 +1,173 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package fitter fits benchls models to benchmarks run in the same process,
+// so that a test can assert on the fit:
+//
+//	func TestSortScaling(t *testing.T) {
+//		var results []fitter.Result
+//		for _, n := range []int{100, 1000, 10000, 100000} {
+//			r := testing.Benchmark(func(b *testing.B) { benchmarkSort(b, n) })
+//			results = append(results, fitter.Result{Vars: map[string]float64{"N": float64(n)}, BenchmarkResult: r})
+//		}
+//		coeffs, stats, err := fitter.Fit(results, fitter.Model{XTransform: "math.Log(N), 1.0", YTransform: "math.Log(Y)"})
+//		if err != nil {
+//			t.Fatal(err)
+//		}
+//		if coeffs[0]+stats.CI[0] > 1.2 {
+//			t.Errorf("sort grows faster than n^1.2: %v ± %v", coeffs, stats.CI)
+//		}
+//	}
+package fitter
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/jonlawlor/benchls"
+)
+
+// Result is the result of a benchmark run with the input variables Vars,
+// like N.
+type Result struct {
+	Vars map[string]float64
+	testing.BenchmarkResult
+}
+
+// Model is the model to fit, written as for the benchls command.
+type Model struct {
+	XTransform string // explanatory terms, separated by commas, defaults to "N, 1.0"
+	YTransform string // function of the response Y to fit, defaults to "Y"
+	Response   string // one of benchls.Responses, defaults to "NsPerOp"
+
+	Constants map[string]float64 // named values in the transforms, like "B"
+	Options   benchls.Options    // of the fit, like its confidence level
+}
+
+// Coeffs are the fitted coefficients, one per explanatory term.
+type Coeffs []float64
+
+// Stats describes how well the model fits, as in benchls.Stats.
+type Stats benchls.Stats
+
+// Fit fits model to the results by least squares.  An invalid model is a
+// *benchls.ExprError, and a model that cannot be fit, which needs at least
+// one more result than there are terms, is benchls.ErrSingularFit.  Each call
+// has its own settings, in model.Options, so Fit is safe to call from several
+// goroutines at once.
+func Fit(results []Result, model Model) (Coeffs, Stats, error) {
+	return FitContext(context.Background(), results, model)
+}
+
+// FitContext is Fit, but gives up with ctx.Err() once ctx is done, as when the
+// request it serves times out.
+func FitContext(ctx context.Context, results []Result, model Model) (Coeffs, Stats, error) {
+	s, err := sample(ctx, results, model)
+	if err != nil {
+		return nil, Stats{}, err
+	}
+	fit, err := benchls.NewFitContext(ctx, s, model.Options)
+	if err != nil {
+		return nil, Stats{}, err
+	}
+	if fit == nil {
+		return nil, Stats{}, benchls.ErrSingularFit
+	}
+	return Coeffs(fit.Model), Stats(fit.Stats), nil
+}
+
+// sample evaluates the terms of model for each result.
+func sample(ctx context.Context, results []Result, model Model) (benchls.Sample, error) {
+	if model.XTransform == "" {
+		model.XTransform = "N, 1.0"
+	}
+	if model.YTransform == "" {
+		model.YTransform = "Y"
+	}
+	if model.Response == "" {
+		model.Response = "NsPerOp"
+	}
+
+	names := make(map[string]struct{})
+	for _, r := range results {
+		for v := range r.Vars {
+			names[v] = struct{}{}
+		}
+	}
+	xExprs, err := benchls.NewExpressions(model.XTransform, names, model.Constants)
+	if err != nil {
+		return benchls.Sample{}, err
+	}
+	names["Y"] = struct{}{}
+	yExpr, err := benchls.NewExpression(model.YTransform, names, model.Constants)
+	if err != nil {
+		return benchls.Sample{}, err
+	}
+
+	var s benchls.Sample
+	for _, r := range results {
+		if err := ctx.Err(); err != nil {
+			return benchls.Sample{}, err
+		}
+		y, err := response(r.BenchmarkResult, model.Response)
+		if err != nil {
+			return benchls.Sample{}, err
+		}
+		// the expressions add to the variables
+		vars := make(map[string]float64, len(r.Vars)+1)
+		for k, v := range r.Vars {
+			vars[k] = v
+		}
+		for _, x := range xExprs {
+			s.X = append(s.X, x.Eval(vars))
+		}
+		vars["Y"] = y
+		s.Y = append(s.Y, yExpr.Eval(vars))
+		s.Names = append(s.Names, name(r.Vars))
+		s.Vars = append(s.Vars, r.Vars)
+	}
+	return s, nil
+}
+
+// response returns the named field of r, per op.
+func response(r testing.BenchmarkResult, field string) (float64, error) {
+	if r.N == 0 {
+		return 0, fmt.Errorf("benchmark with %s did not run", field)
+	}
+	switch field {
+	case "NsPerOp":
+		return float64(r.T.Nanoseconds()) / float64(r.N), nil
+	case "AllocedBytesPerOp":
+		return float64(r.MemBytes) / float64(r.N), nil
+	case "AllocsPerOp":
+		return float64(r.MemAllocs) / float64(r.N), nil
+	case "MBPerS":
+		if r.T <= 0 {
+			return 0, nil
+		}
+		return float64(r.Bytes) * float64(r.N) / 1e6 / r.T.Seconds(), nil
+	case "BytesPerOp":
+		return float64(r.Bytes), nil
+	}
+	return 0, fmt.Errorf("unknown response %q, must be one of %q", field, benchls.Responses)
+}
+
+// name identifies a result by its input variables, like "N=100".
+func name(vars map[string]float64) string {
+	keys := make([]string, 0, len(vars))
+	for k := range vars {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var n string
+	for i, k := range keys {
+		if i > 0 {
+			n += "/"
+		}
+		n += fmt.Sprintf("%s=%g", k, vars[k])
+	}
+	return n
+}
@@ -0,0 +1,129 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fitter
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/jonlawlor/benchls"
+)
+
+func TestFit(t *testing.T) {
+	// n^1.5 ns per op, with a little noise
+	var results []Result
+	for i, n := range []float64{10, 100, 1000, 10000, 100000} {
+		ns := math.Pow(n, 1.5) * (1 + 0.01*float64(i%2))
+		results = append(results, Result{
+			Vars:            map[string]float64{"N": n},
+			BenchmarkResult: testing.BenchmarkResult{N: 1000, T: time.Duration(ns * 1000)},
+		})
+	}
+	coeffs, stats, err := Fit(results, Model{XTransform: "math.Log(N), 1.0", YTransform: "math.Log(Y)"})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if len(coeffs) != 2 {
+		t.Fatalf("expected 2 coefficients, got %v", coeffs)
+	}
+	if math.Abs(coeffs[0]-1.5) > 0.01 {
+		t.Errorf("expected an exponent near 1.5, got %g", coeffs[0])
+	}
+	if stats.DOF != 3 || len(stats.CI) != 2 || stats.RSquared < 0.99 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+
+	// too few results to fit
+	if coeffs, _, err := Fit(results[:2], Model{}); coeffs != nil || err != benchls.ErrSingularFit {
+		t.Errorf("expected no fit, got %v, %v", coeffs, err)
+	}
+}
+
+func TestFitContext(t *testing.T) {
+	var results []Result
+	for i, n := range []float64{10, 100, 1000, 10000} {
+		results = append(results, Result{
+			Vars:            map[string]float64{"N": n},
+			BenchmarkResult: testing.BenchmarkResult{N: 1000, T: time.Duration(n*1000) + time.Duration(i%2)*time.Millisecond},
+		})
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if coeffs, _, err := FitContext(ctx, results, Model{}); coeffs != nil || err != context.Canceled {
+		t.Errorf("expected a canceled fit, got %v, %v", coeffs, err)
+	}
+
+	// concurrent fits with different settings do not share them
+	opts := []benchls.Options{
+		{Confidence: 0.5},
+		{Confidence: 0.99, HC1: true},
+		{Native: true},
+		{Confidence: 0.9, HC1: true, Native: true},
+	}
+	want := make([]Stats, len(opts))
+	for i, o := range opts {
+		var err error
+		if _, want[i], err = Fit(results, Model{Options: o}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	done := make(chan error)
+	for i := range opts {
+		go func(i int) {
+			_, st, err := FitContext(context.Background(), results, Model{Options: opts[i]})
+			if err == nil && math.Abs(st.CI[0]-want[i].CI[0]) > 1e-9*want[i].CI[0] {
+				err = fmt.Errorf("%+v: expected CI %g, got %g", opts[i], want[i].CI[0], st.CI[0])
+			}
+			done <- err
+		}(i)
+	}
+	for range opts {
+		if err := <-done; err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestResponse(t *testing.T) {
+	r := testing.BenchmarkResult{N: 10, T: time.Millisecond, Bytes: 100, MemAllocs: 30, MemBytes: 640}
+	for field, want := range map[string]float64{
+		"NsPerOp":           1e5,
+		"AllocedBytesPerOp": 64,
+		"AllocsPerOp":       3,
+		"MBPerS":            1,
+		"BytesPerOp":        100,
+	} {
+		got, err := response(r, field)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", field, err)
+			continue
+		}
+		if math.Abs(got-want) > 1e-9 {
+			t.Errorf("%s: expected %g, got %g", field, want, got)
+		}
+	}
+	if _, err := response(r, "cachemisses/op"); err == nil {
+		t.Error("expected an error for an unknown response")
+	}
+}
+
+func TestFitErrors(t *testing.T) {
+	results := []Result{{Vars: map[string]float64{"N": 1}, BenchmarkResult: testing.BenchmarkResult{N: 1, T: 1}}}
+	for _, test := range []struct {
+		xt   string
+		want error
+	}{
+		{"M, 1.0", benchls.ErrUnknownVariable},
+		{"N +, 1.0", benchls.ErrInvalidExpression},
+	} {
+		_, _, err := Fit(results, Model{XTransform: test.xt})
+		if e, ok := err.(*benchls.ExprError); !ok || e.Err != test.want {
+			t.Errorf("%s: expected %v, got %v", test.xt, test.want, err)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package benchls
+
+import (
+	"bytes"
+	"errors"
+	"go/token"
+	"strings"
+)
+
+// formulaFuncs are the functions of R formulas and the math functions they
+// are written as.
+var formulaFuncs = map[string]string{
+	"log":   "math.Log",
+	"log2":  "math.Log2",
+	"log10": "math.Log10",
+	"log1p": "math.Log1p",
+	"exp":   "math.Exp",
+	"sqrt":  "math.Sqrt",
+	"abs":   "math.Abs",
+	"floor": "math.Floor",
+	"ceil":  "math.Ceil",
+}
+
+// Formula translates a model written as an R formula, like
+// "log(Y) ~ log(N) + P", into a ytransform and an xtransform.  Terms are
+// separated by +, a:b is the product of a and b, and a*b is a + b + a:b.
+// Arithmetic in a term is protected by I(), as in I(N^2).  As in R, there is
+// an intercept unless the formula has - 1 or + 0, and the R names of the math
+// functions, like log and sqrt, can be used.
+func Formula(src string) (xt, yt string, err error) {
+	parts := strings.Split(src, "~")
+	if len(parts) != 2 {
+		return "", "", errors.New("formula needs one ~ between the response and the terms: " + src)
+	}
+	if strings.TrimSpace(parts[0]) == "" {
+		return "", "", errors.New("formula has no response: " + src)
+	}
+	yt, err = formulaExpr(parts[0])
+	if err != nil {
+		return "", "", err
+	}
+
+	var terms []string
+	add := func(t string) {
+		for _, have := range terms {
+			if have == t {
+				return
+			}
+		}
+		terms = append(terms, t)
+	}
+	intercept := true
+	rhs := parts[1]
+	toks := scan(rhs)
+	start, sign := 0, token.ADD
+	for i := 0; i <= len(toks); i++ {
+		if i < len(toks) {
+			switch toks[i].tok {
+			case token.LPAREN, token.LBRACK:
+				if i = match(toks, i, 1); i < 0 {
+					return "", "", errors.New("unbalanced parentheses in formula: " + src)
+				}
+				continue
+			case token.ADD, token.SUB:
+				if i == 0 {
+					// a leading sign, as in - 1 + N
+					sign, start = toks[i].tok, 1
+					continue
+				}
+			default:
+				continue
+			}
+		}
+		if i == start {
+			return "", "", errors.New("empty term in formula: " + src)
+		}
+		term := strings.TrimSpace(rhs[toks[start].off:toks[i-1].end])
+		switch {
+		case term == "1" && sign == token.ADD:
+			intercept = true
+		case term == "1" || term == "0":
+			intercept = sign == token.ADD && term == "1"
+		case sign == token.SUB:
+			return "", "", errors.New("only the intercept can be removed from a formula: " + src)
+		default:
+			expanded, err := formulaTerm(rhs, toks[start:i])
+			if err != nil {
+				return "", "", err
+			}
+			for _, t := range expanded {
+				add(t)
+			}
+		}
+		if i < len(toks) {
+			sign = toks[i].tok
+		}
+		start = i + 1
+	}
+	if intercept {
+		terms = append(terms, "1.0")
+	}
+	if len(terms) == 0 {
+		return "", "", errors.New("formula has no terms: " + src)
+	}
+	return strings.Join(terms, ", "), yt, nil
+}
+
+// formulaTerm expands a term of a formula, which may cross factors with * or
+// multiply them with :, into explanatory terms.
+func formulaTerm(src string, toks []tok) ([]string, error) {
+	// each product of factors, separated by *
+	var crossed [][]string
+	var factors []string
+	start := 0
+	for i := 0; i <= len(toks); i++ {
+		if i < len(toks) {
+			switch toks[i].tok {
+			case token.LPAREN, token.LBRACK:
+				i = match(toks, i, 1)
+				continue
+			case token.COLON, token.MUL:
+			default:
+				continue
+			}
+		}
+		if i == start {
+			return nil, errors.New("empty factor in formula term: " + src)
+		}
+		f, err := formulaExpr(src[toks[start].off:toks[i-1].end])
+		if err != nil {
+			return nil, err
+		}
+		factors = append(factors, f)
+		if i == len(toks) || toks[i].tok == token.MUL {
+			crossed = append(crossed, factors)
+			factors = nil
+		}
+		start = i + 1
+	}
+
+	// a*b is a + b + a:b, in the order R gives them: each subset of the
+	// crossed products, the smaller subsets first
+	subsets := [][]int{nil}
+	for i := range crossed {
+		for _, sub := range subsets {
+			subsets = append(subsets, append(append([]int(nil), sub...), i))
+		}
+	}
+	var out []string
+	for order := 1; order <= len(crossed); order++ {
+		for _, sub := range subsets {
+			if len(sub) != order {
+				continue
+			}
+			var t []string
+			for _, i := range sub {
+				t = append(t, crossed[i]...)
+			}
+			out = append(out, strings.Join(t, " * "))
+		}
+	}
+	return out, nil
+}
+
+// formulaExpr writes a factor or response of a formula as an expression: the
+// R math functions become those of the math package, and I() parentheses.
+func formulaExpr(src string) (string, error) {
+	src = strings.TrimSpace(src)
+	toks := scan(src)
+	var b bytes.Buffer
+	last := 0
+	for i, t := range toks {
+		if t.tok != token.IDENT || i+1 == len(toks) || toks[i+1].tok != token.LPAREN || (i > 0 && toks[i-1].tok == token.PERIOD) {
+			continue
+		}
+		name := src[t.off:t.end]
+		repl, ok := formulaFuncs[name]
+		if name == "I" {
+			repl, ok = "", true
+		}
+		if !ok {
+			continue
+		}
+		b.WriteString(src[last:t.off])
+		b.WriteString(repl)
+		last = t.end
+	}
+	b.WriteString(src[last:])
+	if b.Len() == 0 {
+		return "", errors.New("empty expression in formula")
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,38 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package benchls
+
+import "testing"
+
+func TestFormula(t *testing.T) {
+	for _, test := range []struct {
+		src, xt, yt string
+	}{
+		{"log(Y) ~ log(N) + P + 1", "math.Log(N), P, 1.0", "math.Log(Y)"},
+		{"Y ~ N", "N, 1.0", "Y"},
+		{"Y ~ N - 1", "N", "Y"},
+		{"Y ~ 0 + N + I(N^2)", "N, (N^2)", "Y"},
+		{"Y ~ N:M + sqrt(N)", "N * M, math.Sqrt(N), 1.0", "Y"},
+		{"Y ~ N*M", "N, M, N * M, 1.0", "Y"},
+		{"Y ~ N*M*P - 1", "N, M, P, N * M, N * P, M * P, N * M * P", "Y"},
+		{"Y ~ N*log(M):P + N", "N, math.Log(M) * P, N * math.Log(M) * P, 1.0", "Y"},
+		{"Y ~ -1 + N", "N", "Y"},
+		{"Y ~ math.Log(N) + I(math.Max(N, 2) - 1)", "math.Log(N), (math.Max(N, 2) - 1), 1.0", "Y"},
+	} {
+		xt, yt, err := Formula(test.src)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", test.src, err)
+			continue
+		}
+		if xt != test.xt || yt != test.yt {
+			t.Errorf("%s: expected %q ~ %q, got %q ~ %q", test.src, test.yt, test.xt, yt, xt)
+		}
+	}
+	for _, src := range []string{"Y", "~ N", "Y ~ N ~ M", "Y ~ N + + M", "Y ~ -N", "Y ~ N - M", "Y ~ log(N", "Y ~ N:"} {
+		if _, _, err := Formula(src); err == nil {
+			t.Errorf("%s: expected an error", src)
+		}
+	}
+}
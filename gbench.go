@@ -0,0 +1,125 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package benchls
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// gbenchFields are the fields of a Google Benchmark run that are not user
+// counters.
+var gbenchFields = map[string]bool{
+	"name": true, "family_index": true, "per_family_instance_index": true,
+	"run_name": true, "run_type": true, "repetitions": true, "repetition_index": true,
+	"threads": true, "iterations": true, "real_time": true, "cpu_time": true,
+	"time_unit": true, "bytes_per_second": true, "items_per_second": true, "label": true,
+	"aggregate_name": true, "aggregate_unit": true, "error_occurred": true, "error_message": true,
+}
+
+// gbenchModifiers are the elements of Google Benchmark names that set how the
+// benchmark runs rather than what it measures, like real_time or
+// min_time:0.5.
+var gbenchModifiers = []string{"real_time", "process_time", "manual_time", "min_time:", "min_warmup_time:", "iterations:", "repeats:"}
+
+// GoogleBenchmark converts the results of Google Benchmark, written with
+// --benchmark_format=json, to go test -bench output.  Arguments of the name
+// are kept, so BM_Sort/1024 is BenchmarkBM_Sort/1024-1, and named arguments
+// like size:1024 become size=1024.  The real time is ns/op, the cpu time the
+// metric cpu-ns/op, bytes_per_second MB/s, items_per_second the metric
+// items/s, and each user counter a metric of its name.  Aggregates of
+// repetitions, like their mean, are left out, since each repetition is a
+// replicate.
+func GoogleBenchmark(r io.Reader) ([]byte, error) {
+	var results struct {
+		Benchmarks []map[string]interface{} `json:"benchmarks"`
+	}
+	if err := json.NewDecoder(r).Decode(&results); err != nil {
+		return nil, fmt.Errorf("google benchmark: %v", err)
+	}
+	var out bytes.Buffer
+	for _, run := range results.Benchmarks {
+		name, _ := run["name"].(string)
+		if t, _ := run["run_type"].(string); t == "aggregate" {
+			continue
+		}
+		if failed, _ := run["error_occurred"].(bool); failed {
+			continue
+		}
+
+		var elems []string
+		threads := 1
+		for i, e := range strings.Split(name, "/") {
+			if i > 0 && strings.HasPrefix(e, "threads:") {
+				fmt.Sscan(strings.TrimPrefix(e, "threads:"), &threads)
+				continue
+			}
+			if i > 0 && gbenchModifier(e) {
+				continue
+			}
+			elems = append(elems, strings.Replace(e, ":", "=", 1))
+		}
+		if th, ok := run["threads"].(float64); ok {
+			threads = int(th)
+		}
+
+		unit, _ := run["time_unit"].(string)
+		if unit == "" {
+			unit = "ns"
+		}
+		var ms []measurement
+		for _, f := range []struct {
+			field, unit string
+		}{{"real_time", "ns/op"}, {"cpu_time", "cpu-ns/op"}} {
+			v, ok := run[f.field].(float64)
+			if !ok {
+				continue
+			}
+			ns, err := nsPerOp(v, unit+"/op")
+			if err != nil {
+				return nil, fmt.Errorf("google benchmark: %s: %v", name, err)
+			}
+			ms = append(ms, measurement{ns, f.unit})
+		}
+		if v, ok := run["bytes_per_second"].(float64); ok {
+			ms = append(ms, measurement{v / 1e6, "MB/s"})
+		}
+		if v, ok := run["items_per_second"].(float64); ok {
+			ms = append(ms, measurement{v, "items/s"})
+		}
+		var counters []string
+		for k, v := range run {
+			if _, ok := v.(float64); ok && !gbenchFields[k] {
+				counters = append(counters, k)
+			}
+		}
+		sort.Strings(counters)
+		for _, c := range counters {
+			ms = append(ms, measurement{run[c].(float64), strings.Join(strings.Fields(c), "_")})
+		}
+
+		iters := 1
+		if it, ok := run["iterations"].(float64); ok && it >= 1 {
+			iters = int(it)
+		}
+		writeLine(&out, benchName(strings.Join(elems, "/"), nil, threads), iters, ms...)
+	}
+	return out.Bytes(), nil
+}
+
+// gbenchModifier reports whether the element of a name is one of the
+// gbenchModifiers.
+func gbenchModifier(e string) bool {
+	for _, m := range gbenchModifiers {
+		if e == m || (strings.HasSuffix(m, ":") && strings.HasPrefix(e, m)) {
+			return true
+		}
+	}
+	return false
+}
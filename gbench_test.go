@@ -0,0 +1,34 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package benchls
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGoogleBenchmark(t *testing.T) {
+	input := `{
+  "context": {"num_cpus": 8},
+  "benchmarks": [
+    {"name": "BM_Sort/1024", "run_type": "iteration", "threads": 1, "iterations": 5000,
+     "real_time": 1.5, "cpu_time": 1.25, "time_unit": "us", "bytes_per_second": 2e9, "CacheMisses": 12},
+    {"name": "BM_Sort/1024", "run_type": "aggregate", "aggregate_name": "mean", "threads": 1, "iterations": 2,
+     "real_time": 1.5, "cpu_time": 1.25, "time_unit": "us"},
+    {"name": "BM_Copy/size:64/real_time/threads:4", "run_type": "iteration", "threads": 4, "iterations": 100,
+     "real_time": 20, "cpu_time": 80, "time_unit": "ns", "items_per_second": 5e7},
+    {"name": "BM_Fail/8", "run_type": "iteration", "error_occurred": true, "error_message": "oops"}
+  ]
+}`
+	want := "BenchmarkBM_Sort/1024-1\t5000\t1500 ns/op\t1250 cpu-ns/op\t2000 MB/s\t12 CacheMisses\n" +
+		"BenchmarkBM_Copy/size=64-4\t100\t20 ns/op\t80 cpu-ns/op\t5e+07 items/s\n"
+	got, err := GoogleBenchmark(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if string(got) != want {
+		t.Errorf("expected\n%s\ngot\n%s", want, got)
+	}
+}
@@ -0,0 +1,197 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package benchls
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strconv"
+	"strings"
+)
+
+// GoSource returns the expression as Go source of type float64, in which each
+// named variable is a float64 named by rename.  Constants and shorthands are
+// expanded, and conditional expressions and the results of multiple return
+// math functions are written as function literals.
+func (e Expression) GoSource(rename func(string) string) (string, error) {
+	src, err := operators(e.src)
+	if err != nil {
+		return "", err
+	}
+	n, err := parser.ParseExpr(src)
+	if err != nil {
+		return "", err
+	}
+	g := goSource{rw: &rewriter{vars: e.vars, consts: e.consts}, rename: rename}
+	return g.expr(n)
+}
+
+// goSource writes expressions as Go source.
+type goSource struct {
+	rw     *rewriter
+	rename func(string) string
+}
+
+func (g goSource) expr(n ast.Expr) (string, error) {
+	switch n := n.(type) {
+	case *ast.BasicLit:
+		switch n.Kind {
+		case token.FLOAT:
+			return n.Value, nil
+		case token.INT:
+			// an untyped integer constant would divide as an integer
+			i, err := strconv.ParseInt(n.Value, 0, 64)
+			if err != nil {
+				return "", err
+			}
+			return strconv.FormatInt(i, 10) + ".0", nil
+		}
+	case *ast.Ident:
+		if c, ok := g.rw.constant(n.Name); ok {
+			return g.expr(c)
+		}
+		if sh, ok := g.rw.shorthand(n.Name); ok {
+			return g.expr(sh)
+		}
+		if _, ok := g.rw.vars[n.Name]; ok {
+			return g.rename(n.Name), nil
+		}
+		return "", errors.New("unknown variable " + n.Name)
+	case *ast.ParenExpr:
+		x, err := g.expr(n.X)
+		return "(" + x + ")", err
+	case *ast.UnaryExpr:
+		if n.Op == token.SUB || n.Op == token.ADD {
+			x, err := g.expr(n.X)
+			return n.Op.String() + x, err
+		}
+	case *ast.BinaryExpr:
+		switch n.Op {
+		case token.ADD, token.SUB, token.MUL, token.QUO, token.REM:
+			x, err := g.expr(n.X)
+			if err != nil {
+				return "", err
+			}
+			y, err := g.expr(n.Y)
+			if err != nil {
+				return "", err
+			}
+			if n.Op == token.REM {
+				return "math.Mod(" + x + ", " + y + ")", nil
+			}
+			return x + " " + n.Op.String() + " " + y, nil
+		}
+	case *ast.IndexExpr:
+		if call, ok := n.X.(*ast.CallExpr); ok && multiFunc(call) != "" {
+			lit, ok := n.Index.(*ast.BasicLit)
+			if !ok || lit.Kind != token.INT {
+				return "", errors.New("result index must be an integer literal: " + format(n))
+			}
+			var i int
+			fmt.Sscan(lit.Value, &i)
+			return g.multi(call, i)
+		}
+	case *ast.CallExpr:
+		if id, ok := n.Fun.(*ast.Ident); ok && id.Name == ifFunc && len(n.Args) == 3 {
+			return g.ifCall(n)
+		}
+		if multiFunc(n) != "" {
+			return g.multi(n, 0)
+		}
+		sel, ok := n.Fun.(*ast.SelectorExpr)
+		if !ok {
+			break
+		}
+		if pkg, ok := sel.X.(*ast.Ident); !ok || pkg.Name != "math" {
+			break
+		}
+		args := make([]string, len(n.Args))
+		for i, arg := range n.Args {
+			var err error
+			if args[i], err = g.expr(arg); err != nil {
+				return "", err
+			}
+		}
+		return "math." + sel.Sel.Name + "(" + strings.Join(args, ", ") + ")", nil
+	}
+	return "", errors.New("cannot write as Go: " + unternary(format(n)))
+}
+
+// cond writes a condition, which compares expressions, possibly combined
+// with !, && and ||.
+func (g goSource) cond(n ast.Expr) (string, error) {
+	switch n := n.(type) {
+	case *ast.ParenExpr:
+		x, err := g.cond(n.X)
+		return "(" + x + ")", err
+	case *ast.UnaryExpr:
+		if n.Op == token.NOT {
+			x, err := g.cond(n.X)
+			return "!" + x, err
+		}
+	case *ast.BinaryExpr:
+		var x, y string
+		var err error
+		switch n.Op {
+		case token.LAND, token.LOR:
+			if x, err = g.cond(n.X); err != nil {
+				return "", err
+			}
+			y, err = g.cond(n.Y)
+		case token.LSS, token.LEQ, token.GTR, token.GEQ, token.EQL, token.NEQ:
+			if x, err = g.expr(n.X); err != nil {
+				return "", err
+			}
+			y, err = g.expr(n.Y)
+		default:
+			return "", errors.New("invalid condition: " + format(n))
+		}
+		return x + " " + n.Op.String() + " " + y, err
+	}
+	return "", errors.New("invalid condition: " + format(n))
+}
+
+// ifCall writes a conditional expression as a function literal.
+func (g goSource) ifCall(call *ast.CallExpr) (string, error) {
+	c, err := g.cond(call.Args[0])
+	if err != nil {
+		return "", err
+	}
+	a, err := g.expr(call.Args[1])
+	if err != nil {
+		return "", err
+	}
+	b, err := g.expr(call.Args[2])
+	if err != nil {
+		return "", err
+	}
+	return "func() float64 {\nif " + c + " {\nreturn " + a + "\n}\nreturn " + b + "\n}()", nil
+}
+
+// multi writes the i'th result of a multiple return math function as a
+// function literal.
+func (g goSource) multi(call *ast.CallExpr, i int) (string, error) {
+	name := multiFunc(call)
+	if len(call.Args) != 1 {
+		return "", errors.New("math." + name + " takes one argument: " + format(call))
+	}
+	n := len(multiFuncs[name](0))
+	if i < 0 || i >= n {
+		return "", fmt.Errorf("math.%s has %d results, cannot select result %d", name, n, i)
+	}
+	arg, err := g.expr(call.Args[0])
+	if err != nil {
+		return "", err
+	}
+	results := make([]string, n)
+	for j := range results {
+		results[j] = "_"
+	}
+	results[i] = "r"
+	return "func() float64 {\n" + strings.Join(results, ", ") + " := math." + name + "(" + arg + ")\nreturn float64(r)\n}()", nil
+}
@@ -0,0 +1,45 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package benchls
+
+import (
+	"go/parser"
+	"strings"
+	"testing"
+)
+
+func TestGoSource(t *testing.T) {
+	consts := map[string]float64{"B": 4096}
+	names := map[string]struct{}{"N": struct{}{}}
+	for _, test := range []struct {
+		src, want string
+	}{
+		{"N * math.Log(N)", "n * math.Log(n)"},
+		{"1/2 * N", "1.0 / 2.0 * n"},
+		{"NlogN", "(n * math.Log(n))"},
+		{"N^2 / B", "math.Pow(n, 2.0) / (4096.0)"},
+		{"N % 3", "math.Mod(n, 3.0)"},
+		{"-math.Max(N, 2)", "-math.Max(n, 2.0)"},
+		{"math.Frexp(N)[1]", "func() float64 {\n_, r := math.Frexp(n)\nreturn float64(r)\n}()"},
+		{"N < 1024 && !(N == 0) ? N : 2 * N", "func() float64 {\nif n < 1024.0 && !(n == 0.0) {\nreturn n\n}\nreturn 2.0 * n\n}()"},
+	} {
+		e, err := NewExpression(test.src, names, consts)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", test.src, err)
+			continue
+		}
+		got, err := e.GoSource(strings.ToLower)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", test.src, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("%s: expected %q, got %q", test.src, test.want, got)
+		}
+		if _, err := parser.ParseExpr(got); err != nil {
+			t.Errorf("%s: %q is not a Go expression: %v", test.src, got, err)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package benchls
+
+import (
+	"math"
+	"sort"
+	"strings"
+)
+
+// Class is a candidate complexity class.  In both the name and the terms, %s
+// stands for the input variable.
+type Class struct {
+	Name  string
+	Terms string // explanatory terms, separated by commas
+}
+
+// Classes are the complexity classes that Infer considers.
+var Classes = []Class{
+	{"O(1)", "1.0"},
+	{"O(log %s)", "math.Log(%s), 1.0"},
+	{"O(%s)", "%s, 1.0"},
+	{"O(%s log %s)", "%s * math.Log(%s), 1.0"},
+	{"O(%s^2)", "%s * %s, 1.0"},
+	{"O(%s^3)", "%s * %s * %s, 1.0"},
+	{"O(2^%s)", "math.Pow(2, %s), 1.0"},
+}
+
+// Candidate is the fit of one complexity class to a group.
+type Candidate struct {
+	Class       string // the class name, in terms of the input variable
+	Fit         *Fit
+	AdjRSquared float64 // centered R squared, adjusted for the number of terms
+	AIC         float64 // Akaike information criterion, lower is better
+}
+
+type byAIC []Candidate
+
+func (cs byAIC) Len() int           { return len(cs) }
+func (cs byAIC) Less(i, j int) bool { return cs[i].AIC < cs[j].AIC }
+func (cs byAIC) Swap(i, j int)      { cs[i], cs[j] = cs[j], cs[i] }
+
+// Infer fits each of the Classes in the input variable v to every group, and
+// returns the candidates for each group with the lowest AIC first.  Classes
+// that cannot be fit to a group, like O(2^N) when 2^N overflows, or that have
+// as many terms as the group has observations, are left out.  The classes are
+// fit with opts.
+func Infer(benchSet Set, ex Extractor, v string, yExpr Expression, yVar string, opts Options) (map[string][]Candidate, error) {
+	cands := make(map[string][]Candidate)
+	for _, c := range Classes {
+		xExprs, err := NewExpressions(strings.Replace(c.Terms, "%s", v, -1), map[string]struct{}{v: {}}, nil)
+		if err != nil {
+			return nil, err
+		}
+		name := strings.Replace(c.Name, "%s", v, -1)
+		for g, s := range SampleGroup(benchSet, ex, xExprs, yExpr, yVar) {
+			if cand, ok := candidate(name, s, opts); ok {
+				cands[g] = append(cands[g], cand)
+			}
+		}
+	}
+	for _, cs := range cands {
+		sort.Stable(byAIC(cs))
+	}
+	return cands, nil
+}
+
+// candidate fits s and scores the fit.
+func candidate(name string, s Sample, opts Options) (Candidate, bool) {
+	n, k := len(s.Y), len(s.X)/len(s.Y)
+	if n <= k+1 {
+		return Candidate{}, false
+	}
+	for _, x := range s.X {
+		if math.IsInf(x, 0) || math.IsNaN(x) {
+			return Candidate{}, false
+		}
+	}
+	fit := NewFit(s, opts)
+	if fit == nil {
+		return Candidate{}, false
+	}
+
+	// the centered R squared, unlike Stats.AdjRSquared, does not credit O(1)
+	// with explaining the mean
+	mean := 0.0
+	for _, y := range s.Y {
+		mean += y / float64(n)
+	}
+	RSS, TSS := 0.0, 0.0
+	res, _ := Standardized(fit.Model, s)
+	for i, y := range s.Y {
+		RSS += res[i] * res[i]
+		TSS += (y - mean) * (y - mean)
+	}
+	return Candidate{
+		Class:       name,
+		Fit:         fit,
+		AdjRSquared: 1 - (RSS/float64(n-k))/(TSS/float64(n-1)),
+		AIC:         fit.Stats.AIC,
+	}, true
+}
@@ -0,0 +1,46 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package benchls
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"testing"
+
+	"golang.org/x/perf/benchfmt"
+)
+
+func TestInfer(t *testing.T) {
+	benchSet := make(Set)
+	for n := 10.0; n <= 1e6; n *= 10 {
+		for _, g := range []string{"BenchmarkSort", "BenchmarkSum"} {
+			ns := 20 * n * math.Log(n) * (1 + 0.01*math.Sin(n))
+			if g == "BenchmarkSum" {
+				ns = 3*n + 50
+			}
+			name := g + strconv.Itoa(int(n)) + "-4"
+			benchSet[name] = []*benchfmt.Result{{Iters: 1, Values: []benchfmt.Value{{Value: ns, Unit: "ns/op"}}}}
+		}
+	}
+	ex := RegexpExtractor{regexp.MustCompile(`(?P<N>\d+)-\d+$`)}
+	yExpr, err := NewExpression("Y", map[string]struct{}{"Y": {}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cands, err := Infer(benchSet, ex, "N", yExpr, "NsPerOp", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for g, want := range map[string]string{"BenchmarkSort": "O(N log N)", "BenchmarkSum": "O(N)"} {
+		if len(cands[g]) == 0 {
+			t.Errorf("%s: no candidates", g)
+			continue
+		}
+		if got := cands[g][0].Class; got != want {
+			t.Errorf("%s: expected %s, got %s", g, want, got)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package benchls
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// JMH converts the results of the Java Microbenchmark Harness, written with
+// -rf csv or -rf json, to go test -bench output.  A benchmark like
+// org.sample.Sort.quick with the parameter size=1000 on 1 thread is named
+// Benchmarkorg.sample.Sort.quick/size=1000-1.  Scores are converted to
+// ns/op, including throughputs, and in JSON each measurement of rawData is
+// a replicate, and the normalized allocation rate of -prof gc is B/op.
+func JMH(r io.Reader) ([]byte, error) {
+	input, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if trimmed := bytes.TrimSpace(input); len(trimmed) > 0 && trimmed[0] == '[' {
+		return jmhJSON(trimmed)
+	}
+	return jmhCSV(input)
+}
+
+// jmhResult is a result of JMH's JSON output.
+type jmhResult struct {
+	Benchmark     string            `json:"benchmark"`
+	Threads       int               `json:"threads"`
+	Params        map[string]string `json:"params"`
+	PrimaryMetric struct {
+		Score     float64     `json:"score"`
+		ScoreUnit string      `json:"scoreUnit"`
+		RawData   [][]float64 `json:"rawData"`
+	} `json:"primaryMetric"`
+	SecondaryMetrics map[string]struct {
+		Score     float64 `json:"score"`
+		ScoreUnit string  `json:"scoreUnit"`
+	} `json:"secondaryMetrics"`
+}
+
+func jmhJSON(input []byte) ([]byte, error) {
+	var results []jmhResult
+	if err := json.Unmarshal(input, &results); err != nil {
+		return nil, fmt.Errorf("jmh: %v", err)
+	}
+	var out bytes.Buffer
+	for _, res := range results {
+		keys := make([]string, 0, len(res.Params))
+		for k := range res.Params {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		params := make([][2]string, len(keys))
+		for i, k := range keys {
+			params[i] = [2]string{k, res.Params[k]}
+		}
+		name := benchName(res.Benchmark, params, res.Threads)
+
+		var extra []measurement
+		if gc, ok := res.SecondaryMetrics["·gc.alloc.rate.norm"]; ok && gc.ScoreUnit == "B/op" {
+			extra = append(extra, measurement{gc.Score, "B/op"})
+		}
+		scores := []float64{res.PrimaryMetric.Score}
+		if raw := res.PrimaryMetric.RawData; len(raw) > 0 {
+			scores = nil
+			for _, fork := range raw {
+				scores = append(scores, fork...)
+			}
+		}
+		for _, score := range scores {
+			ns, err := nsPerOp(score, res.PrimaryMetric.ScoreUnit)
+			if err != nil {
+				return nil, fmt.Errorf("jmh: %s: %v", res.Benchmark, err)
+			}
+			writeLine(&out, name, 1, append([]measurement{{ns, "ns/op"}}, extra...)...)
+		}
+	}
+	return out.Bytes(), nil
+}
+
+func jmhCSV(input []byte) ([]byte, error) {
+	records, err := csv.NewReader(bytes.NewReader(input)).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("jmh: %v", err)
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+	col := make(map[string]int)
+	var paramCols []int
+	for i, h := range records[0] {
+		col[h] = i
+		if strings.HasPrefix(h, "Param: ") {
+			paramCols = append(paramCols, i)
+		}
+	}
+	for _, h := range []string{"Benchmark", "Score", "Unit"} {
+		if _, ok := col[h]; !ok {
+			return nil, fmt.Errorf("jmh: the CSV has no %s column", h)
+		}
+	}
+
+	// the secondary metrics of -prof gc are rows of their own, like
+	// org.sample.Sort.quick:·gc.alloc.rate.norm
+	type line struct {
+		name string
+		ms   []measurement
+	}
+	var lines []*line
+	byName := make(map[string]*line)
+	for _, rec := range records[1:] {
+		if len(rec) != len(records[0]) {
+			continue
+		}
+		bench, secondary := rec[col["Benchmark"]], ""
+		if i := strings.Index(bench, ":"); i >= 0 {
+			bench, secondary = bench[:i], bench[i+1:]
+		}
+		var params [][2]string
+		for _, i := range paramCols {
+			if rec[i] != "" {
+				params = append(params, [2]string{strings.TrimPrefix(records[0][i], "Param: "), rec[i]})
+			}
+		}
+		threads := 1
+		if i, ok := col["Threads"]; ok {
+			threads, _ = strconv.Atoi(rec[i])
+		}
+		name := benchName(bench, params, threads)
+		score, err := strconv.ParseFloat(rec[col["Score"]], 64)
+		if err != nil {
+			return nil, fmt.Errorf("jmh: %s: %v", rec[col["Benchmark"]], err)
+		}
+		unit := rec[col["Unit"]]
+
+		if secondary != "" {
+			if l := byName[name]; l != nil && secondary == "·gc.alloc.rate.norm" && unit == "B/op" {
+				l.ms = append(l.ms, measurement{score, "B/op"})
+			}
+			continue
+		}
+		ns, err := nsPerOp(score, unit)
+		if err != nil {
+			return nil, fmt.Errorf("jmh: %s: %v", bench, err)
+		}
+		l := &line{name: name, ms: []measurement{{ns, "ns/op"}}}
+		lines = append(lines, l)
+		byName[name] = l
+	}
+	var out bytes.Buffer
+	for _, l := range lines {
+		writeLine(&out, l.name, 1, l.ms...)
+	}
+	return out.Bytes(), nil
+}
@@ -0,0 +1,48 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package benchls
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestJMH(t *testing.T) {
+	for _, test := range []struct {
+		format, input, want string
+	}{
+		{
+			"csv",
+			`"Benchmark","Mode","Threads","Samples","Score","Score Error (99.9%)","Unit","Param: size"
+"org.sample.Sort.quick","avgt",1,5,1.5,0.1,"us/op",1000
+"org.sample.Sort.quick:·gc.alloc.rate.norm","avgt",1,5,24.2,0.1,"B/op",1000
+"org.sample.Sort.quick","thrpt",4,5,2,0.1,"ops/ms",10000
+`,
+			"Benchmarkorg.sample.Sort.quick/size=1000-1\t1\t1500 ns/op\t24 B/op\n" +
+				"Benchmarkorg.sample.Sort.quick/size=10000-4\t1\t500000 ns/op\n",
+		},
+		{
+			"json",
+			`[{"benchmark": "org.sample.Sort.quick", "mode": "avgt", "threads": 1, "params": {"size": "1000", "kind": "random ints"},
+			  "primaryMetric": {"score": 1.5, "scoreUnit": "us/op", "rawData": [[1.4, 1.6], [1.5]]},
+			  "secondaryMetrics": {"·gc.alloc.rate.norm": {"score": 24, "scoreUnit": "B/op"}}}]`,
+			"Benchmarkorg.sample.Sort.quick/kind=random_ints/size=1000-1\t1\t1400 ns/op\t24 B/op\n" +
+				"Benchmarkorg.sample.Sort.quick/kind=random_ints/size=1000-1\t1\t1600 ns/op\t24 B/op\n" +
+				"Benchmarkorg.sample.Sort.quick/kind=random_ints/size=1000-1\t1\t1500 ns/op\t24 B/op\n",
+		},
+	} {
+		got, err := JMH(strings.NewReader(test.input))
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", test.format, err)
+			continue
+		}
+		if string(got) != test.want {
+			t.Errorf("%s: expected\n%s\ngot\n%s", test.format, test.want, got)
+		}
+	}
+	if _, err := JMH(strings.NewReader("Benchmark,Score,Unit\nfoo,1,furlongs/op\n")); err == nil {
+		t.Error("expected an error for an unknown unit")
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package benchls
+
+import (
+	"math"
+	"sort"
+
+	"golang.org/x/perf/benchfmt"
+)
+
+// responseUnits are the units of the Responses that are measured directly.
+var responseUnits = map[string]string{
+	"NsPerOp":           "ns/op",
+	"AllocedBytesPerOp": "B/op",
+	"AllocsPerOp":       "allocs/op",
+	"MBPerS":            "MB/s",
+}
+
+// Response returns yVar of res, which is one of Responses or the unit of a
+// metric as it is written in go test output, including the custom metrics
+// reported by b.ReportMetric like
+//
+//	BenchmarkFoo-4   1000   1234 ns/op   56 cachemisses/op
+//
+// It returns false if res does not have it.  BytesPerOp is implied by the
+// MB/s of b.SetBytes, so res does not have it without a throughput.
+func Response(res *benchfmt.Result, yVar string) (float64, bool) {
+	if yVar == "BytesPerOp" {
+		if _, ok := Response(res, "MBPerS"); !ok {
+			return 0, false
+		}
+		return bytesPerOp(res), true
+	}
+	if unit, ok := responseUnits[yVar]; ok {
+		yVar = unit
+	}
+	for _, v := range res.Values {
+		// benchfmt tidies units like ns/op to sec/op, but keeps the original
+		if v.OrigUnit == yVar {
+			return v.OrigValue, true
+		}
+		if v.OrigUnit == "" && v.Unit == yVar {
+			return v.Value, true
+		}
+	}
+	return 0, false
+}
+
+// bytesPerOp returns the number of bytes processed per op, as set by
+// b.SetBytes, or 0 if the benchmark did not report a throughput.
+func bytesPerOp(res *benchfmt.Result) float64 {
+	mbs, ok := Response(res, "MBPerS")
+	if !ok {
+		return 0
+	}
+	ns, ok := Response(res, "NsPerOp")
+	if !ok {
+		return 0
+	}
+	// MB/s is 1e6 bytes per 1e9 ns, and SetBytes takes an integer
+	return math.Floor(mbs*ns/1e3 + 0.5)
+}
+
+// Units returns the units of the measurements in the sets, as they are
+// written in go test output, sorted.
+func Units(sets ...Set) []string {
+	seen := make(map[string]struct{})
+	var units []string
+	for _, set := range sets {
+		for _, rs := range set {
+			for _, res := range rs {
+				for _, v := range res.Values {
+					unit := v.Unit
+					if v.OrigUnit != "" {
+						unit = v.OrigUnit
+					}
+					if _, ok := seen[unit]; !ok {
+						seen[unit] = struct{}{}
+						units = append(units, unit)
+					}
+				}
+			}
+		}
+	}
+	sort.Strings(units)
+	return units
+}
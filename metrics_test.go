@@ -0,0 +1,90 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package benchls
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestMetrics(t *testing.T) {
+	s := `
+goos: linux
+BenchmarkFoo10-4   	  100000	      1000 ns/op	      20 cachemisses/op
+BenchmarkFoo100-4  	   10000	     10000 ns/op	     200 cachemisses/op
+BenchmarkFoo1000-4 	    1000	    100000 ns/op
+PASS
+`
+	benchSet, err := ReadSet(strings.NewReader(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := Response(benchSet["BenchmarkFoo100-4"][0], "cachemisses/op"); !ok || got != 200 {
+		t.Errorf("expected 200 cachemisses/op, got %g, %v", got, ok)
+	}
+	if got := Units(benchSet); strings.Join(got, " ") != "cachemisses/op ns/op" {
+		t.Errorf("expected units cachemisses/op and ns/op, got %q", got)
+	}
+
+	ex := RegexpExtractor{regexp.MustCompile(`(?P<N>\d+)-\d+$`)}
+	xExprs, err := NewExpressions("N", ex.VarNames(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	yExpr, err := NewExpression("Y", map[string]struct{}{"Y": {}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the benchmark without the metric is left out
+	samp := SampleGroup(benchSet, ex, xExprs, yExpr, "cachemisses/op")["BenchmarkFoo"]
+	if len(samp.Y) != 2 || samp.Y[0] != 20 || samp.Y[1] != 200 {
+		t.Errorf("expected responses [20 200], got %v", samp.Y)
+	}
+}
+
+func TestResponseUnits(t *testing.T) {
+	s := `
+BenchmarkCopy10-4  	 1000000	      1000 ns/op	  10.00 MB/s	      64 B/op	       2 allocs/op
+BenchmarkCopy100-4 	  100000	     10000 ns/op	      640 B/op	       3 allocs/op
+BenchmarkCopy1000-4	   10000	    100000 ns/op	  10.00 MB/s
+PASS
+`
+	benchSet, err := ReadSet(strings.NewReader(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ex := RegexpExtractor{regexp.MustCompile(`(?P<N>\d+)-\d+$`)}
+	xExprs, err := NewExpressions("N", ex.VarNames(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	yExpr, err := NewExpression("Y", map[string]struct{}{"Y": {}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the benchmarks without the column are left out, rather than read as 0
+	for _, c := range []struct {
+		yVar string
+		want []float64
+	}{
+		{"B/op", []float64{64, 640}},
+		{"AllocedBytesPerOp", []float64{64, 640}},
+		{"allocs/op", []float64{2, 3}},
+		{"BytesPerOp", []float64{10, 1000}},
+	} {
+		samp := SampleGroup(benchSet, ex, xExprs, yExpr, c.yVar)["BenchmarkCopy"]
+		if len(samp.Y) != len(c.want) {
+			t.Errorf("%s: expected responses %v, got %v", c.yVar, c.want, samp.Y)
+			continue
+		}
+		for i := range c.want {
+			if samp.Y[i] != c.want[i] {
+				t.Errorf("%s: expected responses %v, got %v", c.yVar, c.want, samp.Y)
+				break
+			}
+		}
+	}
+}
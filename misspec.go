@@ -0,0 +1,90 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package benchls
+
+import (
+	"math"
+	"sort"
+)
+
+// Misspecification measures how the residuals of a fit, ordered by an input
+// variable, cluster by sign.  A model with the wrong transform, like a linear
+// fit of N log N data, leaves long runs of residuals of the same sign, which
+// have a Durbin-Watson statistic below 2 and fewer runs than chance would
+// give.
+type Misspecification struct {
+	DurbinWatson float64 // sum of squared differences of neighbors over the RSS, 2 if they are independent
+	Runs         int     // runs of residuals of the same sign
+	RunsP        float64 // p-value of so few runs, by the Wald-Wolfowitz runs test
+}
+
+// Misspecified orders the residuals of m, which was fit to s, by the input
+// variable v and measures how they cluster.  The statistics are NaN if s does
+// not have v, and RunsP is NaN if the residuals all have the same sign.
+func Misspecified(m Model, s Sample, v string) Misspecification {
+	nan := Misspecification{DurbinWatson: math.NaN(), RunsP: math.NaN()}
+	if len(s.Y) < 2 || len(s.Vars) != len(s.Y) {
+		return nan
+	}
+	order := make([]int, len(s.Y))
+	for i := range order {
+		if _, ok := s.Vars[i][v]; !ok {
+			return nan
+		}
+		order[i] = i
+	}
+	sort.Stable(byVar{order, s.Vars, v})
+
+	res, _ := Standardized(m, s)
+	var mis Misspecification
+	num, den := 0.0, 0.0
+	n1, n2 := 0, 0 // positive and negative residuals
+	last := 0.0
+	for k, i := range order {
+		e := res[i]
+		den += e * e
+		if k > 0 {
+			num += (e - res[order[k-1]]) * (e - res[order[k-1]])
+		}
+		switch {
+		case e > 0:
+			n1++
+		case e < 0:
+			n2++
+		default:
+			// zero residuals are not in any run
+			continue
+		}
+		if last*e <= 0 {
+			mis.Runs++
+		}
+		last = e
+	}
+	mis.DurbinWatson = num / den
+
+	mis.RunsP = math.NaN()
+	if n1 > 0 && n2 > 0 {
+		n := float64(n1 + n2)
+		p := 2 * float64(n1) * float64(n2)
+		mean := p/n + 1
+		variance := p * (p - n) / (n * n * (n - 1))
+		if variance > 0 {
+			z := (float64(mis.Runs) + 0.5 - mean) / math.Sqrt(variance) // with a continuity correction
+			mis.RunsP = 0.5 * math.Erfc(-z/math.Sqrt2)
+		}
+	}
+	return mis
+}
+
+// byVar sorts the indexes of observations by the value of an input variable.
+type byVar struct {
+	idx  []int
+	vars []map[string]float64
+	v    string
+}
+
+func (b byVar) Len() int           { return len(b.idx) }
+func (b byVar) Swap(i, j int)      { b.idx[i], b.idx[j] = b.idx[j], b.idx[i] }
+func (b byVar) Less(i, j int) bool { return b.vars[b.idx[i]][b.v] < b.vars[b.idx[j]][b.v] }
@@ -0,0 +1,37 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package benchls
+
+import (
+	"math"
+	"testing"
+)
+
+func TestMisspecified(t *testing.T) {
+	// N log N data, with noise that alternates in sign, in reverse order
+	var linear, nlogn Sample
+	for n := 20.0; n >= 1; n-- {
+		y := 3*n*math.Log(n) + 2 + 0.1*math.Cos(math.Pi*n)
+		linear.X = append(linear.X, n, 1.0)
+		nlogn.X = append(nlogn.X, n*math.Log(n), 1.0)
+		for _, s := range []*Sample{&linear, &nlogn} {
+			s.Y = append(s.Y, y)
+			s.Vars = append(s.Vars, map[string]float64{"N": n})
+		}
+	}
+
+	wrong := Misspecified(Estimate(linear, Options{}), linear, "N")
+	if wrong.DurbinWatson > 1 || !(wrong.RunsP < 0.01) {
+		t.Errorf("expected a linear fit of N log N to be misspecified, got %+v", wrong)
+	}
+	right := Misspecified(Estimate(nlogn, Options{}), nlogn, "N")
+	if right.DurbinWatson < 2 || !(right.RunsP > 0.5) {
+		t.Errorf("expected an N log N fit not to be misspecified, got %+v", right)
+	}
+
+	if mis := Misspecified(Estimate(nlogn, Options{}), nlogn, "M"); !math.IsNaN(mis.DurbinWatson) || !math.IsNaN(mis.RunsP) {
+		t.Errorf("expected NaN for a missing variable, got %+v", mis)
+	}
+}
@@ -0,0 +1,200 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package benchls
+
+import (
+	"context"
+	"go/ast"
+	"go/parser"
+	"math"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// Levenberg-Marquardt settings
+const (
+	lmIters   = 500
+	lmLambda  = 1e-3 // initial damping
+	lmMaxDamp = 1e16 // give up once the damping is this large
+	lmTol     = 1e-12
+)
+
+// Nonlinear is a model that is nonlinear in its parameters, like
+// a * math.Pow(N, b).  The parameters are the identifiers in the expression
+// that are not input variables.
+type Nonlinear struct {
+	Expr   Expression
+	Params []string // in order of first appearance
+}
+
+// NewNonlinear parses a nonlinear model in the named input variables and
+// constants, which may be nil.
+func NewNonlinear(src string, vars map[string]struct{}, consts map[string]float64) (*Nonlinear, error) {
+	n, err := parser.ParseExpr(src)
+	if err != nil {
+		return nil, err
+	}
+	nl := &Nonlinear{}
+	all := make(map[string]struct{}, len(vars))
+	for v := range vars {
+		all[v] = struct{}{}
+	}
+	rw := &rewriter{vars: vars, consts: consts}
+	ast.Inspect(n, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.SelectorExpr:
+			// math.Pow and the like are not parameters
+			return false
+		case *ast.Ident:
+			if _, ok := rw.shorthand(n.Name); ok {
+				break
+			}
+			if _, ok := rw.constant(n.Name); ok {
+				break
+			}
+			if _, ok := all[n.Name]; !ok {
+				all[n.Name] = struct{}{}
+				nl.Params = append(nl.Params, n.Name)
+			}
+		}
+		return true
+	})
+	if nl.Expr, err = NewExpression(src, all, consts); err != nil {
+		return nil, err
+	}
+	return nl, nil
+}
+
+// eval evaluates the model at the inputs of each observation in s.
+func (nl *Nonlinear) eval(s Sample, params []float64) []float64 {
+	f := make([]float64, len(s.Y))
+	for i, inputs := range s.Vars {
+		vars := make(map[string]float64, len(inputs)+len(params))
+		for k, v := range inputs {
+			vars[k] = v
+		}
+		for j, p := range nl.Params {
+			vars[p] = params[j]
+		}
+		f[i] = nl.Expr.Eval(vars)
+	}
+	return f
+}
+
+// jacobian estimates the derivatives of the model with respect to each
+// parameter by forward differences, in row major order.
+func (nl *Nonlinear) jacobian(s Sample, params, f []float64) []float64 {
+	k := len(params)
+	jac := make([]float64, len(s.Y)*k)
+	for j := range params {
+		h := 1e-7 * math.Max(math.Abs(params[j]), 1e-3)
+		shifted := append([]float64(nil), params...)
+		shifted[j] += h
+		fh := nl.eval(s, shifted)
+		for i := range fh {
+			jac[i*k+j] = (fh[i] - f[i]) / h
+		}
+	}
+	return jac
+}
+
+func rss(y, f []float64) float64 {
+	sum := 0.0
+	for i := range y {
+		sum += (y[i] - f[i]) * (y[i] - f[i])
+	}
+	return sum
+}
+
+// Fit estimates the parameters of nl for s by Levenberg-Marquardt, starting
+// with every parameter at 1.  The statistics are those of the model
+// linearized at the estimate, with opts.  Returns nil if it could not
+// converge.
+func (nl *Nonlinear) Fit(s Sample, opts Options) *Fit {
+	fit, _ := nl.FitContext(context.Background(), s, opts)
+	return fit
+}
+
+// FitContext is Fit, but stops iterating and returns ctx.Err() instead of a
+// fit once ctx is done.
+func (nl *Nonlinear) FitContext(ctx context.Context, s Sample, opts Options) (*Fit, error) {
+	k := len(nl.Params)
+	if k == 0 || len(s.Y) <= k {
+		return nil, nil
+	}
+	params := make([]float64, k)
+	for j := range params {
+		params[j] = 1
+	}
+	f := nl.eval(s, params)
+	cost := rss(s.Y, f)
+	lambda := lmLambda
+	for iter := 0; iter < lmIters && lambda < lmMaxDamp; iter++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		J := mat64.NewDense(len(s.Y), k, nl.jacobian(s, params, f))
+		r := make([]float64, len(s.Y))
+		for i := range r {
+			r[i] = s.Y[i] - f[i]
+		}
+		JTJ := mat64.NewDense(k, k, nil)
+		JTJ.Mul(J.T(), J)
+		JTr := mat64.NewDense(k, 1, nil)
+		JTr.Mul(J.T(), mat64.NewDense(len(r), 1, r))
+
+		// damp the diagonal, and try a step
+		A := mat64.DenseCopyOf(JTJ)
+		for j := 0; j < k; j++ {
+			A.Set(j, j, JTJ.At(j, j)*(1+lambda))
+		}
+		step := mat64.NewDense(k, 1, nil)
+		if err := step.Solve(A, JTr); err != nil {
+			lambda *= 10
+			continue
+		}
+		next := make([]float64, k)
+		for j := range next {
+			next[j] = params[j] + step.At(j, 0)
+		}
+		fNext := nl.eval(s, next)
+		nextCost := rss(s.Y, fNext)
+		if math.IsNaN(nextCost) || nextCost >= cost {
+			lambda *= 10
+			continue
+		}
+		converged := cost-nextCost <= lmTol*cost
+		params, f, cost = next, fNext, nextCost
+		lambda /= 10
+		if converged {
+			break
+		}
+	}
+	if math.IsNaN(cost) || math.IsInf(cost, 0) {
+		return nil, nil
+	}
+
+	// linearize: with X the jacobian and Y the residuals plus X * params, a
+	// linear fit of params has the same residuals and standard errors
+	lin := Sample{X: nl.jacobian(s, params, f), Y: make([]float64, len(s.Y))}
+	for i := range lin.Y {
+		lin.Y[i] = s.Y[i] - f[i]
+		for j, p := range params {
+			lin.Y[i] += lin.X[i*k+j] * p
+		}
+	}
+	st := NewStats(params, lin, opts)
+
+	// but the goodness of fit is of the original response
+	YSS := 0.0
+	for _, y := range s.Y {
+		YSS += y * y
+	}
+	st.RSquared = 1 - cost/YSS
+	st.AdjRSquared = 1 - (1-st.RSquared)*float64(len(s.Y))/float64(st.DOF)
+	st.F = (YSS - cost) / float64(k) / (cost / float64(st.DOF))
+	st.FP = fSurvival(st.F, k, st.DOF)
+	return &Fit{Model: params, Stats: st}, nil
+}
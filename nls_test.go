@@ -0,0 +1,57 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package benchls
+
+import (
+	"context"
+	"math"
+	"testing"
+)
+
+func TestNonlinear(t *testing.T) {
+	nl, err := NewNonlinear("a * math.Pow(N, b)", map[string]struct{}{"N": {}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nl.Params) != 2 || nl.Params[0] != "a" || nl.Params[1] != "b" {
+		t.Fatalf("expected parameters [a b], got %v", nl.Params)
+	}
+
+	var s Sample
+	for n := 10.0; n <= 1e6; n *= 2 {
+		s.Y = append(s.Y, 30*math.Pow(n, 1.2)*(1+0.01*math.Sin(n)))
+		s.Vars = append(s.Vars, map[string]float64{"N": n})
+	}
+	fit := nl.Fit(s, Options{})
+	if fit == nil {
+		t.Fatal("expected a fit")
+	}
+	if math.Abs(fit.Model[0]-30) > 3 || math.Abs(fit.Model[1]-1.2) > 0.01 {
+		t.Errorf("expected parameters near [30 1.2], got %v", fit.Model)
+	}
+	if fit.Stats.CI[1] <= 0 || fit.Stats.CI[1] > 0.01 {
+		t.Errorf("expected a small interval on b, got %g", fit.Stats.CI[1])
+	}
+	if fit.Stats.RSquared < 0.999 {
+		t.Errorf("expected a close fit, got R^2 %g", fit.Stats.RSquared)
+	}
+}
+
+func TestNonlinearFitContext(t *testing.T) {
+	nl, err := NewNonlinear("a * math.Pow(N, b)", map[string]struct{}{"N": {}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var s Sample
+	for n := 10.0; n <= 1e6; n *= 2 {
+		s.Y = append(s.Y, 30*math.Pow(n, 1.2))
+		s.Vars = append(s.Vars, map[string]float64{"N": n})
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if fit, err := nl.FitContext(ctx, s, Options{}); fit != nil || err != context.Canceled {
+		t.Errorf("expected a canceled fit, got %v, %v", fit, err)
+	}
+}
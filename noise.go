@@ -0,0 +1,53 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package benchls
+
+import "math"
+
+// Replicates describes the spread of the replicates of one benchmark, like
+// those of go test -count.
+type Replicates struct {
+	Name string
+	Vars map[string]float64 // input variables
+	N    int                // number of replicates
+	Mean float64
+	CV   float64 // coefficient of variation, the standard deviation over the mean
+}
+
+// ReplicateNoise returns the spread of the response of each benchmark name of
+// s that has more than one replicate, in the order of their first
+// observations.  s must not be aggregated.
+func ReplicateNoise(s Sample) []Replicates {
+	var names []string
+	reps := make(map[string][]int)
+	for i, name := range s.Names {
+		if reps[name] == nil {
+			names = append(names, name)
+		}
+		reps[name] = append(reps[name], i)
+	}
+	var noise []Replicates
+	for _, name := range names {
+		idx := reps[name]
+		if len(idx) < 2 {
+			continue
+		}
+		ys := make([]float64, len(idx))
+		for k, i := range idx {
+			ys[k] = s.Y[i]
+		}
+		m := mean(ys)
+		var ss float64
+		for _, y := range ys {
+			ss += (y - m) * (y - m)
+		}
+		r := Replicates{Name: name, N: len(idx), Mean: m, CV: math.Sqrt(ss/float64(len(ys)-1)) / math.Abs(m)}
+		if len(s.Vars) == len(s.Y) {
+			r.Vars = s.Vars[idx[0]]
+		}
+		noise = append(noise, r)
+	}
+	return noise
+}
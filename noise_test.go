@@ -0,0 +1,34 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package benchls
+
+import (
+	"math"
+	"testing"
+)
+
+func TestReplicateNoise(t *testing.T) {
+	s := Sample{
+		Y:     []float64{9, 100, 11, 50, 120, 80},
+		Names: []string{"Sort10", "Sort100", "Sort10", "Sort50", "Sort100", "Sort100"},
+		Vars:  []map[string]float64{{"N": 10}, {"N": 100}, {"N": 10}, {"N": 50}, {"N": 100}, {"N": 100}},
+	}
+	noise := ReplicateNoise(s)
+	if len(noise) != 2 {
+		t.Fatalf("expected the 2 names with replicates, got %v", noise)
+	}
+	for i, want := range []Replicates{
+		{Name: "Sort10", N: 2, Mean: 10, CV: math.Sqrt2 / 10},
+		{Name: "Sort100", N: 3, Mean: 100, CV: 0.2},
+	} {
+		got := noise[i]
+		if got.Name != want.Name || got.N != want.N || got.Mean != want.Mean || math.Abs(got.CV-want.CV) > 1e-12 {
+			t.Errorf("expected %+v, got %+v", want, got)
+		}
+	}
+	if noise[1].Vars["N"] != 100 {
+		t.Errorf("expected the input variables of Sort100, got %v", noise[1].Vars)
+	}
+}
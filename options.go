@@ -0,0 +1,36 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package benchls
+
+// Options are the settings of a fit and of the statistics that describe it.
+// They are passed to each function that fits or describes one, rather than
+// set for the whole package, so that fits with different settings can run
+// at the same time.  The zero value is the default settings.
+type Options struct {
+	// Confidence is the level of the confidence and prediction intervals,
+	// and of the tests for significance, or DefaultConfidence if it is 0.
+	Confidence float64
+
+	// HC1 uses White's heteroskedasticity consistent standard errors, scaled
+	// by n/(n-k), which remain valid when the variance of the response
+	// changes with the inputs, as it usually does in timings.
+	HC1 bool
+
+	// Native solves the least squares problems with a Householder QR
+	// decomposition written in Go, instead of with LAPACK's dgels.  For the
+	// few terms of most models they agree to within rounding.
+	Native bool
+}
+
+// DefaultConfidence is the confidence level of Options that do not set one.
+const DefaultConfidence = 0.95
+
+// level returns the confidence level of o.
+func (o Options) level() float64 {
+	if o.Confidence == 0 {
+		return DefaultConfidence
+	}
+	return o.Confidence
+}
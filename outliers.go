@@ -0,0 +1,57 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package benchls
+
+import (
+	"math"
+	"sort"
+)
+
+// thresholds above which an observation is an outlier
+const (
+	studentizedLimit = 3 // for the absolute studentized residual
+	cookLimit        = 4 // for Cook's distance, times the number of observations
+)
+
+// Influence describes how much one observation affects a fit.
+type Influence struct {
+	Name        string
+	Studentized float64 // externally studentized residual
+	Leverage    float64
+	Cook        float64 // Cook's distance
+}
+
+// Outliers returns the observations of s that unduly influence m, either
+// because their externally studentized residual is larger than 3 or because
+// their Cook's distance is larger than 4/n, largest Cook's distance first.
+// It returns nil if s has too few observations to tell.
+func Outliers(m Model, s Sample) []Influence {
+	n, k := len(s.Y), len(s.X)/len(s.Y)
+	if n-k < 2 {
+		return nil
+	}
+	_, std := Standardized(m, s)
+	var outs []Influence
+	for i, h := range leverages(s) {
+		r := std[i]
+		in := Influence{
+			Name:        s.Names[i],
+			Studentized: r * math.Sqrt(float64(n-k-1)/(float64(n-k)-r*r)),
+			Leverage:    h,
+			Cook:        r * r * h / (float64(k) * (1 - h)),
+		}
+		if math.Abs(in.Studentized) > studentizedLimit || in.Cook > cookLimit/float64(n) {
+			outs = append(outs, in)
+		}
+	}
+	sort.Sort(byCook(outs))
+	return outs
+}
+
+type byCook []Influence
+
+func (ins byCook) Len() int           { return len(ins) }
+func (ins byCook) Less(i, j int) bool { return ins[i].Cook > ins[j].Cook }
+func (ins byCook) Swap(i, j int)      { ins[i], ins[j] = ins[j], ins[i] }
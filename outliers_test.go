@@ -0,0 +1,31 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package benchls
+
+import (
+	"math"
+	"strconv"
+	"testing"
+)
+
+func TestOutliers(t *testing.T) {
+	var s Sample
+	for n := 1.0; n <= 10; n++ {
+		y := 2*n + 1 + 0.01*math.Sin(n)
+		if n == 4 {
+			y += 5
+		}
+		s.X = append(s.X, n, 1.0)
+		s.Y = append(s.Y, y)
+		s.Names = append(s.Names, "BenchmarkFoo"+strconv.Itoa(int(n)))
+	}
+	outs := Outliers(Estimate(s, Options{}), s)
+	if len(outs) == 0 || outs[0].Name != "BenchmarkFoo4" {
+		t.Fatalf("expected BenchmarkFoo4 to be the first outlier, got %v", outs)
+	}
+	if outs[0].Studentized < studentizedLimit {
+		t.Errorf("expected a large studentized residual, got %g", outs[0].Studentized)
+	}
+}
@@ -0,0 +1,126 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package benchls
+
+import (
+	"errors"
+	"go/token"
+	"sort"
+	"strings"
+)
+
+// poolFunc marks the terms of a pooled model that have a coefficient for each
+// group.
+const poolFunc = "group"
+
+// PoolTerms splits the terms of a pooled model, like "N, group(1.0)", into
+// explanatory terms for NewExpressions, without the group(), and whether each
+// of them has a coefficient for each group rather than one that all of the
+// groups share.
+func PoolTerms(src string) (string, []bool, error) {
+	toks := scan(src)
+	var terms []string
+	var perGroup []bool
+	start := 0
+	for i := 0; i <= len(toks); i++ {
+		if i < len(toks) && toks[i].tok != token.COMMA {
+			switch toks[i].tok {
+			case token.LPAREN, token.LBRACK, token.LBRACE:
+				if i = match(toks, i, 1); i < 0 {
+					return "", nil, errors.New("unbalanced brackets in " + src)
+				}
+			}
+			continue
+		}
+		if start == i {
+			return "", nil, errors.New("empty term in " + src)
+		}
+		term := toks[start:i]
+		lo, hi := term[0].off, term[len(term)-1].end
+		group := len(term) >= 3 && term[0].tok == token.IDENT && src[term[0].off:term[0].end] == poolFunc &&
+			term[1].tok == token.LPAREN && match(toks, start+1, 1) == i-1
+		if group {
+			lo, hi = term[1].end, term[len(term)-1].off
+		}
+		terms = append(terms, strings.TrimSpace(src[lo:hi]))
+		perGroup = append(perGroup, group)
+		start = i + 1
+	}
+	if len(terms) == 0 {
+		return "", nil, errors.New("no terms in " + src)
+	}
+	return strings.Join(terms, ", "), perGroup, nil
+}
+
+// Pooled fits all of the groups jointly, as in an analysis of covariance,
+// with one coefficient for each group for the terms that are perGroup, and
+// one that all of the groups share for the others.  The columns of X in each
+// sample are the terms.  The fit of each group has its own coefficients and
+// the shared ones, with their statistics from the joint fit, whose goodness
+// of fit every group has.  The joint fit is with opts.  The error is from
+// Underdetermined, or ErrSingularFit.
+func Pooled(samps map[string]Sample, perGroup []bool, opts Options) (map[string]*Fit, error) {
+	groups := make([]string, 0, len(samps))
+	for g := range samps {
+		groups = append(groups, g)
+	}
+	sort.Strings(groups)
+
+	// the column of the joint model of each term in each group
+	k := len(perGroup)
+	cols := make(map[string][]int, len(groups))
+	n := 0
+	for _, pg := range perGroup {
+		if !pg {
+			for _, g := range groups {
+				cols[g] = append(cols[g], n)
+			}
+			n++
+			continue
+		}
+		for _, g := range groups {
+			cols[g] = append(cols[g], n)
+			n++
+		}
+	}
+
+	var joint Sample
+	for _, g := range groups {
+		s := samps[g]
+		for i, y := range s.Y {
+			row := make([]float64, n)
+			for j, c := range cols[g] {
+				row[c] = s.X[i*k+j]
+			}
+			joint.X = append(joint.X, row...)
+			joint.Y = append(joint.Y, y)
+			joint.Names = append(joint.Names, s.Names[i])
+		}
+	}
+	if err := Underdetermined(joint, n); err != nil {
+		return nil, err
+	}
+	m, err := Solve(joint, opts)
+	if err != nil {
+		return nil, err
+	}
+	st := NewStats(m, joint, opts)
+
+	fits := make(map[string]*Fit, len(groups))
+	for _, g := range groups {
+		f := &Fit{Model: make(Model, k), Stats: st}
+		f.Stats.CI = make([]float64, k)
+		f.Stats.SE = make([]float64, k)
+		f.Stats.T = make([]float64, k)
+		f.Stats.P = make([]float64, k)
+		for j, c := range cols[g] {
+			f.Model[j] = m[c]
+			f.Stats.CI[j], f.Stats.SE[j] = st.CI[c], st.SE[c]
+			f.Stats.T[j], f.Stats.P[j] = st.T[c], st.P[c]
+		}
+		fits[g] = f
+	}
+	return fits, nil
+}
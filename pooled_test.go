@@ -0,0 +1,74 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package benchls
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPoolTerms(t *testing.T) {
+	for _, test := range []struct {
+		src, want string
+		perGroup  []bool
+	}{
+		{"N, group(1.0)", "N, 1.0", []bool{false, true}},
+		{"group(math.Max(N, 2)), N^2, group( 1 )", "math.Max(N, 2), N^2, 1", []bool{true, false, true}},
+		{"groups(N), group(N) * 2", "groups(N), group(N) * 2", []bool{false, false}},
+	} {
+		got, perGroup, err := PoolTerms(test.src)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", test.src, err)
+			continue
+		}
+		if got != test.want || len(perGroup) != len(test.perGroup) {
+			t.Errorf("%s: expected %q %v, got %q %v", test.src, test.want, test.perGroup, got, perGroup)
+			continue
+		}
+		for i := range perGroup {
+			if perGroup[i] != test.perGroup[i] {
+				t.Errorf("%s: expected %v, got %v", test.src, test.perGroup, perGroup)
+				break
+			}
+		}
+	}
+	for _, src := range []string{"", "N,, 1.0", "group(N"} {
+		if _, _, err := PoolTerms(src); err == nil {
+			t.Errorf("%s: expected an error", src)
+		}
+	}
+}
+
+func TestPooled(t *testing.T) {
+	// a shared slope of 3 per N, and an intercept of 10 or 100
+	samps := map[string]Sample{
+		"BenchmarkA": {X: []float64{1, 1, 2, 1}, Y: []float64{13, 16}, Names: []string{"A1", "A2"}},
+		"BenchmarkB": {X: []float64{1, 1, 3, 1, 5, 1}, Y: []float64{103.5, 108.5, 115}, Names: []string{"B1", "B3", "B5"}},
+	}
+	fits, err := Pooled(samps, []bool{false, true}, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, b := fits["BenchmarkA"], fits["BenchmarkB"]
+	if a.Model[0] != b.Model[0] || a.Stats.CI[0] != b.Stats.CI[0] {
+		t.Errorf("expected a shared slope, got %v and %v", a.Model, b.Model)
+	}
+	if math.Abs(a.Model[0]-3) > 0.2 || math.Abs(a.Model[1]-10) > 1 || math.Abs(b.Model[1]-100) > 1 {
+		t.Errorf("unexpected coefficients %v and %v", a.Model, b.Model)
+	}
+	// 5 observations of 3 coefficients
+	if a.Stats.DOF != 2 || b.Stats.DOF != 2 {
+		t.Errorf("expected 2 degrees of freedom, got %d", a.Stats.DOF)
+	}
+
+	// one observation of A alone could not be fit, but pooled it can
+	samps["BenchmarkA"] = Sample{X: []float64{1, 1}, Y: []float64{13}, Names: []string{"A1"}}
+	if _, err := Pooled(samps, []bool{false, true}, Options{}); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	if _, err := Pooled(samps, []bool{true, true}, Options{}); err == nil {
+		t.Error("expected too few observations for a slope per group")
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package benchls
+
+import "math"
+
+// PowerLaw is a fit of Y = Constant * v^Exponent, made as the linear fit of
+// log(Y) = Exponent * log(v) + log(Constant).
+type PowerLaw struct {
+	Exponent               float64
+	ExponentCI             float64 // half width of the confidence interval
+	Constant               float64
+	ConstantLo, ConstantHi float64 // the constant's interval is not symmetric
+	Fit                    *Fit    // of the logarithms
+}
+
+// PowerLaws fits a power law in the input variable v to every group.
+// Observations where v or the response is not positive have no logarithm,
+// and are left out.  Groups that cannot be fit are left out.  The logarithms
+// are fit with opts.
+func PowerLaws(benchSet Set, ex Extractor, v string, yExpr Expression, yVar string, opts Options) (map[string]PowerLaw, error) {
+	xExprs, err := NewExpressions("math.Log("+v+"), 1.0", map[string]struct{}{v: {}}, nil)
+	if err != nil {
+		return nil, err
+	}
+	pls := make(map[string]PowerLaw)
+	for g, s := range SampleGroup(benchSet, ex, xExprs, yExpr, yVar) {
+		if pl, ok := powerLaw(s, opts); ok {
+			pls[g] = pl
+		}
+	}
+	return pls, nil
+}
+
+// powerLaw takes the logarithm of the response of s and fits it.
+func powerLaw(s Sample, opts Options) (PowerLaw, bool) {
+	var logs Sample
+	for i, y := range s.Y {
+		x := s.X[2*i : 2*i+2]
+		if y <= 0 || math.IsInf(x[0], 0) || math.IsNaN(x[0]) {
+			continue
+		}
+		logs.X = append(logs.X, x...)
+		logs.Y = append(logs.Y, math.Log(y))
+	}
+	if len(logs.Y) <= 2 {
+		return PowerLaw{}, false
+	}
+	fit := NewFit(logs, opts)
+	if fit == nil {
+		return PowerLaw{}, false
+	}
+	a, ci := fit.Model[1], fit.Stats.CI[1]
+	return PowerLaw{
+		Exponent:   fit.Model[0],
+		ExponentCI: fit.Stats.CI[0],
+		Constant:   math.Exp(a),
+		ConstantLo: math.Exp(a - ci),
+		ConstantHi: math.Exp(a + ci),
+		Fit:        fit,
+	}, true
+}
@@ -0,0 +1,42 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package benchls
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"testing"
+
+	"golang.org/x/perf/benchfmt"
+)
+
+func TestPowerLaws(t *testing.T) {
+	benchSet := make(Set)
+	for n := 10.0; n <= 1e6; n *= 10 {
+		ns := 7 * math.Pow(n, 1.5) * (1 + 0.01*math.Sin(n))
+		name := "BenchmarkMul" + strconv.Itoa(int(n)) + "-4"
+		benchSet[name] = []*benchfmt.Result{{Iters: 1, Values: []benchfmt.Value{{Value: ns, Unit: "ns/op"}}}}
+	}
+	ex := RegexpExtractor{regexp.MustCompile(`(?P<N>\d+)-\d+$`)}
+	yExpr, err := NewExpression("Y", map[string]struct{}{"Y": {}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pls, err := PowerLaws(benchSet, ex, "N", yExpr, "NsPerOp", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pl, ok := pls["BenchmarkMul"]
+	if !ok {
+		t.Fatal("no power law for BenchmarkMul")
+	}
+	if math.Abs(pl.Exponent-1.5) > pl.ExponentCI || pl.ExponentCI > 0.01 {
+		t.Errorf("expected exponent 1.5, got %g±%g", pl.Exponent, pl.ExponentCI)
+	}
+	if !(pl.ConstantLo < 7 && 7 < pl.ConstantHi) {
+		t.Errorf("expected constant 7 in [%g, %g]", pl.ConstantLo, pl.ConstantHi)
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package benchls
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// PytestBenchmark converts the JSON of pytest-benchmark, written with
+// --benchmark-json or saved with --benchmark-autosave, to go test -bench
+// output.  A test like test_sort[1000] with the params {"n": 1000} is named
+// Benchmarktest_sort/n=1000-1.  Its mean time is ns/op and its median time the
+// metric median-ns/op.
+func PytestBenchmark(r io.Reader) ([]byte, error) {
+	var results struct {
+		Benchmarks []struct {
+			Name   string                 `json:"name"`
+			Params map[string]interface{} `json:"params"`
+			Stats  struct {
+				Mean       float64 `json:"mean"`
+				Median     float64 `json:"median"`
+				Rounds     int     `json:"rounds"`
+				Iterations int     `json:"iterations"`
+			} `json:"stats"`
+		} `json:"benchmarks"`
+	}
+	if err := json.NewDecoder(r).Decode(&results); err != nil {
+		return nil, fmt.Errorf("pytest-benchmark: %v", err)
+	}
+	var out bytes.Buffer
+	for _, b := range results.Benchmarks {
+		name := b.Name
+		keys := make([]string, 0, len(b.Params))
+		for k := range b.Params {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		var params [][2]string
+		for _, k := range keys {
+			params = append(params, [2]string{k, pytestParam(b.Params[k])})
+		}
+		if len(params) > 0 {
+			// the params are the id in brackets
+			if i := strings.Index(name, "["); i > 0 {
+				name = name[:i]
+			}
+		}
+
+		n := b.Stats.Rounds * b.Stats.Iterations
+		if n < 1 {
+			n = 1
+		}
+		writeLine(&out, benchName(name, params, 1), n,
+			measurement{b.Stats.Mean * 1e9, "ns/op"},
+			measurement{b.Stats.Median * 1e9, "median-ns/op"})
+	}
+	return out.Bytes(), nil
+}
+
+// pytestParam formats the value of a param, which may be any JSON value.
+func pytestParam(v interface{}) string {
+	switch v := v.(type) {
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case string:
+		return v
+	case nil:
+		return "None"
+	}
+	b, _ := json.Marshal(v)
+	return string(b)
+}
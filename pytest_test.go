@@ -0,0 +1,32 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package benchls
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPytestBenchmark(t *testing.T) {
+	input := `{
+  "machine_info": {"python_version": "3.11.4"},
+  "benchmarks": [
+    {"group": null, "name": "test_sort[1000000-quick]", "fullname": "tests/test_sort.py::test_sort[1000000-quick]",
+     "params": {"n": 1000000, "algo": "quick sort"}, "param": "1000000-quick",
+     "stats": {"min": 0.001, "max": 0.003, "mean": 0.0015, "median": 0.00125, "rounds": 20, "iterations": 5}},
+    {"group": null, "name": "test_noop", "fullname": "tests/test_sort.py::test_noop", "params": null,
+     "stats": {"mean": 2e-8, "median": 1e-8, "rounds": 1000, "iterations": 1}}
+  ]
+}`
+	want := "Benchmarktest_sort/algo=quick_sort/n=1000000-1\t100\t1.5e+06 ns/op\t1.25e+06 median-ns/op\n" +
+		"Benchmarktest_noop-1\t1000\t20 ns/op\t10 median-ns/op\n"
+	got, err := PytestBenchmark(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if string(got) != want {
+		t.Errorf("expected\n%s\ngot\n%s", want, got)
+	}
+}
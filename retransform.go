@@ -0,0 +1,70 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package benchls
+
+import (
+	"go/ast"
+	"go/parser"
+	"math"
+)
+
+// logBases are the bases of the logarithms of the math package.
+var logBases = map[string]float64{"Log": math.E, "Log2": 2, "Log10": 10}
+
+// LogResponse is a response transform that is the logarithm of an
+// expression, like math.Log(Y).  The fits of such a response can be reported
+// on the original scale: each coefficient b is the factor Base^b that a unit
+// of its term multiplies the response by.
+type LogResponse struct {
+	Base  float64
+	Inner string // the expression whose logarithm is the response
+}
+
+// NewLogResponse returns the LogResponse of yExpr, and whether it is a
+// logarithm.
+func NewLogResponse(yExpr Expression) (LogResponse, bool) {
+	n, err := parser.ParseExpr(yExpr.String())
+	if err != nil {
+		return LogResponse{}, false
+	}
+	for {
+		p, ok := n.(*ast.ParenExpr)
+		if !ok {
+			break
+		}
+		n = p.X
+	}
+	call, ok := n.(*ast.CallExpr)
+	if !ok || len(call.Args) != 1 {
+		return LogResponse{}, false
+	}
+	base, ok := logBases[mathName(call.Fun)]
+	if !ok {
+		return LogResponse{}, false
+	}
+	return LogResponse{Base: base, Inner: format(call.Args[0])}, true
+}
+
+// Inverse returns the value on the original scale of the transformed value v.
+func (l LogResponse) Inverse(v float64) float64 {
+	return math.Pow(l.Base, v)
+}
+
+// Smearing returns Duan's smearing estimate for m, which was fit to s: the
+// mean of the inverses of the residuals.  The inverse of a prediction
+// estimates the median of the response rather than its mean, which is the
+// inverse times the smearing estimate.
+func (l LogResponse) Smearing(m Model, s Sample) float64 {
+	stride := len(s.X) / len(s.Y)
+	sum := 0.0
+	for i, y := range s.Y {
+		yHat := 0.0
+		for j, xj := range s.X[i*stride : (i+1)*stride] {
+			yHat += m[j] * xj
+		}
+		sum += l.Inverse(y - yHat)
+	}
+	return sum / float64(len(s.Y))
+}
@@ -0,0 +1,49 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package benchls
+
+import (
+	"math"
+	"testing"
+)
+
+func TestNewLogResponse(t *testing.T) {
+	vars := map[string]struct{}{"Y": {}, "N": {}}
+	for _, tt := range []struct {
+		src   string
+		ok    bool
+		base  float64
+		inner string
+	}{
+		{"math.Log(Y)", true, math.E, "Y"},
+		{"(math.Log2(Y / N))", true, 2, "Y / N"},
+		{"math.Log10(Y)", true, 10, "Y"},
+		{"Y", false, 0, ""},
+		{"math.Sqrt(Y)", false, 0, ""},
+		{"math.Log(Y) / N", false, 0, ""},
+	} {
+		yExpr, err := NewExpression(tt.src, vars, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		l, ok := NewLogResponse(yExpr)
+		if ok != tt.ok || l.Base != tt.base || l.Inner != tt.inner {
+			t.Errorf("%s: expected %v %g %q, got %v %g %q", tt.src, tt.ok, tt.base, tt.inner, ok, l.Base, l.Inner)
+		}
+	}
+}
+
+func TestSmearing(t *testing.T) {
+	// residuals of ±1 about a constant
+	s := Sample{X: []float64{1, 1, 1, 1}, Y: []float64{1, 3, 1, 3}}
+	l := LogResponse{Base: 2}
+	m := Model{2}
+	if got := l.Inverse(3); got != 8 {
+		t.Errorf("expected inverse 8, got %g", got)
+	}
+	if got, want := l.Smearing(m, s), (2+0.5)/2; math.Abs(got-want) > 1e-12 {
+		t.Errorf("expected smearing %g, got %g", want, got)
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package benchls
+
+import "math"
+
+// qrSolve estimates the parameters of s by least squares, with the QR
+// decomposition of X by Householder reflections.  Returns nil if X does not
+// have full column rank.
+func qrSolve(s Sample) Model {
+	n, k := len(s.Y), len(s.X)/len(s.Y)
+	if n < k {
+		return nil
+	}
+	a := append([]float64(nil), s.X...)
+	b := append([]float64(nil), s.Y...)
+
+	for j := 0; j < k; j++ {
+		// the reflection v that zeroes column j below the diagonal
+		norm := 0.0
+		for i := j; i < n; i++ {
+			norm = math.Hypot(norm, a[i*k+j])
+		}
+		if norm == 0 {
+			return nil
+		}
+		if a[j*k+j] > 0 {
+			norm = -norm
+		}
+		v := make([]float64, n-j)
+		for i := j; i < n; i++ {
+			v[i-j] = a[i*k+j]
+		}
+		v[0] -= norm
+		vv := 0.0
+		for _, vi := range v {
+			vv += vi * vi
+		}
+		if vv == 0 {
+			continue
+		}
+
+		// apply I - 2vv'/v'v to the remaining columns and to b
+		for c := j; c < k; c++ {
+			dot := 0.0
+			for i := j; i < n; i++ {
+				dot += v[i-j] * a[i*k+c]
+			}
+			f := 2 * dot / vv
+			for i := j; i < n; i++ {
+				a[i*k+c] -= f * v[i-j]
+			}
+		}
+		dot := 0.0
+		for i := j; i < n; i++ {
+			dot += v[i-j] * b[i]
+		}
+		f := 2 * dot / vv
+		for i := j; i < n; i++ {
+			b[i] -= f * v[i-j]
+		}
+	}
+
+	// back substitute R m = Q'b
+	m := make(Model, k)
+	for j := k - 1; j >= 0; j-- {
+		r := a[j*k+j]
+		if r == 0 || math.IsNaN(r) {
+			return nil
+		}
+		sum := b[j]
+		for c := j + 1; c < k; c++ {
+			sum -= a[j*k+c] * m[c]
+		}
+		m[j] = sum / r
+	}
+	return m
+}
@@ -0,0 +1,41 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package benchls
+
+import (
+	"math"
+	"testing"
+)
+
+func TestNative(t *testing.T) {
+	var s Sample
+	for i, n := range []float64{10, 100, 1000, 10000, 100000, 1000000, 10000000} {
+		s.X = append(s.X, n*math.Log(n), n, 1)
+		s.Y = append(s.Y, 22*n*math.Log(n)+3*n+1000+float64(i%2)*1e4)
+	}
+	want := Estimate(s, Options{})
+	got := Estimate(s, Options{Native: true})
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	for j := range want {
+		if math.Abs(got[j]-want[j]) > 1e-8*math.Abs(want[j]) {
+			t.Errorf("coefficient %d: expected %g, got %g", j, want[j], got[j])
+		}
+	}
+
+	// collinear terms
+	s = Sample{X: []float64{1, 2, 2, 4, 3, 6}, Y: []float64{1, 2, 3}}
+	for _, native := range []bool{false, true} {
+		if m, err := Solve(s, Options{Native: native}); m != nil || err != ErrSingularFit {
+			t.Errorf("native %v: expected a singular fit, got %v, %v", native, m, err)
+		}
+	}
+
+	// too few observations
+	if m := qrSolve(Sample{X: []float64{1, 2}, Y: []float64{3}}); m != nil {
+		t.Errorf("expected no solution, got %v", m)
+	}
+}
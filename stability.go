@@ -0,0 +1,75 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package benchls
+
+import (
+	"context"
+	"math"
+	"math/rand"
+)
+
+// stabilityFrac is the fraction of observations kept in each subsample.
+const stabilityFrac = 0.8
+
+// subsample returns a sample made of n randomly chosen observations of s,
+// drawn without replacement.
+func subsample(s Sample, n int, rng *rand.Rand) Sample {
+	stride := len(s.X) / len(s.Y)
+	var sub Sample
+	for _, i := range rng.Perm(len(s.Y))[:n] {
+		sub.X = append(sub.X, s.X[i*stride:(i+1)*stride]...)
+		sub.Y = append(sub.Y, s.Y[i])
+	}
+	return sub
+}
+
+// Stability refits the model on reps random subsamples of s and returns the
+// relative standard deviation of the leading coefficient across the refits.
+// Small values indicate that the fit does not hinge on a few observations.
+// Returns NaN if there are too few observations to subsample.  The refits use
+// the solver of opts.
+func Stability(s Sample, reps int, rng *rand.Rand, opts Options) float64 {
+	st, _ := StabilityContext(context.Background(), s, reps, rng, opts)
+	return st
+}
+
+// StabilityContext is Stability, but stops refitting and returns ctx.Err()
+// once ctx is done, so that a server can bound the time a request takes.
+func StabilityContext(ctx context.Context, s Sample, reps int, rng *rand.Rand, opts Options) (float64, error) {
+	if len(s.Y) == 0 {
+		return math.NaN(), nil
+	}
+	stride := len(s.X) / len(s.Y)
+	n := int(stabilityFrac * float64(len(s.Y)))
+	if n <= stride || n == len(s.Y) {
+		return math.NaN(), nil
+	}
+
+	var b0s []float64
+	for i := 0; i < reps; i++ {
+		if err := ctx.Err(); err != nil {
+			return math.NaN(), err
+		}
+		m := Estimate(subsample(s, n, rng), opts)
+		if m == nil {
+			continue
+		}
+		b0s = append(b0s, m[0])
+	}
+	if len(b0s) < 2 {
+		return math.NaN(), nil
+	}
+
+	mean := 0.0
+	for _, b := range b0s {
+		mean += b
+	}
+	mean /= float64(len(b0s))
+	ss := 0.0
+	for _, b := range b0s {
+		ss += (b - mean) * (b - mean)
+	}
+	return math.Sqrt(ss/float64(len(b0s)-1)) / math.Abs(mean), nil
+}
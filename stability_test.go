@@ -0,0 +1,44 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package benchls
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestStability(t *testing.T) {
+	// an exact linear relationship should have no variation across subsamples
+	var s Sample
+	for n := 1.0; n <= 20; n++ {
+		s.X = append(s.X, n, 1.0)
+		s.Y = append(s.Y, 3*n+2)
+	}
+	rng := rand.New(rand.NewSource(1))
+	if got := Stability(s, 10, rng, Options{}); got > 1e-9 {
+		t.Errorf("expected stability of exact fit to be 0, got %g", got)
+	}
+
+	// too few observations to leave any out
+	s.X, s.Y = s.X[:4], s.Y[:2]
+	if got := Stability(s, 10, rng, Options{}); !math.IsNaN(got) {
+		t.Errorf("expected NaN stability for 2 observations, got %g", got)
+	}
+}
+
+func TestStabilityContext(t *testing.T) {
+	var s Sample
+	for n := 1.0; n <= 20; n++ {
+		s.X = append(s.X, n, 1.0)
+		s.Y = append(s.Y, 3*n+2)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if got, err := StabilityContext(ctx, s, 1000, rand.New(rand.NewSource(1)), Options{}); err != context.Canceled || !math.IsNaN(got) {
+		t.Errorf("expected a canceled refit, got %g, %v", got, err)
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package benchls
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+)
+
+// MaxCandidates is the most candidate terms that BestSubsets searches, since
+// it fits every subset of them.
+const MaxCandidates = 16
+
+// ErrTooManyCandidates is the error of BestSubsets for a sample with more than
+// MaxCandidates terms.
+var ErrTooManyCandidates = errors.New("too many candidate terms")
+
+// Subset is the fit of a subset of candidate terms.
+type Subset struct {
+	Terms []int // the indexes of the candidate terms, in order
+	Fit   *Fit
+}
+
+type byBIC []Subset
+
+func (ss byBIC) Len() int           { return len(ss) }
+func (ss byBIC) Less(i, j int) bool { return ss[i].Fit.Stats.BIC < ss[j].Fit.Stats.BIC }
+func (ss byBIC) Swap(i, j int)      { ss[i], ss[j] = ss[j], ss[i] }
+
+// BestSubsets fits every nonempty subset of the terms of s, whose explanatory
+// terms are all of the candidates, and returns the subsets with the lowest
+// BIC first.  Subsets that cannot be fit, because they have too many terms
+// for the observations, a term that is not finite, or terms that are linear
+// combinations of the others, are left out.  The subsets are fit with opts.
+// It returns ErrTooManyCandidates if s has more than MaxCandidates terms.
+func BestSubsets(s Sample, opts Options) ([]Subset, error) {
+	return BestSubsetsContext(context.Background(), s, opts)
+}
+
+// BestSubsetsContext is BestSubsets, but stops fitting subsets and returns
+// ctx.Err() once ctx is done, since there are up to 2^MaxCandidates of them.
+func BestSubsetsContext(ctx context.Context, s Sample, opts Options) ([]Subset, error) {
+	if len(s.Y) == 0 {
+		return nil, nil
+	}
+	k := len(s.X) / len(s.Y)
+	if k > MaxCandidates {
+		return nil, ErrTooManyCandidates
+	}
+	// finite reports whether candidate j is finite in every observation
+	finite := make([]bool, k)
+	for j := range finite {
+		finite[j] = true
+		for i := range s.Y {
+			x := s.X[i*k+j]
+			finite[j] = finite[j] && !math.IsNaN(x) && !math.IsInf(x, 0)
+		}
+	}
+
+	var subsets []Subset
+	for mask := 1; mask < 1<<uint(k); mask++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		var terms []int
+		for j := 0; j < k; j++ {
+			if mask&(1<<uint(j)) != 0 {
+				terms = append(terms, j)
+			}
+		}
+		if fit := fitSubset(s, terms, finite, opts); fit != nil {
+			subsets = append(subsets, Subset{Terms: terms, Fit: fit})
+		}
+	}
+	sort.Stable(byBIC(subsets))
+	return subsets, nil
+}
+
+// fitSubset fits the terms of s, or returns nil if they cannot be fit.
+func fitSubset(s Sample, terms []int, finite []bool, opts Options) *Fit {
+	for _, j := range terms {
+		if !finite[j] {
+			return nil
+		}
+	}
+	k := len(s.X) / len(s.Y)
+	sub := Sample{Y: s.Y, X: make([]float64, 0, len(s.Y)*len(terms))}
+	for i := range s.Y {
+		for _, j := range terms {
+			sub.X = append(sub.X, s.X[i*k+j])
+		}
+	}
+	return NewFit(sub, opts)
+}
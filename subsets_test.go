@@ -0,0 +1,68 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package benchls
+
+import (
+	"context"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestBestSubsets(t *testing.T) {
+	// candidates N * N, N * math.Log(N), N, math.Log(N) and 1.0, of N log N data
+	var s Sample
+	for n := 2.0; n <= 4096; n *= 2 {
+		s.X = append(s.X, n*n, n*math.Log(n), n, math.Log(n), 1.0)
+		s.Y = append(s.Y, 3*n*math.Log(n)+100+math.Sin(n))
+	}
+	subsets, err := BestSubsets(s, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(subsets) != 31 {
+		t.Fatalf("expected every subset to be fit, got %d", len(subsets))
+	}
+	if want := []int{1, 4}; !reflect.DeepEqual(subsets[0].Terms, want) {
+		t.Errorf("expected the best subset %v, got %v", want, subsets[0].Terms)
+	}
+	for i := 1; i < len(subsets); i++ {
+		if subsets[i].Fit.Stats.BIC < subsets[i-1].Fit.Stats.BIC {
+			t.Fatalf("subsets are not in order of BIC at %d", i)
+		}
+	}
+
+	// subsets with a term that is not finite are left out
+	s.X[3] = math.Inf(-1)
+	subsets, err = BestSubsets(s, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, sub := range subsets {
+		for _, j := range sub.Terms {
+			if j == 3 {
+				t.Fatalf("expected no subset with math.Log(N), got %v", sub.Terms)
+			}
+		}
+	}
+}
+
+func TestBestSubsetsContext(t *testing.T) {
+	var s Sample
+	for n := 1.0; n <= 20; n++ {
+		s.X = append(s.X, n*n, n, 1.0)
+		s.Y = append(s.Y, 3*n+2)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if subsets, err := BestSubsetsContext(ctx, s, Options{}); err != context.Canceled || subsets != nil {
+		t.Errorf("expected the search to be canceled, got %d subsets, %v", len(subsets), err)
+	}
+
+	s.X = make([]float64, 20*(MaxCandidates+1))
+	if _, err := BestSubsets(s, Options{}); err != ErrTooManyCandidates {
+		t.Errorf("expected %v, got %v", ErrTooManyCandidates, err)
+	}
+}
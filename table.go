@@ -0,0 +1,85 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package benchls
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// CSV converts a table of measurements, with a header row, to go test -bench
+// output, so that data that does not come from a benchmark harness can be fit
+// too.  The columns xCols are the input variables and yCol the response,
+// which is reported as a metric with the unit yCol.  With the xCols N and M
+// and the yCol seconds, a row with N=10 and M=3 is named
+// Benchmarkseconds/N=10/M=3-1, so -auto-vars finds the variables.
+func CSV(r io.Reader, xCols []string, yCol string) ([]byte, error) {
+	unit := strings.Join(strings.Fields(yCol), "_")
+	if unit == "" {
+		return nil, fmt.Errorf("csv: no response column")
+	}
+	if len(xCols) == 0 {
+		return nil, fmt.Errorf("csv: no input variable columns")
+	}
+	for _, x := range xCols {
+		if x == "" || strings.ContainsAny(x, "/= \t") {
+			return nil, fmt.Errorf("csv: invalid variable column name %q", x)
+		}
+	}
+
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	records, err := cr.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("csv: %v", err)
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+	col := make(map[string]int)
+	for i, h := range records[0] {
+		col[strings.TrimSpace(h)] = i
+	}
+	idx := make([]int, len(xCols))
+	for i, x := range xCols {
+		var ok bool
+		if idx[i], ok = col[x]; !ok {
+			return nil, fmt.Errorf("csv: no column %q, have %q", x, records[0])
+		}
+	}
+	y, ok := col[yCol]
+	if !ok {
+		return nil, fmt.Errorf("csv: no column %q, have %q", yCol, records[0])
+	}
+
+	var out bytes.Buffer
+	for row, rec := range records[1:] {
+		if len(rec) == 1 && strings.TrimSpace(rec[0]) == "" {
+			continue
+		}
+		if len(rec) != len(records[0]) {
+			return nil, fmt.Errorf("csv: row %d has %d columns, the header has %d", row+2, len(rec), len(records[0]))
+		}
+		params := make([][2]string, len(xCols))
+		for i, x := range xCols {
+			v := strings.TrimSpace(rec[idx[i]])
+			if _, err := strconv.ParseFloat(v, 64); err != nil {
+				return nil, fmt.Errorf("csv: row %d: %s is not a number: %q", row+2, x, v)
+			}
+			params[i] = [2]string{x, v}
+		}
+		v, err := strconv.ParseFloat(strings.TrimSpace(rec[y]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("csv: row %d: %s is not a number: %q", row+2, yCol, rec[y])
+		}
+		writeLine(&out, benchName(unit, params, 1), 1, measurement{v, unit})
+	}
+	return out.Bytes(), nil
+}
@@ -0,0 +1,43 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package benchls
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCSV(t *testing.T) {
+	input := `host, N, M, seconds
+a, 10, 3, 0.5
+b, 1e3, 3, 12
+
+`
+	want := "Benchmarkseconds/N=10/M=3-1\t1\t0.5 seconds\n" +
+		"Benchmarkseconds/N=1e3/M=3-1\t1\t12 seconds\n"
+	got, err := CSV(strings.NewReader(input), []string{"N", "M"}, "seconds")
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if string(got) != want {
+		t.Errorf("expected\n%s\ngot\n%s", want, got)
+	}
+
+	for _, test := range []struct {
+		input string
+		xCols []string
+		yCol  string
+	}{
+		{input, []string{"K"}, "seconds"},
+		{input, []string{"N"}, "minutes"},
+		{input, []string{"host"}, "seconds"},
+		{input, []string{"N/M"}, "seconds"},
+		{"N,seconds\n1,fast\n", []string{"N"}, "seconds"},
+	} {
+		if _, err := CSV(strings.NewReader(test.input), test.xCols, test.yCol); err == nil {
+			t.Errorf("%q %q: expected an error", test.xCols, test.yCol)
+		}
+	}
+}
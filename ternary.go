@@ -0,0 +1,275 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package benchls
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"strings"
+)
+
+// ifFunc is the call that a conditional expression, c ? a : b, is parsed as.
+const ifFunc = "_if"
+
+// ternaries rewrites the conditional expressions in src, like
+// N < 1024 ? N : N * math.Log(N), as calls of ifFunc, which Go can parse.
+// They have the lowest precedence and group to the right.
+func ternaries(src string) (string, error) {
+	t := ternary{src: src, toks: scan(src)}
+	found := false
+	for _, tk := range t.toks {
+		found = found || t.is(tk, "?")
+	}
+	if !found {
+		return src, nil
+	}
+	return t.list(0, len(t.toks))
+}
+
+type ternary struct {
+	src  string
+	toks []tok
+}
+
+// is reports whether tk is the punctuation p, which the Go scanner may not
+// know.
+func (t ternary) is(tk tok, p string) bool {
+	return t.src[tk.off:tk.end] == p
+}
+
+// list rewrites the comma separated expressions in toks[lo:hi].
+func (t ternary) list(lo, hi int) (string, error) {
+	if lo == hi {
+		return "", nil
+	}
+	var parts []string
+	start := lo
+	for i := lo; i <= hi; i++ {
+		if i < hi && t.toks[i].tok != token.COMMA {
+			if k := t.close(i); k > i {
+				i = k
+			}
+			continue
+		}
+		part, err := t.expr(start, i)
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, part)
+		start = i + 1
+	}
+	return strings.Join(parts, ", "), nil
+}
+
+// close returns the index of the bracket that closes toks[i], or i if it does
+// not open one.
+func (t ternary) close(i int) int {
+	switch t.toks[i].tok {
+	case token.LPAREN, token.LBRACK, token.LBRACE:
+		return match(t.toks, i, 1)
+	}
+	return i
+}
+
+// expr rewrites the expression in toks[lo:hi].
+func (t ternary) expr(lo, hi int) (string, error) {
+	if lo >= hi {
+		return "", errors.New("missing operand in conditional expression: " + t.src)
+	}
+	// the first ? at this level, and the : that matches it
+	q, c, depth := -1, -1, 0
+	for i := lo; i < hi && c < 0; i++ {
+		if k := t.close(i); k > i {
+			i = k
+			continue
+		}
+		switch {
+		case t.is(t.toks[i], "?"):
+			if q < 0 {
+				q = i
+			}
+			depth++
+		case t.toks[i].tok == token.COLON && q >= 0:
+			if depth--; depth == 0 {
+				c = i
+			}
+		}
+	}
+	if q < 0 {
+		return t.plain(lo, hi)
+	}
+	if c < 0 {
+		return "", errors.New("missing : in conditional expression: " + t.src)
+	}
+	cond, err := t.plain(lo, q)
+	if err != nil {
+		return "", err
+	}
+	a, err := t.expr(q+1, c)
+	if err != nil {
+		return "", err
+	}
+	b, err := t.expr(c+1, hi)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s(%s, %s, %s)", ifFunc, cond, a, b), nil
+}
+
+// plain copies toks[lo:hi], which has no conditional expression at this
+// level, rewriting any inside of brackets.
+func (t ternary) plain(lo, hi int) (string, error) {
+	if lo >= hi {
+		return "", errors.New("missing condition in conditional expression: " + t.src)
+	}
+	var out []string
+	pos := t.toks[lo].off
+	for i := lo; i < hi; i++ {
+		k := t.close(i)
+		if k < 0 || k >= hi {
+			return "", errors.New("unbalanced brackets: " + t.src)
+		}
+		if k == i {
+			continue
+		}
+		inner, err := t.list(i+1, k)
+		if err != nil {
+			return "", err
+		}
+		out = append(out, t.src[pos:t.toks[i].end], inner)
+		pos = t.toks[k].off
+		i = k
+	}
+	out = append(out, t.src[pos:t.toks[hi-1].end])
+	return strings.Join(out, ""), nil
+}
+
+// unternary formats the calls of ifFunc in a formatted expression as the
+// conditional expressions they were written as.
+func unternary(s string) string {
+	i := strings.Index(s, ifFunc+"(")
+	if i < 0 {
+		return s
+	}
+	// split the arguments at the commas outside of brackets
+	var args []string
+	depth, start, end := 0, i+len(ifFunc)+1, len(s)
+	for j := start; j < len(s) && end == len(s); j++ {
+		switch s[j] {
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			if depth == 0 {
+				args = append(args, s[start:j])
+				end = j
+			}
+			depth--
+		case ',':
+			if depth == 0 {
+				args = append(args, s[start:j])
+				start = j + 2 // after ", "
+			}
+		}
+	}
+	if len(args) != 3 {
+		return s
+	}
+	cond := unternary(args[0]) + " ? " + unternary(args[1]) + " : " + unternary(args[2])
+	// parenthesize it, unless it is already delimited
+	before, after := s[:i], s[end+1:]
+	if !(before == "" || strings.HasSuffix(before, "(") || strings.HasSuffix(before, ", ")) ||
+		!(after == "" || strings.HasPrefix(after, ")") || strings.HasPrefix(after, ",")) {
+		cond = "(" + cond + ")"
+	}
+	return before + cond + unternary(after)
+}
+
+// condition returns a function that evaluates a condition, which compares
+// expressions, possibly combined with !, && and ||.
+func (rw *rewriter) condition(n ast.Expr) (func(map[string]float64) bool, error) {
+	switch n := n.(type) {
+	case *ast.ParenExpr:
+		return rw.condition(n.X)
+	case *ast.UnaryExpr:
+		if n.Op == token.NOT {
+			c, err := rw.condition(n.X)
+			if err != nil {
+				return nil, err
+			}
+			return func(vars map[string]float64) bool { return !c(vars) }, nil
+		}
+	case *ast.BinaryExpr:
+		switch n.Op {
+		case token.LAND, token.LOR:
+			x, err := rw.condition(n.X)
+			if err != nil {
+				return nil, err
+			}
+			y, err := rw.condition(n.Y)
+			if err != nil {
+				return nil, err
+			}
+			if n.Op == token.LAND {
+				return func(vars map[string]float64) bool { return x(vars) && y(vars) }, nil
+			}
+			return func(vars map[string]float64) bool { return x(vars) || y(vars) }, nil
+		case token.LSS, token.LEQ, token.GTR, token.GEQ, token.EQL, token.NEQ:
+			x, err := rw.parse(n.X)
+			if err != nil {
+				return nil, err
+			}
+			y, err := rw.parse(n.Y)
+			if err != nil {
+				return nil, err
+			}
+			op := n.Op
+			return func(vars map[string]float64) bool {
+				a, b := x.Eval(vars), y.Eval(vars)
+				switch op {
+				case token.LSS:
+					return a < b
+				case token.LEQ:
+					return a <= b
+				case token.GTR:
+					return a > b
+				case token.GEQ:
+					return a >= b
+				case token.EQL:
+					return a == b
+				}
+				return a != b
+			}, nil
+		}
+	}
+	return nil, errors.New("invalid condition: " + format(n))
+}
+
+// ifCall replaces a conditional expression with an auxiliary variable.  Only
+// the chosen branch is evaluated.
+func (rw *rewriter) ifCall(call *ast.CallExpr, aux *[]auxVar) (ast.Expr, error) {
+	if len(call.Args) != 3 {
+		return nil, errors.New("invalid conditional expression: " + unternary(format(call)))
+	}
+	cond, err := rw.condition(call.Args[0])
+	if err != nil {
+		return nil, err
+	}
+	a, err := rw.parse(call.Args[1])
+	if err != nil {
+		return nil, err
+	}
+	b, err := rw.parse(call.Args[2])
+	if err != nil {
+		return nil, err
+	}
+	return rw.newAux(aux, func(vars map[string]float64) float64 {
+		if cond(vars) {
+			return a.Eval(vars)
+		}
+		return b.Eval(vars)
+	}), nil
+}
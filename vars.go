@@ -0,0 +1,117 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package benchls
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jonlawlor/parsefloat"
+	"golang.org/x/perf/benchfmt"
+)
+
+// An Extractor finds the group and the named input variables of a benchmark
+// from its name.
+type Extractor interface {
+	// VarNames returns the names of the variables that can be extracted.
+	VarNames() map[string]struct{}
+
+	// Extract returns false if the benchmark does not have input variables.
+	Extract(name string) (group string, vars map[string]float64, ok bool)
+}
+
+// RegexpExtractor finds input variables in the named capture groups of a
+// regexp.  The group is whatever the regexp did not match, followed by the
+// captures that are not numbers, like /algo=quick for the capture group
+// (?P<algo>[a-z]+), so that one regexp can both split the groups and find
+// the variables.
+type RegexpExtractor struct {
+	Regexp *regexp.Regexp
+}
+
+func (e RegexpExtractor) VarNames() map[string]struct{} {
+	return parsefloat.NamedVars(e.Regexp)
+}
+
+func (e RegexpExtractor) Extract(name string) (string, map[string]float64, bool) {
+	loc := e.Regexp.FindStringSubmatchIndex(name)
+	if loc == nil {
+		return "", nil, false
+	}
+	// create the group name from whatever didn't match
+	group := name[:loc[0]] + name[loc[1]:]
+
+	// convert input string matches into a variable map, or the group
+	vars := make(map[string]float64)
+	for i, varname := range e.Regexp.SubexpNames() {
+		if i == 0 || loc[2*i] < 0 {
+			continue
+		}
+		input := name[loc[2*i]:loc[2*i+1]]
+		val, err := strconv.ParseFloat(input, 64)
+		if err != nil {
+			if varname != "" {
+				input = varname + "=" + input
+			}
+			group += "/" + input
+			continue
+		}
+		vars[varname] = val
+	}
+	return group, vars, true
+}
+
+// AutoExtractor finds input variables in the key=value parts of sub-benchmark
+// names, like BenchmarkSort/size=1000/algo=quick-8, as benchfmt splits them.
+// Parts with numeric values become variables, and the rest of the name,
+// without the GOMAXPROCS suffix, is the group: BenchmarkSort/algo=quick.
+type AutoExtractor struct {
+	names map[string]struct{}
+}
+
+// NewAutoExtractor finds the variable names used in benchSet.
+func NewAutoExtractor(benchSet Set) AutoExtractor {
+	e := AutoExtractor{names: make(map[string]struct{})}
+	for name := range benchSet {
+		_, vars, _ := e.Extract(name)
+		for k := range vars {
+			e.names[k] = struct{}{}
+		}
+	}
+	return e
+}
+
+func (e AutoExtractor) VarNames() map[string]struct{} {
+	names := make(map[string]struct{}, len(e.names))
+	for k := range e.names {
+		names[k] = struct{}{}
+	}
+	return names
+}
+
+func (e AutoExtractor) Extract(name string) (string, map[string]float64, bool) {
+	base, parts := benchfmt.Name(name).Parts()
+	group := string(base)
+	vars := make(map[string]float64)
+	for _, part := range parts {
+		if part[0] == '-' {
+			// GOMAXPROCS
+			continue
+		}
+		elem := string(part[1:])
+		if i := strings.Index(elem, "="); i > 0 {
+			if val, err := strconv.ParseFloat(elem[i+1:], 64); err == nil {
+				vars[elem[:i]] = val
+				continue
+			}
+		}
+		group += "/" + elem
+	}
+	if len(vars) == 0 {
+		return "", nil, false
+	}
+	return group, vars, true
+}
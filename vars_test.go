@@ -0,0 +1,36 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package benchls
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestRegexpExtractor(t *testing.T) {
+	for _, test := range []struct {
+		re, name, group string
+		n               float64
+	}{
+		{`/?(?P<N>\d+)-\d+$`, "BenchmarkSort10-4", "BenchmarkSort", 10},
+		// the group ends in characters of the match
+		{`/?(?P<N>\d+)-\d+$`, "BenchmarkSort4/10-4", "BenchmarkSort4", 10},
+		{`/?(?P<N>\d+)-\d+$`, "BenchmarkLog1/100-4", "BenchmarkLog1", 100},
+		// the match need not be a suffix
+		{`size(?P<N>\d+)`, "BenchmarkCopysize64/aligned-8", "BenchmarkCopy/aligned-8", 64},
+		// captures that are not numbers are part of the group
+		{`/(?P<algo>[a-z]+)(?P<N>\d+)-\d+$`, "BenchmarkSort/quick1000-4", "BenchmarkSort/algo=quick", 1000},
+		{`_(?P<algo>[a-z]+)/(?P<N>\d+)$`, "BenchmarkSort_heap/64", "BenchmarkSort/algo=heap", 64},
+		{`/?(?P<N>\d+)(?:-(?P<kind>x))?$`, "BenchmarkSort10", "BenchmarkSort", 10},
+	} {
+		group, vars, ok := RegexpExtractor{regexp.MustCompile(test.re)}.Extract(test.name)
+		if !ok || group != test.group || vars["N"] != test.n {
+			t.Errorf("%s: expected %s with N=%g, got %q %v %v", test.name, test.group, test.n, group, vars, ok)
+		}
+	}
+	if _, _, ok := (RegexpExtractor{regexp.MustCompile(`(?P<N>\d+)-\d+$`)}).Extract("BenchmarkSort"); ok {
+		t.Error("expected no match")
+	}
+}
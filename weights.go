@@ -0,0 +1,170 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package benchls
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// varPowerIters is the number of times the variance power is re-estimated.
+const varPowerIters = 5
+
+// The estimated power is limited to [0, maxVarPower].  Benchmark noise does not
+// shrink as measurements grow, and with few observations the estimate can
+// otherwise wander far enough to put all of the weight on a single point.
+const maxVarPower = 4
+
+// Weighted returns s with each observation scaled by the square root of its
+// weight, so that ordinary least squares on the result is weighted least
+// squares on s.
+func Weighted(s Sample, w []float64) Sample {
+	stride := len(s.X) / len(s.Y)
+	ws := s
+	ws.X = make([]float64, len(s.X))
+	ws.Y = make([]float64, len(s.Y))
+	for i, y := range s.Y {
+		sw := math.Sqrt(w[i])
+		for j, x := range s.X[i*stride : (i+1)*stride] {
+			ws.X[i*stride+j] = sw * x
+		}
+		ws.Y[i] = sw * y
+	}
+	return ws
+}
+
+// VarPower fits s assuming that the residual variance is proportional to a
+// power of the fitted mean.  It alternates between estimating the power, by
+// regressing the log squared residuals on the log absolute fitted values, and
+// refitting with weights of |fitted|^-power.  The power is limited to
+// [0, maxVarPower].  It returns the model, the weighted sample it was fit to,
+// and the estimated power.  It returns ErrSingularFit, and a nil model, if
+// any of the fits of s fail.
+func VarPower(s Sample, opts Options) (Model, Sample, float64, error) {
+	return VarPowerContext(context.Background(), s, opts)
+}
+
+// VarPowerContext is VarPower, but stops reestimating the power and returns
+// ctx.Err(), and a nil model, once ctx is done.
+func VarPowerContext(ctx context.Context, s Sample, opts Options) (Model, Sample, float64, error) {
+	m, err := Solve(s, opts)
+	if err != nil {
+		return nil, s, 0, err
+	}
+	stride := len(s.X) / len(s.Y)
+	ws := s
+	power := 0.0
+	for iter := 0; iter < varPowerIters; iter++ {
+		if err := ctx.Err(); err != nil {
+			return nil, ws, power, err
+		}
+		var logFit Sample
+		fitted := make([]float64, len(s.Y))
+		for i, y := range s.Y {
+			for j, x := range s.X[i*stride : (i+1)*stride] {
+				fitted[i] += m[j] * x
+			}
+			r2 := (y - fitted[i]) * (y - fitted[i])
+			if r2 == 0 || fitted[i] == 0 {
+				continue
+			}
+			logFit.X = append(logFit.X, math.Log(math.Abs(fitted[i])), 1)
+			logFit.Y = append(logFit.Y, math.Log(r2))
+		}
+		if len(logFit.Y) < 3 {
+			break
+		}
+		pm := Estimate(logFit, opts)
+		if pm == nil {
+			break
+		}
+		power = math.Min(math.Max(pm[0], 0), maxVarPower)
+
+		w := make([]float64, len(s.Y))
+		for i, f := range fitted {
+			w[i] = math.Pow(math.Abs(f), -power)
+			if math.IsInf(w[i], 0) || math.IsNaN(w[i]) {
+				w[i] = 0
+			}
+		}
+		ws = Weighted(s, w)
+		if m, err = Solve(ws, opts); err != nil {
+			return nil, ws, power, err
+		}
+	}
+	return m, ws, power, nil
+}
+
+// ReplicateWeights returns the weight of each observation in s for generalized
+// least squares, which is the inverse of the sample variance of the replicates
+// of its benchmark name, like those of go test -count.  Names without
+// replicates get the mean variance of those with them, and a variance of zero
+// is raised to the smallest positive variance, so that no observation takes
+// all of the weight.  If no name has replicates, the weights are all 1.
+func ReplicateWeights(s Sample) []float64 {
+	reps := make(map[string][]float64)
+	for i, name := range s.Names {
+		reps[name] = append(reps[name], s.Y[i])
+	}
+	vars := make(map[string]float64)
+	var pooled, smallest float64
+	for name, ys := range reps {
+		if len(ys) < 2 {
+			continue
+		}
+		m := mean(ys)
+		var ss float64
+		for _, y := range ys {
+			ss += (y - m) * (y - m)
+		}
+		v := ss / float64(len(ys)-1)
+		vars[name] = v
+		pooled += v
+		if v > 0 && (smallest == 0 || v < smallest) {
+			smallest = v
+		}
+	}
+
+	w := make([]float64, len(s.Y))
+	if smallest == 0 {
+		for i := range w {
+			w[i] = 1
+		}
+		return w
+	}
+	pooled /= float64(len(vars))
+	for i, name := range s.Names {
+		v, ok := vars[name]
+		if !ok {
+			v = pooled
+		}
+		w[i] = 1 / math.Max(v, smallest)
+	}
+	return w
+}
+
+// ExprWeights returns the weight of each observation of s given by w, an
+// expression of its input variables, like 1 / (N * N) when the variance of
+// the response grows with the square of N.  It returns an error if a weight is
+// negative or not finite.
+func ExprWeights(s Sample, w Expression) ([]float64, error) {
+	if len(s.Vars) != len(s.Y) {
+		return nil, errors.New("the sample has no input variables")
+	}
+	ws := make([]float64, len(s.Y))
+	for i, inputs := range s.Vars {
+		vars := make(map[string]float64, len(inputs))
+		for k, v := range inputs {
+			vars[k] = v
+		}
+		ws[i] = w.Eval(vars)
+		if !(ws[i] >= 0) || math.IsInf(ws[i], 0) {
+			return nil, fmt.Errorf("weight of %s is %g", s.Names[i], ws[i])
+		}
+	}
+	return ws, nil
+}
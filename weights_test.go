@@ -0,0 +1,99 @@
+// Copyright ©2016 Jonathan J Lawlor. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package benchls
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestVarPower(t *testing.T) {
+	// noise with a standard deviation proportional to the mean has a
+	// variance power of 2
+	rng := rand.New(rand.NewSource(1))
+	var s Sample
+	for i := 0; i < 200; i++ {
+		n := math.Pow(10, 1+5*rng.Float64())
+		mean := 3*n + 100
+		s.X = append(s.X, n, 1.0)
+		s.Y = append(s.Y, mean*(1+0.05*rng.NormFloat64()))
+	}
+	m, _, power, err := VarPower(s, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m == nil {
+		t.Fatal("expected a fit")
+	}
+	if math.Abs(power-2) > 0.5 {
+		t.Errorf("expected a variance power near 2, got %g", power)
+	}
+	if math.Abs(m[0]-3) > 0.05 {
+		t.Errorf("expected a slope near 3, got %g", m[0])
+	}
+}
+
+func TestReplicateWeights(t *testing.T) {
+	s := Sample{
+		Y:     []float64{9, 11, 90, 110, 50, 7, 7},
+		Names: []string{"Sort10", "Sort10", "Sort100", "Sort100", "Sort50", "Sort5", "Sort5"},
+	}
+	// the variances are 2, 200 and 0, which is raised to 2
+	want := []float64{0.5, 0.5, 0.005, 0.005, 3.0 / 202, 0.5, 0.5}
+	got := ReplicateWeights(s)
+	for i := range want {
+		if math.Abs(got[i]-want[i]) > 1e-12 {
+			t.Errorf("observation %d: expected weight %g, got %g", i, want[i], got[i])
+		}
+	}
+
+	s = Sample{Y: []float64{1, 2}, Names: []string{"Sort10", "Sort100"}}
+	for i, w := range ReplicateWeights(s) {
+		if w != 1 {
+			t.Errorf("observation %d: expected weight 1 without replicates, got %g", i, w)
+		}
+	}
+}
+
+func TestExprWeights(t *testing.T) {
+	w, err := NewExpression("1 / (N * N)", map[string]struct{}{"N": {}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := Sample{
+		Y:     []float64{1, 2, 3},
+		Names: []string{"Sort1", "Sort2", "Sort0"},
+		Vars:  []map[string]float64{{"N": 1}, {"N": 2}, {"N": 4}},
+	}
+	got, err := ExprWeights(s, w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range []float64{1, 0.25, 0.0625} {
+		if got[i] != want {
+			t.Errorf("observation %d: expected weight %g, got %g", i, want, got[i])
+		}
+	}
+
+	s.Vars[2]["N"] = 0
+	if _, err := ExprWeights(s, w); err == nil {
+		t.Error("expected an error for an infinite weight")
+	}
+}
+
+func TestVarPowerContext(t *testing.T) {
+	var s Sample
+	for n := 1.0; n <= 20; n++ {
+		s.X = append(s.X, n, 1.0)
+		s.Y = append(s.Y, (3*n+1)*(1+0.05*math.Sin(n)))
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if m, _, _, err := VarPowerContext(ctx, s, Options{}); m != nil || err != context.Canceled {
+		t.Errorf("expected a canceled fit, got %v, %v", m, err)
+	}
+}